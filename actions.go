@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// selected returns the test the per-test actions apply to: the test at the cursor,
+// or when there is no cursor, the most recent failure.
+func (m *model) selected() *node {
+	if m.cursor == nil {
+		return m.lastFailure
+	}
+	if !m.cursor.isTest {
+		return nil
+	}
+	return m.cursor
+}
+
+// runPattern returns a go test -run pattern which matches just the test.
+func runPattern(n *node) string {
+	var parts []string
+	for _, name := range strings.Split(n.testName(), "/") {
+		parts = append(parts, "^"+regexp.QuoteMeta(name)+"$")
+	}
+	return strings.Join(parts, "/")
+}
+
+// reproCommand returns a go test command which runs just the test.
+func reproCommand(n *node) string {
+	run := strings.ReplaceAll(runPattern(n), "'", `'\''`)
+	return fmt.Sprintf("go test -count=1 -run '%s' %s", run, n.pkg().name)
+}
+
+// copyToClipboard copies s to the clipboard, see writeClipboard.  The OSC 52
+// fallback is sent through the program, which owns the terminal while it runs.
+func copyToClipboard(s string) tea.Cmd {
+	return func() tea.Msg {
+		if copyWithTool(s) {
+			return nil
+		}
+		return tea.SetClipboard(s)()
+	}
+}
+
+// failingLinePattern matches the file and line of a failure in a test's output,
+// e.g. "foo_test.go:12: ".
+var failingLinePattern = regexp.MustCompile(`([\w./\\-]+\.go):(\d+)`)
+
+// failingLine returns the first file and line number in the output, or "" if
+// there is none.
+func failingLine(output string) (file, line string) {
+	matches := failingLinePattern.FindStringSubmatch(output)
+	if matches == nil {
+		return "", ""
+	}
+	return matches[1], matches[2]
+}
+
+// editFailure opens the test's failing line in $EDITOR.  Files are named relative to
+// the package directory in test output, so it's found with go list, in the returned
+// command, so the UI doesn't block on it.
+func editFailure(n *node) tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	file, line := failingLine(n.log)
+	if editor == "" || file == "" {
+		return nil
+	}
+	pkg := n.pkg().name
+	return func() tea.Msg {
+		if !filepath.IsAbs(file) {
+			out, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkg).Output()
+			if err != nil {
+				log.Println("error finding package dir:", err)
+				return nil
+			}
+			dir := strings.TrimSpace(string(out))
+			if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+				file = filepath.Join(dir, file)
+			}
+		}
+		// most editors accept +line
+		c := exec.Command("sh", "-c", editor+` "+$1" "$2"`, "sh", line, file)
+		return tea.ExecProcess(c, func(err error) tea.Msg {
+			if err != nil {
+				log.Println("error running editor:", err)
+			}
+			return nil
+		})()
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReproCommand(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "example.com/a"},
+		{Action: "run", Package: "example.com/a", Test: "TestA"},
+		{Action: "run", Package: "example.com/a", Test: "TestA/case_1.5"},
+		{Action: "output", Package: "example.com/a", Test: "TestA/case_1.5", Output: "    a_test.go:12: boom\n"},
+		{Action: "fail", Package: "example.com/a", Test: "TestA/case_1.5"},
+	}...)
+
+	n := m.selected()
+	assert.Equal(t, `go test -count=1 -run '^TestA$/^case_1\.5$' example.com/a`, reproCommand(n))
+	assert.Contains(t, n.log, "boom")
+
+	file, line := failingLine(n.log)
+	assert.Equal(t, "a_test.go", file)
+	assert.Equal(t, "12", line)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// annotation is a user's triage note on a failure, e.g. "known" or
+// "investigating".
+type annotation struct {
+	Note    string
+	Package string
+	Test    string
+	Time    time.Time
+}
+
+// annotations are keyed by failure signature.
+type annotations map[string]annotation
+
+// annotationsPath returns the location of the annotations file.  Can be
+// overridden with $GOTESTPRETTY_ANNOTATIONS.
+func annotationsPath() (string, error) {
+	return cachePath("GOTESTPRETTY_ANNOTATIONS", "annotations.json")
+}
+
+func loadAnnotations() (annotations, error) {
+	path, err := annotationsPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return annotations{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a := annotations{}
+	return a, json.Unmarshal(b, &a)
+}
+
+func (a annotations) save() error {
+	path, err := annotationsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// volatilePattern matches the parts of test output which change from run to run,
+// like durations and pointer addresses.
+var volatilePattern = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+(\.\d+)?(ns|µs|us|ms|s|m|h)\b`)
+
+// failureSignature hashes the test name and its output, with volatile parts
+// removed, so the same failure can be recognized in later runs.
+func failureSignature(pkg, test string, output *bytes.Buffer) string {
+	h := sha256.New()
+	h.Write([]byte(pkg + "\x00" + test + "\x00"))
+	if output != nil {
+		h.Write(volatilePattern.ReplaceAll(output.Bytes(), nil))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// annotate sets the note on the last failed test.  If the failure already
+// has that note, the note is cleared instead.
+func (m *model) annotate(note string) {
+	n := m.selected()
+	if n == nil || n.signature == "" {
+		// only failures can be annotated
+		return
+	}
+	if m.annotations == nil {
+		m.annotations = annotations{}
+	}
+
+	if n.annotation == note {
+		n.annotation = ""
+		delete(m.annotations, n.signature)
+	} else {
+		n.annotation = note
+		m.annotations[n.signature] = annotation{
+			Note:    note,
+			Package: n.pkg().name,
+			Test:    n.name,
+			Time:    time.Now(),
+		}
+	}
+
+	if err := m.annotations.save(); err != nil {
+		log.Println("error saving annotations:", err)
+	}
+}
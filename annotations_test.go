@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureSignature(t *testing.T) {
+	a := failureSignature("a", "TestA", bytes.NewBufferString("--- FAIL: TestA (0.01s)\n    oops at 0xc000123\n"))
+	b := failureSignature("a", "TestA", bytes.NewBufferString("--- FAIL: TestA (1.52s)\n    oops at 0xc000456\n"))
+	c := failureSignature("a", "TestA", bytes.NewBufferString("--- FAIL: TestA (0.01s)\n    different\n"))
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.NotEqual(t, a, failureSignature("b", "TestA", bytes.NewBufferString("--- FAIL: TestA (0.01s)\n    oops at 0xc000123\n")))
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Setenv("GOTESTPRETTY_ANNOTATIONS", filepath.Join(t.TempDir(), "annotations.json"))
+
+	events := []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+	}
+
+	m := newModel()
+	feed(m, events...)
+	m.annotate("known")
+	assert.Equal(t, "known", m.lastFailure.annotation)
+
+	// a later run should see the annotation on the same failure
+	m = newModel()
+	var err error
+	m.annotations, err = loadAnnotations()
+	require.NoError(t, err)
+	feed(m, events...)
+	assert.Equal(t, "known", m.lastFailure.annotation)
+
+	// annotating again with the same note clears it
+	m.annotate("known")
+	assert.Equal(t, "", m.lastFailure.annotation)
+	a, err := loadAnnotations()
+	require.NoError(t, err)
+	assert.Empty(t, a)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+)
+
+// asciiReplacer transliterates the icons and symbols gotestpretty uses.
+var asciiReplacer = strings.NewReplacer(
+	"✓", "[PASS]",
+	"✖", "[FAIL]",
+	"✗", "[XFAIL]",
+	"⍉", "[SKIP]",
+	"⊘", "[ABORT]",
+	"☠", "[PANIC]",
+	"⚒", "[BUILD]",
+	"⇄", "[RACE]",
+	"◌", "[WAIT]",
+	"⏸", "[PAUSE]",
+	"…", "...",
+	"←", "<-",
+	"›", ">",
+	"⚠", "!",
+	"·", ".",
+	"¦", "|",
+	"µs", "us",
+	"▁", "_", "▂", "_", "▃", "-", "▄", "-", "▅", "=", "▆", "=", "▇", "#", "█", "#", "░", ".",
+)
+
+// toASCII transliterates s to ASCII for -ascii, for consoles which mangle unicode.
+// Any other non-ASCII characters, e.g. in test output, are replaced with "?".
+func toASCII(s string) string {
+	s = asciiReplacer.Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r > 127 {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// consoleText applies -ascii to text for the console or reports.
+func consoleText(s string) string {
+	if flags.ascii {
+		return toASCII(s)
+	}
+	return s
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToASCII(t *testing.T) {
+	assert.Equal(t, "[PASS] TestA\t12us", toASCII("✓ TestA\t12µs"))
+	assert.Equal(t, "[FAIL] TestB  caused 1 skip\n[SKIP] TestC  <- TestB failed", toASCII("✖ TestB  caused 1 skip\n⍉ TestC  ← TestB failed"))
+	assert.Equal(t, "got: ?? world", toASCII("got: 你好 world"))
+}
+
+func TestASCIISummary(t *testing.T) {
+	keepFlags(t)
+	flags.ascii = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a"},
+	}...)
+	m.done = true
+
+	out := consoleText(m.String())
+	assert.Contains(t, out, "[FAIL] a")
+	assert.Contains(t, out, "  [FAIL] TestA")
+	for _, r := range out {
+		assert.Less(t, r, rune(128))
+	}
+}
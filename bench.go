@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// benchResult is a benchmark's result line, e.g.
+// "BenchmarkFoo-8 \t 1000\t 1050 ns/op\t 16 B/op\t 1 allocs/op".
+type benchResult struct {
+	iterations int64
+	// metrics are the measurements, e.g. "1050 ns/op", in the order they were reported
+	metrics []string
+}
+
+// parseBenchLine parses a benchmark result line.
+func parseBenchLine(s string) (benchResult, bool) {
+	fields := strings.Split(strings.TrimRight(s, "\n"), "\t")
+	if len(fields) < 3 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return benchResult{}, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+	if err != nil {
+		return benchResult{}, false
+	}
+	r := benchResult{iterations: n}
+	for _, f := range fields[2:] {
+		if f = strings.Join(strings.Fields(f), " "); f != "" {
+			r.metrics = append(r.metrics, f)
+		}
+	}
+	return r, true
+}
+
+func (r *benchResult) String() string {
+	return strings.Join(append([]string{strconv.FormatInt(r.iterations, 10)}, r.metrics...), "  ")
+}
+
+// finishBench records a benchmark's result.  test2json doesn't report when a
+// benchmark passes, so it's finished once its result line is seen.
+func (m *model) finishBench(n *node, r benchResult) {
+	n.bench = &r
+	n.status = "bench"
+	n.done = true
+	n.doneTs = now()
+	if !n.start.IsZero() {
+		n.elapsed += since(n.start)
+		n.start = time.Time{}
+	}
+	m.benchmarks = append(m.benchmarks, n)
+	n.parent.processChildren(false, false)
+}
+
+// finishBenchmarks finishes the benchmarks in a finished package which never
+// reported a result of their own, e.g. benchmarks which only ran sub-benchmarks.
+func finishBenchmarks(n *node) {
+	for _, c := range n.children {
+		finishBenchmarks(c)
+		if !c.done && strings.HasPrefix(c.testName(), "Benchmark") {
+			c.status = "bench"
+			c.done = true
+			c.doneTs = now()
+			c.start = time.Time{}
+		}
+	}
+}
+
+func renderBenchmarks(m *model) string {
+	if len(m.benchmarks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Benchmarks:\n")
+	var width int
+	for _, n := range m.benchmarks {
+		width = max(width, len(n.pkg().name)+len(n.testName())+1)
+	}
+	// names are padded to the same width, so only the numbers are aligned right,
+	// like go test's output.  The cell padding indents the names.
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', tabwriter.AlignRight)
+	for _, n := range m.benchmarks {
+		fmt.Fprintf(tw, "%-*s\t%d\t", width, n.pkg().name+" "+n.testName(), n.bench.iterations)
+		for _, metric := range n.bench.metrics {
+			fmt.Fprintf(tw, "%s\t", metric)
+		}
+		fmt.Fprintln(tw)
+	}
+	_ = tw.Flush()
+	return sb.String()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBenchLine(t *testing.T) {
+	r, ok := parseBenchLine("BenchmarkFoo/sub-8         \t     100\t         2.140 ns/op\t       0 B/op\t       0 allocs/op\n")
+	require.True(t, ok)
+	assert.Equal(t, int64(100), r.iterations)
+	assert.Equal(t, []string{"2.140 ns/op", "0 B/op", "0 allocs/op"}, r.metrics)
+	assert.Equal(t, "100  2.140 ns/op  0 B/op  0 allocs/op", r.String())
+
+	_, ok = parseBenchLine("BenchmarkFoo\n")
+	assert.False(t, ok)
+	_, ok = parseBenchLine("    foo_test.go:12: Benchmark\tfoo\tbar\n")
+	assert.False(t, ok)
+}
+
+func TestBenchmarks(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "BenchmarkA"},
+		{Action: "output", Package: "a", Test: "BenchmarkA", Output: "BenchmarkA\n"},
+		{Action: "output", Package: "a", Test: "BenchmarkA", Output: "BenchmarkA \t     100\t        48.32 ns/op\n"},
+		{Action: "bench", Package: "a", Test: "BenchmarkA"},
+		{Action: "run", Package: "a", Test: "BenchmarkB"},
+		{Action: "run", Package: "a", Test: "BenchmarkB/sub"},
+		{Action: "output", Package: "a", Test: "BenchmarkB/sub", Output: "BenchmarkB/sub \t     100\t         2.140 ns/op\t       0 B/op\n"},
+		{Action: "pass", Package: "a", Elapsed: 1},
+	}...)
+
+	pkg := m.root.children[0]
+	benchB, _ := pkg.findChild([]string{"BenchmarkB"})
+	require.NotNil(t, benchB)
+	assert.True(t, benchB.done, "benchmarks are finished with their package")
+	assert.Equal(t, 0, m.total, "benchmarks aren't counted as tests")
+
+	out := m.String()
+	assert.NotContains(t, out, "???")
+	assert.Regexp(t, `✓ BenchmarkA\t\S+\t100  48.32 ns/op`, out)
+	assert.Equal(t, "Benchmarks:\n  a BenchmarkA      100  48.32 ns/op\n  a BenchmarkB/sub  100  2.140 ns/op  0 B/op\n", renderBenchmarks(m))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// expectedDurations returns the duration of each package in its most recent run in
+// the history, estimated as the sum of its top level tests' durations.
+func expectedDurations(runs []historyRun) map[string]time.Duration {
+	expected := map[string]time.Duration{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		durations := map[string]time.Duration{}
+		for _, r := range runs[i].Results {
+			if strings.Contains(r.Test, "/") {
+				continue
+			}
+			durations[r.Package] += r.Elapsed
+		}
+		for pkg, d := range durations {
+			if _, ok := expected[pkg]; !ok {
+				expected[pkg] = d
+			}
+		}
+	}
+	return expected
+}
+
+// projectedElapsed estimates the total time of the run: the time so far, plus the
+// expected remaining time of the packages which are still running or haven't started.
+// go test runs GOMAXPROCS packages at once by default, so the remaining time is
+// assumed to be split across that many.
+func (m *model) projectedElapsed() time.Duration {
+	started := map[string]*node{}
+	for _, n := range m.root.children {
+		started[n.name] = n
+	}
+	var remaining time.Duration
+	for pkg, d := range m.expected {
+		n, ok := started[pkg]
+		switch {
+		case !ok:
+			remaining += d
+		case !n.done:
+			remaining += max(d-scaledTimeSince(n.firstStart), 0)
+		}
+	}
+	return scaledTimeSince(m.start) + remaining/time.Duration(runtime.GOMAXPROCS(0))
+}
+
+// budgetWarning returns a warning if the run is projected to exceed -time-budget,
+// or "".
+func (m *model) budgetWarning() string {
+	if flags.timeBudget <= 0 {
+		return ""
+	}
+	projected := m.projectedElapsed()
+	if projected <= flags.timeBudget {
+		return ""
+	}
+	return failedText.Render(fmt.Sprintf("⚠ projected %s, over the %s budget", round(projected, 0), flags.timeBudget))
+}
+
+// checkBudget stops go test from starting any more packages once the run is
+// projected to exceed the budget, with -stop-over-budget.
+func (m *model) checkBudget() {
+	if !flags.stopOverBudget || flags.timeBudget <= 0 || goTest == nil || m.rerunning {
+		return
+	}
+	if m.projectedElapsed() > flags.timeBudget {
+		goTest.stopStarting()
+	}
+}
+
+// notRun returns the packages from the history which haven't run, and weren't
+// skipped by -stop-over-budget.
+func (m *model) notRun() []string {
+	var pkgs []string
+	for pkg := range m.expected {
+		if m.findPackage(pkg) == nil && !slices.Contains(m.notStarted, pkg) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	slices.Sort(pkgs)
+	return pkgs
+}
+
+func (m *model) findPackage(name string) *node {
+	for _, n := range m.root.children {
+		if n.name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+func renderBudget(m *model) string {
+	if flags.timeBudget <= 0 {
+		return ""
+	}
+	elapsed := scaledTimeSince(m.start)
+	if !m.end.IsZero() {
+		elapsed = m.end.Sub(m.start)
+	}
+	var sb strings.Builder
+	if elapsed > flags.timeBudget {
+		sb.WriteString(failedText.Render(fmt.Sprintf("The run took %s, over the %s budget", round(elapsed, 0), flags.timeBudget)))
+		sb.WriteString("\n")
+		if pkgs := m.notRun(); len(pkgs) > 0 {
+			sb.WriteString("Packages in previous runs which didn't run:\n")
+			m.renderExpected(&sb, pkgs)
+		}
+	}
+	if len(m.notStarted) > 0 {
+		sb.WriteString(failedText.Render(fmt.Sprintf("%d packages weren't run, to stay within the %s budget:", len(m.notStarted), flags.timeBudget)))
+		sb.WriteString("\n")
+		m.renderExpected(&sb, m.notStarted)
+	}
+	return sb.String()
+}
+
+// renderExpected lists the packages, with their expected durations if known.
+func (m *model) renderExpected(sb *strings.Builder, pkgs []string) {
+	for _, pkg := range pkgs {
+		if d, ok := m.expected[pkg]; ok {
+			fmt.Fprintf(sb, "  %s\t%s\n", pkg, gray.Render("~"+round(d, 0).String()))
+		} else {
+			fmt.Fprintf(sb, "  %s\n", pkg)
+		}
+	}
+}
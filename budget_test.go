@@ -0,0 +1,89 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectedDurations(t *testing.T) {
+	runs := []historyRun{
+		{Results: []historyResult{
+			{Package: "a", Test: "TestA", Elapsed: time.Second},
+			{Package: "c", Test: "TestC", Elapsed: 5 * time.Second},
+		}},
+		{Results: []historyResult{
+			{Package: "a", Test: "TestA", Elapsed: 2 * time.Second},
+			{Package: "a", Test: "TestA/sub", Elapsed: 2 * time.Second},
+			{Package: "a", Test: "TestB", Elapsed: time.Second},
+			{Package: "b", Test: "TestB", Elapsed: 4 * time.Second},
+		}},
+	}
+
+	assert.Equal(t, map[string]time.Duration{
+		"a": 3 * time.Second,
+		"b": 4 * time.Second,
+		"c": 5 * time.Second,
+	}, expectedDurations(runs))
+}
+
+func TestTimeBudget(t *testing.T) {
+	keepFlags(t)
+	flags.timeBudget = time.Minute
+
+	start := time.Now()
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	procs := time.Duration(runtime.GOMAXPROCS(0))
+	m := newModel()
+	m.expected = map[string]time.Duration{
+		"a": 10 * time.Second * procs,
+		"b": 2 * time.Minute * procs,
+	}
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	assert.Contains(t, m.budgetWarning(), "projected 2m10s")
+
+	m.processEvent(TestEvent{Action: "start", Package: "b"})
+	m.processEvent(TestEvent{Action: "pass", Package: "b"})
+	assert.Empty(t, m.budgetWarning())
+	assert.Empty(t, m.notRun())
+
+	now = func() time.Time { return start.Add(2 * time.Minute) }
+	m.done = true
+	assert.Contains(t, renderBudget(m), "The run took 2m0s, over the 1m0s budget")
+}
+
+func TestStopOverBudget(t *testing.T) {
+	keepFlags(t)
+	flags.timeBudget, flags.stopOverBudget = time.Minute, true
+
+	start := time.Now()
+	now = func() time.Time { return start }
+	t.Cleanup(func() { now = time.Now })
+
+	oldGoTest := goTest
+	goTest = &goTestRun{}
+	t.Cleanup(func() { goTest = oldGoTest })
+
+	procs := time.Duration(runtime.GOMAXPROCS(0))
+	m := newModel()
+	m.expected = map[string]time.Duration{
+		"a": 10 * time.Second * procs,
+		"b": 2 * time.Minute * procs,
+	}
+	m.Update(TestEvent{Action: "start", Package: "a"})
+	assert.True(t, goTest.stopping.Load())
+
+	// the run reports the packages it didn't start
+	m.Update(TestEvent{Action: "pass", Package: "a"})
+	goTest.skipped = []string{"b"}
+	m.Update(Done{})
+	assert.Equal(t, []string{"b"}, m.notStarted)
+	assert.True(t, m.overallFail)
+	assert.Contains(t, renderBudget(m), "1 packages weren't run, to stay within the 1m0s budget")
+	assert.Contains(t, renderBudget(m), "  b\t")
+	assert.Empty(t, m.notRun())
+}
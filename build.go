@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// buildState collects compiler and vet output, so it can be shown under the
+// package which failed to build.  go 1.24 and later report it as build-output
+// events, keyed by import path.  Older versions print it to stderr, which is
+// only seen if it's piped in too, as unattributed lines following a "# pkg" header.
+type buildState struct {
+	output map[string]string
+	// pending is the unattributed build output since the last build failure, and
+	// capturing is set after a "# pkg" header, until the next test event
+	pending   strings.Builder
+	capturing bool
+}
+
+// buildEvent handles the build-output and build-fail events.
+func (m *model) buildEvent(ev TestEvent) {
+	if ev.Action != "build-output" {
+		return
+	}
+	if m.build.output == nil {
+		m.build.output = map[string]string{}
+	}
+	m.build.output[ev.ImportPath] += ev.Output
+}
+
+// captureBuildOutput returns true if the unattributed line is build output from an
+// older version of go, and keeps it for the package which failed to build.
+func (m *model) captureBuildOutput(line string) bool {
+	if strings.HasPrefix(line, "# ") {
+		m.build.capturing = true
+	}
+	if !m.build.capturing {
+		return false
+	}
+	m.build.pending.WriteString(line + "\n")
+	return true
+}
+
+// buildFailed marks a package which failed to build, or failed vet, and adds the
+// compiler's output to the package's output.
+func (m *model) buildFailed(n *node, ev TestEvent) {
+	var output string
+	if ev.FailedBuild != "" {
+		output = m.build.output[ev.FailedBuild]
+	} else {
+		// older versions of go print the output of a package's failed dependencies
+		// just before it fails, so the pending output is all attributed to it
+		output = m.build.pending.String()
+		m.build.pending.Reset()
+	}
+
+	n.buildFailure = "build failed"
+	if strings.Contains(output, "\n# [") {
+		// vet's output is headed by the package name in brackets
+		n.buildFailure = "vet failed"
+		n.msg = strings.Replace(n.msg, "[build failed]", "[vet failed]", 1)
+	}
+	m.buildFails++
+
+	if output != "" {
+		buf := bytes.NewBufferString(output)
+		if n.outputBuf != nil {
+			_, _ = n.outputBuf.WriteTo(buf)
+		}
+		n.outputBuf = buf
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFailed(t *testing.T) {
+	m := newModel()
+	for _, line := range []string{
+		`{"ImportPath":"bf/a [bf/a.test]","Action":"build-output","Output":"# bf/a [bf/a.test]\n"}`,
+		`{"ImportPath":"bf/a [bf/a.test]","Action":"build-output","Output":"a/a.go:2:23: undefined: x\n"}`,
+		`{"ImportPath":"bf/a [bf/a.test]","Action":"build-fail"}`,
+		`{"Action":"start","Package":"bf/a"}`,
+		`{"Action":"output","Package":"bf/a","Output":"FAIL\tbf/a [build failed]\n","OutputType":"frame"}`,
+		`{"Action":"fail","Package":"bf/a","Elapsed":0,"FailedBuild":"bf/a [bf/a.test]"}`,
+		`{"ImportPath":"vf/c [vf/c.test]","Action":"build-output","Output":"# vf/c\n"}`,
+		`{"ImportPath":"vf/c [vf/c.test]","Action":"build-output","Output":"# [vf/c]\n"}`,
+		`{"ImportPath":"vf/c [vf/c.test]","Action":"build-output","Output":"c/c_test.go:3:39: fmt.Printf format %d has arg \"x\" of wrong type string\n"}`,
+		`{"ImportPath":"vf/c [vf/c.test]","Action":"build-fail"}`,
+		`{"Action":"start","Package":"vf/c"}`,
+		`{"Action":"output","Package":"vf/c","Output":"FAIL\tvf/c [build failed]\n","OutputType":"frame"}`,
+		`{"Action":"fail","Package":"vf/c","Elapsed":0,"FailedBuild":"vf/c [vf/c.test]"}`,
+	} {
+		ev, err := decodeEvent([]byte(line))
+		require.NoError(t, err, line)
+		m.processEvent(ev)
+	}
+
+	require.Len(t, m.root.children, 2, "build events don't create package nodes")
+	a, c := m.root.children[0], m.root.children[1]
+	assert.Equal(t, "build failed", a.buildFailure)
+	assert.Equal(t, "vet failed", c.buildFailure)
+	assert.Contains(t, c.msg, "[vet failed]")
+	assert.Equal(t, 2, m.buildFails)
+	assert.Contains(t, m.String(), "0 tests, 2 packages failed to build in")
+}
+
+func TestBuildFailedOldGo(t *testing.T) {
+	m := newModel()
+	m.Update(Unattributed("some other output"))
+	m.Update(Unattributed("# bf/a [bf/a.test]"))
+	m.Update(Unattributed("a/a.go:2:23: undefined: x"))
+	feed(m, []TestEvent{
+		{Action: "start", Package: "bf/a"},
+		{Action: "output", Package: "bf/a", Output: "FAIL\tbf/a [build failed]\n"},
+	}...)
+	m.Update(Unattributed("not build output"))
+	m.processEvent(TestEvent{Action: "fail", Package: "bf/a"})
+
+	a := m.root.children[0]
+	assert.Equal(t, "build failed", a.buildFailure)
+	assert.Equal(t, "# bf/a [bf/a.test]\na/a.go:2:23: undefined: x\nFAIL\tbf/a [build failed]\n", a.log)
+	assert.Equal(t, 2, m.unattributed.total, "only the lines after a header, until the next event, are build output")
+}
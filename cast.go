@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// castFrameInterval is the minimum time between frames in an exported cast.
+const castFrameInterval = 250 * time.Millisecond
+
+// castHeader is the header line of an asciinema v2 cast file.
+type castHeader struct {
+	Version   int   `json:"version"`
+	Width     int   `json:"width"`
+	Height    int   `json:"height"`
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+func exportCastFile(path string) error {
+	var width, height int
+	if _, err := fmt.Sscanf(flags.castSize, "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid -cast-size %q, should be <width>x<height>", flags.castSize)
+	}
+
+	r, err := openInput()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := exportCast(r, w, width, height); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// exportCast replays a recorded `go test -json` stream through the model, and writes
+// snapshots of the live view to w as an asciinema v2 cast.  The model's clock
+// follows the event timestamps, so elapsed times match the original run.
+func exportCast(r io.Reader, w io.Writer, width, height int) error {
+	var simTime time.Time
+	now = func() time.Time { return simTime }
+	defer func() { now = time.Now }()
+
+	m := newModel()
+	m.windowHeight = height
+
+	enc := json.NewEncoder(w)
+	var start, lastFrameTs time.Time
+	var lastFrame string
+
+	frame := func(view string) error {
+		if view == lastFrame {
+			return nil
+		}
+		lastFrame = view
+		lastFrameTs = simTime
+
+		offset := simTime.Sub(start).Seconds()
+		if flags.rate > 0 {
+			offset *= flags.rate
+		}
+		// clear the screen and redraw from the top left
+		data := "\x1b[H\x1b[2J" + strings.ReplaceAll(view, "\n", "\r\n")
+		return enc.Encode([]any{offset, "o", data})
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64*1024*1024)
+	for s.Scan() {
+		ev, err := decodeEvent(s.Bytes())
+		if err != nil || ev.Time.IsZero() {
+			continue
+		}
+
+		if start.IsZero() {
+			start, simTime = ev.Time, ev.Time
+			m.start = start
+			if err := enc.Encode(castHeader{Version: 2, Width: width, Height: height, Timestamp: start.Unix()}); err != nil {
+				return err
+			}
+		}
+
+		// render frames for the time between events, so running timers advance
+		for lastFrameTs.Add(castFrameInterval).Before(ev.Time) && !lastFrameTs.IsZero() {
+			simTime = lastFrameTs.Add(castFrameInterval)
+			lastFrameTs = simTime
+			if err := frame(m.renderSafely(true)); err != nil {
+				return err
+			}
+		}
+
+		simTime = ev.Time
+		// the commands only print package output above the view, which isn't part of the cast
+		_ = m.processEvent(ev)
+
+		if lastFrameTs.IsZero() || simTime.Sub(lastFrameTs) >= castFrameInterval {
+			if err := frame(m.renderSafely(true)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if start.IsZero() {
+		return fmt.Errorf("no test events found")
+	}
+
+	m.done = true
+	m.end = simTime
+	m.root.processChildren(true, true)
+	return frame(m.String() + "\n")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCast(t *testing.T) {
+	in := `{"Time":"2024-08-31T18:12:41.0Z","Action":"start","Package":"a"}
+{"Time":"2024-08-31T18:12:41.1Z","Action":"run","Package":"a","Test":"TestA"}
+{"Time":"2024-08-31T18:12:43.1Z","Action":"pass","Package":"a","Test":"TestA","Elapsed":2}
+{"Time":"2024-08-31T18:12:43.2Z","Action":"pass","Package":"a","Elapsed":2.2}
+`
+	var sb strings.Builder
+	require.NoError(t, exportCast(strings.NewReader(in), &sb, 80, 10))
+
+	s := bufio.NewScanner(strings.NewReader(sb.String()))
+	require.True(t, s.Scan())
+	var header castHeader
+	require.NoError(t, json.Unmarshal(s.Bytes(), &header))
+	assert.Equal(t, castHeader{Version: 2, Width: 80, Height: 10, Timestamp: 1725127961}, header)
+
+	var frames [][]any
+	for s.Scan() {
+		var frame []any
+		require.NoError(t, json.Unmarshal(s.Bytes(), &frame))
+		frames = append(frames, frame)
+	}
+	require.NotEmpty(t, frames)
+	assert.Equal(t, 0.0, frames[0][0])
+
+	// the clock follows the event timestamps, so the running test's timer shows up
+	var sawTimer bool
+	for _, f := range frames {
+		if strings.Contains(f[2].(string), "TestA\t1") {
+			sawTimer = true
+		}
+	}
+	assert.True(t, sawTimer, "running test should show elapsed time")
+
+	last := frames[len(frames)-1]
+	assert.InDelta(t, 2.2, last[0], 0.001)
+	assert.Contains(t, last[2], "PASSED 1 tests in 2.2s")
+}
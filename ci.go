@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// formats are the valid values of -format.
+var formats = []string{"auto", "tui", "ci"}
+
+// ciFormat returns true if the results should be streamed as plain lines, rather
+// than shown in the live view: with -format=ci, or when stdout isn't a terminal.
+func ciFormat() bool {
+	switch flags.format {
+	case "ci":
+		return true
+	case "tui":
+		return false
+	}
+	return !isTerminal(os.Stdout)
+}
+
+// ciOutput runs the input through the model without the live view, printing a
+// line as each test and package finishes, with no spinners or cursor movement, so
+// the output reads well in CI logs.
+type ciOutput struct {
+	mu   sync.Mutex
+	msgs []tea.Msg
+	// ready is signaled when msgs are added
+	ready chan struct{}
+}
+
+func newCIOutput() *ciOutput {
+	return &ciOutput{ready: make(chan struct{}, 1)}
+}
+
+func (c *ciOutput) Send(msg tea.Msg) {
+	c.mu.Lock()
+	c.msgs = append(c.msgs, msg)
+	c.mu.Unlock()
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run processes the input until the run is done.
+func (c *ciOutput) run(m *model) {
+	for !m.done && m.err == nil {
+		<-c.ready
+		c.mu.Lock()
+		msgs := c.msgs
+		c.msgs = nil
+		c.mu.Unlock()
+
+		for _, msg := range msgs {
+			_, cmd := m.Update(msg)
+			runCmd(cmd)
+			if ev, ok := msg.(TestEvent); ok {
+				if line := m.progressLine(ev); line != "" {
+					fmt.Println(consoleText(line))
+				}
+			}
+		}
+	}
+	if m.err != nil {
+		fmt.Println(m.err)
+	}
+}
+
+// progressLine returns the line printed in the ci format when a test or package
+// finishes, or "" if the event didn't finish one.
+func (m *model) progressLine(ev TestEvent) string {
+	switch ev.Action {
+	case "pass", "fail", "skip":
+	default:
+		return ""
+	}
+	n := m.eventNode
+	if n == nil || !n.done {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(m.icon(n) + " " + ev.Package)
+	if ev.Test != "" {
+		sb.WriteString(" " + ev.Test)
+	}
+	if elapsed := formatElapsed(n.elapsed, 0, 3); elapsed != "" {
+		sb.WriteString(" (" + elapsed + ")")
+	}
+	if n.msg != "" && !n.isTest {
+		sb.WriteString("  " + n.msg)
+	}
+	return sb.String()
+}
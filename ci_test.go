@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIFormat(t *testing.T) {
+	keepFlags(t)
+	flags.format = "ci"
+	assert.True(t, ciFormat())
+	flags.format = "tui"
+	assert.False(t, ciFormat())
+}
+
+func TestProgressLine(t *testing.T) {
+	m := newModel()
+	lines := map[string]string{}
+	for _, ev := range []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 1.5},
+		{Action: "output", Package: "a", Output: "ok  \ta\t1.6s\n"},
+		{Action: "pass", Package: "a", Elapsed: 1.6},
+	} {
+		m.processEvent(ev)
+		if line := m.progressLine(ev); line != "" {
+			lines[ev.Test] = line
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"TestA": "✓ a TestA (1.5s)",
+		"":      "✓ a (1.6s)",
+	}, lines)
+}
+
+func TestCIOutput(t *testing.T) {
+	m := newModel()
+	ci := newCIOutput()
+	m.input = ci
+	for _, ev := range []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a"},
+	} {
+		ci.Send(ev)
+	}
+	ci.Send(Done{})
+
+	ci.run(m)
+	assert.True(t, m.done)
+	assert.Equal(t, 1, m.fails)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// ciGroupStyles are the valid values of -ci-groups.
+var ciGroupStyles = []string{"auto", "github", "gitlab", "none"}
+
+// ciGroupStyle returns the style of the collapsible groups the output of each
+// failed top level test is wrapped in, so long CI logs can be navigated.  "auto"
+// picks the CI the run is in, if any.
+func ciGroupStyle() string {
+	if flags.ciGroups != "auto" {
+		return flags.ciGroups
+	}
+	switch {
+	case githubActions():
+		return "github"
+	case os.Getenv("GITLAB_CI") == "true":
+		return "gitlab"
+	}
+	return "none"
+}
+
+// ciGroup returns the lines which start and end the collapsible group for a test's
+// output, or "" if it isn't a failed top level test, or groups are off.
+func (m *model) ciGroup(n *node) (start, end string) {
+	if n.lvl != 2 || n.status != "fail" {
+		return "", ""
+	}
+	title := "FAIL " + n.pkg().name + " " + escapeControl(n.name)
+	switch ciGroupStyle() {
+	case "github":
+		return "##[group]" + title + "\n", "##[endgroup]\n"
+	case "gitlab":
+		// section names may only have letters, digits, and _.-, and must be unique
+		m.ciGroups++
+		name := fmt.Sprintf("gotestpretty_failure_%d", m.ciGroups)
+		ts := now().Unix()
+		start = fmt.Sprintf("\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", ts, name, title)
+		end = fmt.Sprintf("\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", ts, name)
+		return start, end
+	}
+	return "", ""
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIGroups(t *testing.T) {
+	keepFlags(t)
+
+	run := func() string {
+		m := newModel()
+		var out string
+		for _, ev := range []TestEvent{
+			{Action: "start", Package: "a"},
+			{Action: "run", Package: "a", Test: "TestA"},
+			{Action: "run", Package: "a", Test: "TestA/sub"},
+			{Action: "output", Package: "a", Test: "TestA/sub", Output: "boom\n"},
+			{Action: "fail", Package: "a", Test: "TestA/sub"},
+			{Action: "fail", Package: "a", Test: "TestA"},
+			{Action: "run", Package: "a", Test: "TestB"},
+			{Action: "output", Package: "a", Test: "TestB", Output: "fine\n"},
+			{Action: "pass", Package: "a", Test: "TestB"},
+			{Action: "fail", Package: "a"},
+		} {
+			m.processEvent(ev)
+			if ev.Test == "" && ev.Action == "fail" {
+				out = m.root.children[0].log
+			}
+		}
+		return out
+	}
+
+	flags.ciGroups = "github"
+	out := run()
+	assert.Contains(t, out, "##[group]FAIL a TestA\n        boom\n##[endgroup]\n")
+	assert.Equal(t, 1, strings.Count(out, "##[group]"), "one group per top level test")
+
+	flags.ciGroups = "gitlab"
+	out = run()
+	assert.Regexp(t, `\x1b\[0Ksection_start:\d+:gotestpretty_failure_1\[collapsed=true\]\r\x1b\[0KFAIL a TestA\n        boom\n\x1b\[0Ksection_end:\d+:gotestpretty_failure_1\r\x1b\[0K\n`, out)
+
+	flags.ciGroups = "none"
+	assert.NotContains(t, run(), "group")
+}
+
+func TestCIGroupStyle(t *testing.T) {
+	keepFlags(t)
+	flags.ciGroups = "auto"
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "true")
+	assert.Equal(t, "gitlab", ciGroupStyle())
+	t.Setenv("GITHUB_ACTIONS", "true")
+	assert.Equal(t, "github", ciGroupStyle())
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+	assert.Equal(t, "none", ciGroupStyle())
+}
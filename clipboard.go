@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands are the platform clipboard tools, in the order they're tried.
+// The text is written to their stdin.
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip"}},
+	"linux":   {{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}},
+}
+
+// writeClipboard copies s to the clipboard with the platform's clipboard tool, or
+// if there isn't one, or we're in an ssh session, with the OSC 52 escape sequence
+// written to w, which most terminals support.
+func writeClipboard(s string, w io.Writer) error {
+	if copyWithTool(s) {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(s)))
+	return err
+}
+
+// copyWithTool copies s to the clipboard with the platform's clipboard tool, and
+// returns false if there isn't one, or we're in an ssh session, where the tool would
+// copy to the remote machine's clipboard.
+func copyWithTool(s string) bool {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return false
+	}
+	for _, args := range clipboardCommands[runtime.GOOS] {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(s)
+		if err := cmd.Run(); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// clipboardSummary is the plain text summary copied by -copy-summary, for pasting
+// into chat or a PR comment: the totals, and the failed tests.
+func clipboardSummary(s summary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%sED %d tests", strings.ToUpper(s.Result), s.Tests)
+	if s.Skipped > 0 {
+		fmt.Fprintf(&sb, ", %d skipped", s.Skipped)
+	}
+	if s.Failed > 0 {
+		fmt.Fprintf(&sb, ", %d failed", s.Failed)
+	}
+	fmt.Fprintf(&sb, " in %s", round(secondsDuration(s.Elapsed), 1))
+	if flags.timeFormat != "" && !s.Start.IsZero() {
+		fmt.Fprintf(&sb, ", started %s", formatTime(s.Start, ""))
+	}
+	sb.WriteString("\n")
+
+	var failed []string
+	for _, p := range s.Packages {
+		if p.Status == "fail" && len(p.Tests) == 0 {
+			// e.g. the package failed to build
+			failed = append(failed, escapeControl(p.Name))
+		}
+		for _, t := range p.Tests {
+			if t.Status == "fail" {
+				failed = append(failed, escapeControl(p.Name+" "+t.Name))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		sb.WriteString("\nFailed:\n")
+		for _, f := range failed {
+			sb.WriteString("  " + f + "\n")
+		}
+	}
+	return sb.String()
+}
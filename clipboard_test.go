@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipboardSummary(t *testing.T) {
+	s := summary{
+		Result:  "fail",
+		Elapsed: 2.5,
+		Tests:   3,
+		Passed:  1,
+		Failed:  2,
+		Packages: []summaryPackage{
+			{Name: "a", Status: "fail", Tests: []summaryTest{
+				{Name: "TestA", Status: "pass"},
+				{Name: "TestB", Status: "fail"},
+				{Name: "TestB/sub", Status: "fail"},
+			}},
+			{Name: "b", Status: "fail", Tests: []summaryTest{}},
+			{Name: "c", Status: "pass", Tests: []summaryTest{}},
+		},
+	}
+	assert.Equal(t, "FAILED 3 tests, 2 failed in 2.5s\n\nFailed:\n  a TestB\n  a TestB/sub\n  b\n", clipboardSummary(s))
+
+	s = summary{Result: "pass", Tests: 3, Skipped: 1, Elapsed: 1}
+	assert.Equal(t, "PASSED 3 tests, 1 skipped in 1s\n", clipboardSummary(s))
+}
+
+func TestWriteClipboardOverSSH(t *testing.T) {
+	// over ssh, the platform clipboard would be the remote machine's
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	var buf bytes.Buffer
+	require.NoError(t, writeClipboard("hi", &buf))
+	assert.Equal(t, "\x1b]52;c;aGk=\a", buf.String())
+}
+
+func TestCopyToClipboardOverSSH(t *testing.T) {
+	// the escape sequence is left to the program, rather than written to stdout
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	assert.Equal(t, tea.SetClipboard("hi")(), copyToClipboard("hi")())
+}
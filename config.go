@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// configPath returns the path of the config file, $GOTESTPRETTY_CONFIG, or
+// config.toml in $XDG_CONFIG_HOME/gotestpretty or ~/.config/gotestpretty.
+func configPath() (string, error) {
+	if p := os.Getenv("GOTESTPRETTY_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gotestpretty", "config.toml"), nil
+}
+
+// configSetting is a key and its values in the config file.  Arrays have more
+// than one value.
+type configSetting struct {
+	line   int
+	key    string
+	values []string
+}
+
+// parseConfig parses the subset of TOML the config file needs: tables, and keys set
+// to strings, numbers, booleans, and single line arrays of them.  Keys in tables are
+// prefixed with the table's name, like "colors.passed".
+func parseConfig(r io.Reader) ([]configSetting, error) {
+	var settings []configSetting
+	var table string
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(s.Text()))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if table != "" {
+			key = table + "." + key
+		}
+		values, err := parseConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		settings = append(settings, configSetting{line: lineNo, key: key, values: values})
+	}
+	return settings, s.Err()
+}
+
+// stripComment removes a trailing # comment, unless the # is in a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseConfigValue(v string) ([]string, error) {
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		var values []string
+		for _, elem := range splitArray(v[1 : len(v)-1]) {
+			if elem = strings.TrimSpace(elem); elem == "" {
+				continue
+			}
+			value, err := parseConfigScalar(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	value, err := parseConfigScalar(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+// splitArray splits the elements of an array on the commas which aren't in strings.
+func splitArray(s string) []string {
+	var elems []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0 && r == quote && (quote == '\'' || i == 0 || s[i-1] != '\\'):
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			elems = append(elems, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(elems, s[start:])
+}
+
+func parseConfigScalar(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", v)
+		}
+		return s, nil
+	case strings.HasPrefix(v, "'"):
+		if len(v) < 2 || !strings.HasSuffix(v, "'") {
+			return "", fmt.Errorf("invalid string %s", v)
+		}
+		return v[1 : len(v)-1], nil
+	case v == "true" || v == "false":
+		return v, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(v, "_", ""), 64); err != nil {
+		return "", fmt.Errorf("invalid value %s, strings must be quoted", v)
+	}
+	return strings.ReplaceAll(v, "_", ""), nil
+}
+
+// configColors are the colors which can be set in the config file's [colors] table,
+// for both light and dark backgrounds, or in [colors.light] and [colors.dark].
+var configColors = map[string]*lipgloss.AdaptiveColor{
+	"passed":  &colorPassed,
+	"skipped": &colorSkipped,
+	"failed":  &colorFailed,
+	"gray":    &colorGray,
+	"slow":    &colorOrange,
+}
+
+// applyConfig applies the settings in the config file: [colors] and [icons] to the
+// theme, and the rest to the flags, by name, as their defaults.
+func applyConfig(fs *flag.FlagSet, settings []configSetting) error {
+	for _, s := range settings {
+		table, name, _ := strings.Cut(s.key, ".")
+		if len(s.values) != 1 && (table == "colors" || table == "icons") {
+			return fmt.Errorf("line %d: %s must be a string", s.line, s.key)
+		}
+		switch table {
+		case "colors":
+			variant, name, _ := strings.Cut(name, ".")
+			if name == "" {
+				variant, name = "", variant
+			}
+			c, ok := configColors[name]
+			if !ok || (variant != "" && variant != "light" && variant != "dark") {
+				return fmt.Errorf("line %d: unknown color %q", s.line, s.key)
+			}
+			if variant != "dark" {
+				c.Light = s.values[0]
+			}
+			if variant != "light" {
+				c.Dark = s.values[0]
+			}
+			continue
+		case "icons":
+			if _, ok := glyphs[name]; !ok {
+				return fmt.Errorf("line %d: unknown icon %q", s.line, s.key)
+			}
+			glyphs[name] = s.values[0]
+			continue
+		}
+		if fs.Lookup(s.key) == nil {
+			return fmt.Errorf("line %d: unknown setting %q", s.line, s.key)
+		}
+		for _, v := range s.values {
+			if err := fs.Set(s.key, v); err != nil {
+				return fmt.Errorf("line %d: %s: %w", s.line, s.key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reservedEnv are the GOTESTPRETTY_* variables which name files, rather than set the
+// flags of the same name.
+var reservedEnv = map[string]bool{
+	"GOTESTPRETTY_HISTORY": true,
+	"GOTESTPRETTY_TIMINGS": true,
+}
+
+// envName returns the environment variable which sets a flag, e.g. GOTESTPRETTY_SLOW_THRESHOLD.
+func envName(flagName string) string {
+	return "GOTESTPRETTY_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets flags from their GOTESTPRETTY_* environment variables.
+func applyEnv(fs *flag.FlagSet, getenv func(string) string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		v := getenv(name)
+		if err != nil || v == "" || reservedEnv[name] {
+			return
+		}
+		if setErr := fs.Set(f.Name, v); setErr != nil {
+			err = fmt.Errorf("$%s: %w", name, setErr)
+		}
+	})
+	return err
+}
+
+// loadConfig sets the defaults of the flags, and the theme, from the config file and
+// the environment.  A missing config file is fine.
+func loadConfig(fs *flag.FlagSet) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		defer f.Close()
+		settings, err := parseConfig(f)
+		if err == nil {
+			err = applyConfig(fs, settings)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return applyEnv(fs, os.Getenv)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	settings, err := parseConfig(strings.NewReader(`# gotestpretty config
+slow-threshold = "2s"   # a comment
+include-passed = true
+top-slow = 1_000
+skip-cause = 'needs (docker|network)'
+sound = ["fail=bell", "done-fail=bell*3"]
+on-fail = "echo \"#fail\""
+
+[colors]
+passed = "10"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []configSetting{
+		{line: 2, key: "slow-threshold", values: []string{"2s"}},
+		{line: 3, key: "include-passed", values: []string{"true"}},
+		{line: 4, key: "top-slow", values: []string{"1000"}},
+		{line: 5, key: "skip-cause", values: []string{"needs (docker|network)"}},
+		{line: 6, key: "sound", values: []string{"fail=bell", "done-fail=bell*3"}},
+		{line: 7, key: "on-fail", values: []string{`echo "#fail"`}},
+		{line: 10, key: "colors.passed", values: []string{"10"}},
+	}, settings)
+
+	_, err = parseConfig(strings.NewReader("slow-threshold = 2s\n"))
+	assert.EqualError(t, err, "line 1: invalid value 2s, strings must be quoted")
+	_, err = parseConfig(strings.NewReader("\nslow-threshold\n"))
+	assert.EqualError(t, err, "line 2: expected key = value")
+}
+
+func TestApplyConfig(t *testing.T) {
+	defer func(c lipgloss.AdaptiveColor, g string) { colorFailed, glyphs["passed"] = c, g }(colorFailed, glyphs["passed"])
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	slow := fs.Duration("slow-threshold", time.Second, "")
+	passed := fs.Bool("include-passed", false, "")
+	var pins []string
+	fs.Func("pin", "", func(s string) error {
+		pins = append(pins, s)
+		return nil
+	})
+
+	settings, err := parseConfig(strings.NewReader(`
+slow-threshold = "2s"
+include-passed = true
+pin = ["a", "b"]
+
+[colors]
+failed = "9"
+
+[colors.light]
+failed = "88"
+
+[icons]
+passed = "ok"
+`))
+	require.NoError(t, err)
+	require.NoError(t, applyConfig(fs, settings))
+	assert.Equal(t, 2*time.Second, *slow)
+	assert.True(t, *passed)
+	assert.Equal(t, []string{"a", "b"}, pins)
+	assert.Equal(t, lipgloss.AdaptiveColor{Light: "88", Dark: "9"}, colorFailed)
+	assert.Equal(t, "ok", glyphs["passed"])
+
+	for in, want := range map[string]string{
+		"bogus = 1":                 `line 1: unknown setting "bogus"`,
+		"slow-threshold = \"fast\"": `line 1: slow-threshold: parse error`,
+		"[colors]\npurple = \"5\"":  `line 2: unknown color "colors.purple"`,
+		"[icons]\nrocket = \"^\"":   `line 2: unknown icon "icons.rocket"`,
+	} {
+		settings, err := parseConfig(strings.NewReader(in))
+		require.NoError(t, err)
+		assert.EqualError(t, applyConfig(fs, settings), want, in)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	slow := fs.Duration("slow-threshold", time.Second, "")
+	history := fs.Bool("history", false, "")
+	env := map[string]string{
+		"GOTESTPRETTY_SLOW_THRESHOLD": "3s",
+		// names the history file, not the flag
+		"GOTESTPRETTY_HISTORY": "/tmp/history.jsonl",
+	}
+	require.NoError(t, applyEnv(fs, func(k string) string { return env[k] }))
+	assert.Equal(t, 3*time.Second, *slow)
+	assert.False(t, *history)
+
+	// the command line overrides the environment
+	require.NoError(t, fs.Parse([]string{"-slow-threshold", "5s"}))
+	assert.Equal(t, 5*time.Second, *slow)
+
+	env["GOTESTPRETTY_SLOW_THRESHOLD"] = "slow"
+	assert.EqualError(t, applyEnv(fs, func(k string) string { return env[k] }), "$GOTESTPRETTY_SLOW_THRESHOLD: parse error")
+}
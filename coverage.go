@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// coverageThreshold is the minimum coverage for packages matching a pattern.
+type coverageThreshold struct {
+	pattern *regexp.Regexp
+	min     float64
+}
+
+// coverageThresholdFlag parses values of -min-coverage, like "internal/=80".
+func coverageThresholdFlag(s string) error {
+	pattern, minStr, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("must be <regex>=<percent>, got %q", s)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	minCov, err := strconv.ParseFloat(strings.TrimSuffix(minStr, "%"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid percent %q: %w", minStr, err)
+	}
+	flags.minCoverage = append(flags.minCoverage, coverageThreshold{pattern: re, min: minCov})
+	return nil
+}
+
+// coveragePattern matches the coverage go test reports for a package, e.g.
+// "coverage: 83.2% of statements".
+var coveragePattern = regexp.MustCompile(`coverage: ([\d.]+)% of statements`)
+
+// parseCoverage returns the coverage percent in a line of package output.
+func parseCoverage(s string) (float64, bool) {
+	matches := coveragePattern.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(matches[1], 64)
+	return f, err == nil
+}
+
+// minCoverage returns the coverage threshold for a package, from the first
+// matching -min-coverage.
+func minCoverage(pkg string) (float64, bool) {
+	for _, t := range flags.minCoverage {
+		if t.pattern.MatchString(pkg) {
+			return t.min, true
+		}
+	}
+	return 0, false
+}
+
+// checkCoverage checks a finished package against its coverage threshold.  Packages
+// below their threshold, or which didn't report coverage at all, fail the run.
+func (m *model) checkCoverage(pkg *node) {
+	if pkg.status != "pass" && pkg.status != "fail" {
+		// no test files
+		return
+	}
+	minCov, ok := minCoverage(pkg.name)
+	if !ok || (pkg.hasCoverage && pkg.coverage >= minCov) {
+		return
+	}
+	pkg.minCoverage = minCov
+	m.coverageViolations = append(m.coverageViolations, pkg)
+	m.overallFail = true
+}
+
+// coverageViolation returns the text marking a package below its coverage
+// threshold in the tree, or "".
+func (n *node) coverageViolation() string {
+	if n.minCoverage == 0 {
+		return ""
+	}
+	return fmt.Sprintf("coverage below %.1f%%", n.minCoverage)
+}
+
+// totalCoverage is the coverage of the whole run.
+type totalCoverage struct {
+	percent float64
+	// weighted is true if the percent is of all statements, from -coverprofile,
+	// rather than the average of the packages' percents
+	weighted bool
+	packages int
+}
+
+// totalCoverage returns the coverage of the run, weighted by statements if the
+// run's -coverprofile was given, or else averaged over the packages which reported
+// coverage.  ok is false if no coverage was reported.
+func (m *model) totalCoverage() (t totalCoverage, ok bool) {
+	if flags.coverProfile != "" {
+		f, err := os.Open(flags.coverProfile)
+		if err == nil {
+			defer f.Close()
+			covered, total, err := parseCoverProfile(f)
+			if err == nil && total > 0 {
+				return totalCoverage{percent: 100 * float64(covered) / float64(total), weighted: true}, true
+			}
+		}
+	}
+	var sum float64
+	for _, n := range m.root.children {
+		if n.hasCoverage {
+			sum += n.coverage
+			t.packages++
+		}
+	}
+	if t.packages == 0 {
+		return t, false
+	}
+	t.percent = sum / float64(t.packages)
+	return t, true
+}
+
+// parseCoverProfile returns the number of covered statements, and the total
+// number of statements, in a cover profile written by go test -coverprofile.
+// Blocks which appear more than once, e.g. with -coverpkg, are only counted once.
+func parseCoverProfile(r io.Reader) (covered, total int64, err error) {
+	blocks, err := parseCoverBlocks(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, b := range blocks {
+		total += b.stmts
+		if b.covered {
+			covered += b.stmts
+		}
+	}
+	return covered, total, nil
+}
+
+// coverBlock is a block of statements in a cover profile.
+type coverBlock struct {
+	// file is the file's import path, e.g. "example.com/pkg/file.go"
+	file       string
+	start, end int
+	stmts      int64
+	covered    bool
+}
+
+// coverBlockPattern matches a block's position, e.g. "example.com/pkg/file.go:12.34,15.2"
+var coverBlockPattern = regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+$`)
+
+// parseCoverBlocks returns the blocks in a cover profile.  Blocks which appear
+// more than once, e.g. with -coverpkg, are merged.
+func parseCoverBlocks(r io.Reader) ([]coverBlock, error) {
+	var blocks []coverBlock
+	seen := map[string]int{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// e.g. "example.com/pkg/file.go:12.34,15.2 3 1"
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid cover profile line %q", line)
+		}
+		pos := coverBlockPattern.FindStringSubmatch(fields[0])
+		n, err1 := strconv.ParseInt(fields[1], 10, 64)
+		count, err2 := strconv.ParseInt(fields[2], 10, 64)
+		if pos == nil || err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid cover profile line %q", line)
+		}
+		if i, ok := seen[fields[0]]; ok {
+			blocks[i].covered = blocks[i].covered || count > 0
+			continue
+		}
+		start, _ := strconv.Atoi(pos[2])
+		end, _ := strconv.Atoi(pos[3])
+		seen[fields[0]] = len(blocks)
+		blocks = append(blocks, coverBlock{file: pos[1], start: start, end: end, stmts: n, covered: count > 0})
+	}
+	return blocks, s.Err()
+}
+
+// checkTotalCoverage fails the run if its coverage is below -coverage-threshold.
+func (m *model) checkTotalCoverage() {
+	if flags.coverageThreshold == 0 {
+		return
+	}
+	if t, ok := m.totalCoverage(); !ok || t.percent < flags.coverageThreshold {
+		m.belowCoverageThreshold = true
+		m.overallFail = true
+	}
+}
+
+func (t totalCoverage) String() string {
+	if t.weighted {
+		return fmt.Sprintf("%.1f%% of statements", t.percent)
+	}
+	pkgs := "packages"
+	if t.packages == 1 {
+		pkgs = "package"
+	}
+	return fmt.Sprintf("%.1f%% average of %d %s", t.percent, t.packages, pkgs)
+}
+
+func renderCoverage(m *model) string {
+	var sb strings.Builder
+	if t, ok := m.totalCoverage(); ok {
+		fmt.Fprintf(&sb, "Coverage: %s", t)
+		if m.belowCoverageThreshold {
+			sb.WriteString("  " + failedText.Render(fmt.Sprintf("below threshold %.1f%%", flags.coverageThreshold)))
+		}
+		sb.WriteString("\n")
+	} else if m.belowCoverageThreshold {
+		sb.WriteString(failedText.Render("No coverage reported, with -coverage-threshold") + "\n")
+	}
+
+	if len(m.coverageViolations) == 0 {
+		return sb.String()
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Packages below minimum coverage:\n")
+	for _, n := range m.coverageViolations {
+		actual := "none"
+		if n.hasCoverage {
+			actual = fmt.Sprintf("%.1f%%", n.coverage)
+		}
+		fmt.Fprintf(&sb, "  %s\t%s\t%s\n", n.name, actual, gray.Render(fmt.Sprintf("min %.1f%%", n.minCoverage)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoverage(t *testing.T) {
+	c, ok := parseCoverage("ok  \tgithub.com/a/b\t0.012s\tcoverage: 83.2% of statements\n")
+	assert.True(t, ok)
+	assert.Equal(t, 83.2, c)
+
+	_, ok = parseCoverage("ok  \tgithub.com/a/b\t0.012s\n")
+	assert.False(t, ok)
+}
+
+func TestMinCoverage(t *testing.T) {
+	keepFlags(t)
+	require.NoError(t, coverageThresholdFlag("internal/=80"))
+	require.NoError(t, coverageThresholdFlag(".=50%"))
+	assert.Error(t, coverageThresholdFlag("nopercent"))
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a/internal/x"},
+		{Action: "output", Package: "a/internal/x", Output: "ok  \ta/internal/x\t0.1s\tcoverage: 75.0% of statements\n"},
+		{Action: "pass", Package: "a/internal/x"},
+		{Action: "start", Package: "a/y"},
+		{Action: "output", Package: "a/y", Output: "ok  \ta/y\t0.1s\tcoverage: 75.0% of statements\n"},
+		{Action: "pass", Package: "a/y"},
+		{Action: "start", Package: "a/z"},
+		{Action: "output", Package: "a/z", Output: "ok  \ta/z\t0.1s\n"},
+		{Action: "pass", Package: "a/z"},
+	}...)
+
+	assert.True(t, m.overallFail)
+	require.Len(t, m.coverageViolations, 2)
+	assert.Equal(t, "a/internal/x", m.coverageViolations[0].name)
+	assert.Equal(t, "a/z", m.coverageViolations[1].name)
+
+	out := renderCoverage(m)
+	assert.Contains(t, out, "a/internal/x\t75.0%")
+	assert.Contains(t, out, "a/z\tnone")
+}
+
+func TestParseCoverProfile(t *testing.T) {
+	profile := `mode: set
+a/x.go:1.1,3.2 4 1
+a/x.go:5.1,7.2 6 0
+a/y.go:1.1,3.2 10 0
+a/y.go:1.1,3.2 10 1
+`
+	covered, total, err := parseCoverProfile(strings.NewReader(profile))
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), covered)
+	assert.Equal(t, int64(20), total)
+
+	_, _, err = parseCoverProfile(strings.NewReader("mode: set\nbogus\n"))
+	assert.Error(t, err)
+}
+
+func TestCoverageThreshold(t *testing.T) {
+	keepFlags(t)
+	flags.coverageThreshold = 80
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "output", Package: "a", Output: "coverage: 90.0% of statements\n"},
+		{Action: "output", Package: "a", Output: "ok  \ta\t0.1s\n"},
+		{Action: "pass", Package: "a"},
+		{Action: "start", Package: "b"},
+		{Action: "output", Package: "b", Output: "ok  \tb\t0.1s\tcoverage: 60.0% of statements\n"},
+		{Action: "pass", Package: "b"},
+	}...)
+
+	total, ok := m.totalCoverage()
+	require.True(t, ok)
+	assert.Equal(t, "75.0% average of 2 packages", total.String())
+
+	m.checkTotalCoverage()
+	assert.True(t, m.overallFail)
+	assert.Contains(t, renderCoverage(m), "Coverage: 75.0% average of 2 packages  below threshold 80.0%")
+	assert.Contains(t, m.String(), "coverage: 90.0% of statements", "coverage on its own line should be shown next to the package")
+
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	require.NoError(t, os.WriteFile(profile, []byte("mode: set\na/x.go:1.1,3.2 9 1\nb/y.go:1.1,3.2 1 0\n"), 0o644))
+	flags.coverProfile = profile
+	total, ok = m.totalCoverage()
+	require.True(t, ok)
+	assert.Equal(t, "90.0% of statements", total.String())
+}
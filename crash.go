@@ -0,0 +1,92 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// crashMarkers are the lines which start a panic, a fatal runtime error, or a
+// data race report, and the label shown next to the test.
+var crashMarkers = []struct{ prefix, label string }{
+	{deadlockPrefix, "DEADLOCK"},
+	{"panic: ", "PANIC"},
+	{"fatal error: ", "FATAL ERROR"},
+	{"WARNING: DATA RACE", "DATA RACE"},
+}
+
+// detectCrash sets the node's crash label if the output line starts a panic, a
+// fatal error, or a data race report.  The first one seen wins.
+func (n *node) detectCrash(s string) {
+	if n.crash != "" {
+		return
+	}
+	s = strings.TrimSpace(s)
+	for _, c := range crashMarkers {
+		if strings.HasPrefix(s, c.prefix) {
+			n.crash = c.label
+			return
+		}
+	}
+}
+
+// crashIcon returns the icon for a test which failed because of a crash.
+func crashIcon(label string) string {
+	if label == "DATA RACE" {
+		return iconRace
+	}
+	return iconPanic
+}
+
+var (
+	// stackFuncPattern matches the function line of a goroutine stack frame, e.g.
+	// "example.com/m/pkg.TestFoo(0xc0000a6820?)" or "created by testing.(*T).Run in goroutine 1".
+	stackFuncPattern = regexp.MustCompile(`^\s*(?:created by )?([\w.\-/]*\w(?:\.\(\*?\w+\))?(?:\.[\w.\[\]]+)?)(?:\(.*\))?(?: in goroutine \d+)?$`)
+	// stackFilePattern matches the file line which follows it, e.g. "\t/src/pkg/foo_test.go:12 +0x25"
+	stackFilePattern = regexp.MustCompile(`^\s+\S+\.go:\d+(?: \+0x[0-9a-f]+)?$`)
+)
+
+// highlightStacks dims the standard library frames of goroutine stack traces in the
+// output, and bolds the frames from the module under test, so the frames which
+// matter stand out.
+func highlightStacks(output, module string) string {
+	lines := strings.Split(output, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		matches := stackFuncPattern.FindStringSubmatch(lines[i])
+		if matches == nil || !stackFilePattern.MatchString(lines[i+1]) {
+			continue
+		}
+		var style lipgloss.Style
+		switch fn := matches[1]; {
+		case module != "" && (strings.HasPrefix(fn, module+".") || strings.HasPrefix(fn, module+"/")):
+			style = stackOwnFrame
+		case !strings.Contains(fn, "/") || !strings.Contains(fn[:strings.Index(fn, "/")], "."):
+			// standard library packages don't have a dot in their first path element
+			style = gray
+		default:
+			i++
+			continue
+		}
+		lines[i] = renderIndented(style, lines[i])
+		lines[i+1] = renderIndented(style, lines[i+1])
+		i++
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderIndented renders the line with the style, leaving its indentation alone,
+// since lipgloss expands tabs.
+func renderIndented(style lipgloss.Style, line string) string {
+	text := strings.TrimLeft(line, " \t")
+	return line[:len(line)-len(text)] + style.Render(text)
+}
+
+// stackModule returns the module whose frames are highlighted in stack traces:
+// the module being tested, or if it isn't known, the package.
+func (m *model) stackModule(pkg string) string {
+	if m.paths != nil && m.paths.module != "" {
+		return m.paths.module
+	}
+	return pkg
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCrash(t *testing.T) {
+	for _, tt := range []struct {
+		output []string
+		want   string
+	}{
+		{[]string{"    a_test.go:3: boom\n"}, ""},
+		{[]string{"    a_test.go:3: panic: not really\n"}, ""},
+		{[]string{"panic: runtime error: index out of range [1] with length 0\n"}, "PANIC"},
+		{[]string{"fatal error: concurrent map writes\n"}, "FATAL ERROR"},
+		{[]string{"==================\n", "WARNING: DATA RACE\n", "panic: later\n"}, "DATA RACE"},
+	} {
+		n := &node{lvl: 2, isTest: true}
+		for _, s := range tt.output {
+			n.output(s)
+		}
+		assert.Equal(t, tt.want, n.crash, "%q", tt.output)
+	}
+}
+
+func TestHighlightStacks(t *testing.T) {
+	defer func(s1, s2 lipgloss.Style) { gray, stackOwnFrame = s1, s2 }(gray, stackOwnFrame)
+	gray = lipgloss.NewStyle().Transform(func(s string) string { return "<dim>" + s })
+	stackOwnFrame = lipgloss.NewStyle().Transform(func(s string) string { return "<bold>" + s })
+
+	output := `panic: boom [recovered]
+goroutine 7 [running]:
+testing.tRunner.func1.2({0x5a1f40, 0x6d6a80})
+	/usr/local/go/src/testing/testing.go:1632 +0x230
+panic({0x6b6dd0?, 0x6ef0a0?})
+	/usr/local/go/src/runtime/panic.go:859 +0x125
+example.com/m/pkg.TestX(0xc0000a6820?)
+	pkg/x_test.go:10 +0x25
+github.com/other/dep.(*Client).Do(...)
+	/go/pkg/mod/github.com/other/dep/client.go:5
+created by testing.(*T).Run in goroutine 1
+	/usr/local/go/src/testing/testing.go:1742 +0x390`
+
+	want := `panic: boom [recovered]
+goroutine 7 [running]:
+<dim>testing.tRunner.func1.2({0x5a1f40, 0x6d6a80})
+	<dim>/usr/local/go/src/testing/testing.go:1632 +0x230
+<dim>panic({0x6b6dd0?, 0x6ef0a0?})
+	<dim>/usr/local/go/src/runtime/panic.go:859 +0x125
+<bold>example.com/m/pkg.TestX(0xc0000a6820?)
+	<bold>pkg/x_test.go:10 +0x25
+github.com/other/dep.(*Client).Do(...)
+	/go/pkg/mod/github.com/other/dep/client.go:5
+<dim>created by testing.(*T).Run in goroutine 1
+	<dim>/usr/local/go/src/testing/testing.go:1742 +0x390`
+
+	assert.Equal(t, want, highlightStacks(output, "example.com/m"))
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// diffLinePattern matches one side of a got/want or expected/actual pair in failure output,
+// e.g. "    got: foo", or testify's "expected: "foo"".
+var diffLinePattern = regexp.MustCompile(`(?i)^(.*?\b(got|want|expected|actual)\s*:\s*)(.*)$`)
+
+var diffPairs = map[string]string{
+	"got":      "want",
+	"want":     "got",
+	"expected": "actual",
+	"actual":   "expected",
+}
+
+// maxDiffTokens limits the size of values which are word diffed, since the diff is
+// quadratic.
+const maxDiffTokens = 2000
+
+var tokenPattern = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+
+// highlightDiffs finds adjacent got/want and expected/actual lines in the output,
+// and highlights the words which differ between them.  With -diff-color, the
+// expected side is colored red and the actual side green, as are the lines of
+// -/+ diffs, like testify's and go-cmp's.
+func highlightDiffs(output string) string {
+	if !flags.diffColor {
+		return markDiffs(output, diffHighlight.Render)
+	}
+	output = styleDiffs(output,
+		diffStyle{same: diffRemoved.Render, changed: diffRemoved.Inherit(diffHighlight).Render},
+		diffStyle{same: diffAdded.Render, changed: diffAdded.Inherit(diffHighlight).Render})
+	return colorDiffBlocks(output)
+}
+
+// diffStyle renders one side of a got/want pair: the words common to both sides,
+// and the words which differ.
+type diffStyle struct {
+	same, changed func(...string) string
+}
+
+func joinStrings(s ...string) string {
+	return strings.Join(s, "")
+}
+
+// markDiffs is highlightDiffs, with the differing words rendered by mark.
+func markDiffs(output string, mark func(...string) string) string {
+	plain := diffStyle{same: joinStrings, changed: mark}
+	return styleDiffs(output, plain, plain)
+}
+
+// styleDiffs is markDiffs, with separate styles for the expected side of each
+// pair (want and expected), and the actual side (got and actual).
+func styleDiffs(output string, expected, actual diffStyle) string {
+	styleOf := func(label string) diffStyle {
+		if label == "want" || label == "expected" {
+			return expected
+		}
+		return actual
+	}
+	lines := strings.Split(output, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		a := diffLinePattern.FindStringSubmatch(lines[i])
+		if a == nil {
+			continue
+		}
+		b := diffLinePattern.FindStringSubmatch(lines[i+1])
+		la, lb := strings.ToLower(a[2]), strings.ToLower(b[2])
+		if b == nil || diffPairs[la] != lb {
+			continue
+		}
+		va, vb := diffWords(a[3], b[3], styleOf(la), styleOf(lb))
+		lines[i] = a[1] + va
+		lines[i+1] = b[1] + vb
+		i++
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wordDiff compares two strings word by word, and returns them with the words which
+// aren't common to both rendered by mark.
+func wordDiff(a, b string, mark func(...string) string) (string, string) {
+	plain := diffStyle{same: joinStrings, changed: mark}
+	return diffWords(a, b, plain, plain)
+}
+
+// diffWords is wordDiff, with each string rendered in its own style.
+func diffWords(a, b string, styleA, styleB diffStyle) (string, string) {
+	ta, tb := tokenPattern.FindAllString(a, -1), tokenPattern.FindAllString(b, -1)
+	if len(ta) > maxDiffTokens || len(tb) > maxDiffTokens || a == b {
+		return styleA.same(a), styleB.same(b)
+	}
+
+	// longest common subsequence of tokens
+	lcs := make([][]int, len(ta)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tb)+1)
+	}
+	for i := len(ta) - 1; i >= 0; i-- {
+		for j := len(tb) - 1; j >= 0; j-- {
+			if ta[i] == tb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	inA, inB := make([]bool, len(ta)), make([]bool, len(tb))
+	for i, j := 0, 0; i < len(ta) && j < len(tb); {
+		switch {
+		case ta[i] == tb[j]:
+			inA[i], inB[j] = true, true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return markTokens(ta, inA, styleA), markTokens(tb, inB, styleB)
+}
+
+// markTokens joins the tokens, rendering runs of common tokens, and runs of tokens
+// which aren't, in the style's colors.
+func markTokens(tokens []string, common []bool, style diffStyle) string {
+	var sb, run strings.Builder
+	for i, t := range tokens {
+		run.WriteString(t)
+		if i+1 == len(tokens) || common[i+1] != common[i] {
+			if common[i] {
+				sb.WriteString(style.same(run.String()))
+			} else {
+				sb.WriteString(style.changed(run.String()))
+			}
+			run.Reset()
+		}
+	}
+	return sb.String()
+}
+
+// diffHeaderPattern matches the line before a go-cmp diff, e.g. "mismatch (-want +got):".
+var diffHeaderPattern = regexp.MustCompile(`\((-\w+ \+\w+|\+\w+ -\w+)\):?\s*$`)
+
+// colorDiffBlocks colors the removed and added lines of the diffs in the output:
+// testify's, which follow a "Diff:" line, and go-cmp's, which are usually printed
+// after a line like "mismatch (-want +got):".  A diff ends at the first blank line,
+// or line indented less than the diff.
+func colorDiffBlocks(output string) string {
+	lines := strings.Split(output, "\n")
+	minIndent := -1
+	for i, l := range lines {
+		content := strings.TrimLeft(l, " \t")
+		indent := len(l) - len(content)
+		if minIndent >= 0 && (content == "" || indent < minIndent) {
+			minIndent = -1
+		}
+		switch {
+		case content == "Diff:":
+			// testify aligns the diff with the "Diff:" line
+			minIndent = indent
+			continue
+		case diffHeaderPattern.MatchString(content):
+			// go test indents the lines after the first line of a log message
+			minIndent = indent + 1
+			continue
+		case minIndent < 0 || strings.Contains(l, "\x1b"):
+			continue
+		}
+		switch {
+		case strings.HasPrefix(content, "---"), strings.HasPrefix(content, "+++"), strings.HasPrefix(content, "@@"):
+			lines[i] = l[:indent] + gray.Render(content)
+		case strings.HasPrefix(content, "-"):
+			lines[i] = l[:indent] + diffRemoved.Render(content)
+		case strings.HasPrefix(content, "+"):
+			lines[i] = l[:indent] + diffAdded.Render(content)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func brackets(s ...string) string {
+	return "[" + strings.Join(s, "") + "]"
+}
+
+func TestWordDiff(t *testing.T) {
+	a, b := wordDiff(`https://example.com/api/v1/users?id=42`, `https://example.com/api/v2/users?id=42`, brackets)
+	assert.Equal(t, `https://example.com/api/[v1]/users?id=42`, a)
+	assert.Equal(t, `https://example.com/api/[v2]/users?id=42`, b)
+
+	a, b = wordDiff(`{"a": 1, "b": 2}`, `{"a": 1, "b": 2, "c": 3}`, brackets)
+	assert.Equal(t, `{"a": 1, "b": 2}`, a)
+	assert.Equal(t, `{"a": 1, "b": 2[, "c": 3]}`, b)
+
+	a, b = wordDiff("same", "same", brackets)
+	assert.Equal(t, "same", a)
+	assert.Equal(t, "same", b)
+}
+
+func TestMarkDiffs(t *testing.T) {
+	in := `    foo_test.go:12: 
+        	Error:      	Not equal: 
+        	            	expected: "hello world"
+        	            	actual  : "hello there"
+    foo_test.go:20: got: 1 apple
+    foo_test.go:20: want: 2 apple
+got: unpaired
+    expected: x
+    got: y`
+	out := markDiffs(in, brackets)
+	assert.Contains(t, out, `expected: "hello [world]"`)
+	assert.Contains(t, out, `actual  : "hello [there]"`)
+	assert.Contains(t, out, "got: [1] apple\n    foo_test.go:20: want: [2] apple")
+	assert.Contains(t, out, "got: unpaired\n    expected: x\n    got: y", "mismatched pairs are left alone")
+}
+
+func TestStyleDiffs(t *testing.T) {
+	tag := func(name string) func(...string) string {
+		return func(s ...string) string { return "<" + name + ">" + strings.Join(s, "") + "</" + name + ">" }
+	}
+	out := styleDiffs("expected: \"hello world\"\nactual  : \"hello there\"",
+		diffStyle{same: tag("r"), changed: tag("R")},
+		diffStyle{same: tag("g"), changed: tag("G")})
+	assert.Equal(t, "expected: <r>\"hello </r><R>world</R><r>\"</r>\nactual  : <g>\"hello </g><G>there</G><g>\"</g>", out)
+}
+
+func TestColorDiffBlocks(t *testing.T) {
+	defer func(r, a, g lipgloss.Style) { diffRemoved, diffAdded, gray = r, a, g }(diffRemoved, diffAdded, gray)
+	diffRemoved = lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).SetString("RED:")
+	diffAdded = lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).SetString("GREEN:")
+	gray = lipgloss.NewStyle().SetString("GRAY:")
+
+	in := `    foo_test.go:12: mismatch (-want +got):
+          main.T{
+        - 	A: 1,
+        + 	A: 2,
+          }
+    foo_test.go:13: - not a diff
+        	Error:      	Not equal: 
+        	            	expected: 1
+        	            	actual  : 2
+        	            	
+        	            	Diff:
+        	            	--- Expected
+        	            	+++ Actual
+        	            	@@ -1 +1 @@
+        	            	-1
+        	            	+2
+        	Test:       	TestFoo
+- not a diff`
+	want := `    foo_test.go:12: mismatch (-want +got):
+          main.T{
+        RED: - 	A: 1,
+        GREEN: + 	A: 2,
+          }
+    foo_test.go:13: - not a diff
+        	Error:      	Not equal: 
+        	            	expected: 1
+        	            	actual  : 2
+        	            	
+        	            	Diff:
+        	            	GRAY: --- Expected
+        	            	GRAY: +++ Actual
+        	            	GRAY: @@ -1 +1 @@
+        	            	RED: -1
+        	            	GREEN: +2
+        	Test:       	TestFoo
+- not a diff`
+	assert.Equal(t, want, colorDiffBlocks(in))
+}
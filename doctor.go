@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// doctorCheck is the result of one of the doctor subcommand's checks.
+type doctorCheck struct {
+	name string
+	// ok is false for problems which will break gotestpretty, and warn is set for
+	// problems which degrade it
+	ok   bool
+	warn bool
+	msg  string
+}
+
+func (c doctorCheck) String() string {
+	icon := iconPassed
+	switch {
+	case !c.ok:
+		icon = iconFailed
+	case c.warn:
+		icon = iconSkipped
+	}
+	return fmt.Sprintf("%s %s: %s", icon, c.name, c.msg)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func checkTerminal() doctorCheck {
+	c := doctorCheck{name: "terminal", ok: true}
+	term := os.Getenv("TERM")
+	switch {
+	case !isTerminal(os.Stdout):
+		c.warn = true
+		c.msg = "stdout isn't a terminal, so the live view will be mixed into the output.  Redirect only the summary, or run in a terminal"
+	case term == "" || term == "dumb":
+		c.warn = true
+		c.msg = fmt.Sprintf("TERM=%q doesn't support cursor movement, the live view may be garbled", term)
+	case os.Getenv("NO_COLOR") != "":
+		c.msg = "TERM=" + term + ", colors are disabled by NO_COLOR"
+	default:
+		c.msg = "TERM=" + term
+		if ct := os.Getenv("COLORTERM"); ct != "" {
+			c.msg += ", COLORTERM=" + ct
+		}
+	}
+	return c
+}
+
+func checkLocale() doctorCheck {
+	c := doctorCheck{name: "locale", ok: true}
+	// the first set of these takes precedence
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(strings.ToUpper(strings.ReplaceAll(v, "-", "")), "UTF8") {
+			c.warn = true
+			c.msg = fmt.Sprintf("%s=%s isn't UTF-8, icons may not render.  Try LANG=en_US.UTF-8", env, v)
+			return c
+		}
+		c.msg = env + "=" + v
+		return c
+	}
+	c.warn = true
+	c.msg = "LANG isn't set, icons may not render if the terminal isn't UTF-8"
+	return c
+}
+
+var goVersionPattern = regexp.MustCompile(`go(\d+)\.(\d+)`)
+
+// checkGoVersion reports the test2json quirks of the go version, from the output
+// of 'go version'.
+func checkGoVersion(version string) doctorCheck {
+	c := doctorCheck{name: "go", ok: true}
+	matches := goVersionPattern.FindStringSubmatch(version)
+	if matches == nil {
+		c.warn = true
+		c.msg = fmt.Sprintf("can't parse go version %q", strings.TrimSpace(version))
+		return c
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	c.msg = "go" + matches[1] + "." + matches[2]
+	switch {
+	case major == 1 && minor < 20:
+		c.warn = true
+		c.msg += " doesn't emit package start events, so queued packages and setup times aren't shown.  Upgrade to go1.20 or later"
+	case major == 1 && minor >= 24:
+		c.msg += " reports build output as JSON, which is shown as unattributed output"
+	}
+	return c
+}
+
+// maxDoctorLines is how many lines of stdin are checked for test events.
+const maxDoctorLines = 50
+
+// checkInput checks whether the start of the input looks like 'go test -json' output.
+func checkInput(r io.Reader) doctorCheck {
+	c := doctorCheck{name: "input", ok: true}
+	var lines, events int
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64*1024*1024)
+	for lines < maxDoctorLines && s.Scan() {
+		lines++
+		if _, err := decodeEvent(s.Bytes()); err == nil {
+			events++
+		}
+	}
+	switch {
+	case s.Err() != nil:
+		c.ok = false
+		c.msg = "error reading input: " + s.Err().Error()
+	case lines == 0:
+		c.warn = true
+		c.msg = "input was empty"
+	case events == 0:
+		c.ok = false
+		c.msg = fmt.Sprintf("none of the first %d lines are test events.  Did you forget 'go test -json'? Use -plain for non-JSON output", lines)
+	case events < lines:
+		c.msg = fmt.Sprintf("%d of the first %d lines are test events, the rest are passed through", events, lines)
+	default:
+		c.msg = fmt.Sprintf("the first %d lines are test events", lines)
+	}
+	return c
+}
+
+func checkFiles() []doctorCheck {
+	checks := []doctorCheck{checkConfig()}
+	if _, err := loadAnnotations(); err != nil {
+		path, _ := annotationsPath()
+		checks = append(checks, doctorCheck{name: "annotations", msg: fmt.Sprintf("can't load %s: %v", path, err)})
+	} else {
+		checks = append(checks, doctorCheck{name: "annotations", ok: true, msg: "ok"})
+	}
+	if _, err := readHistory(); err != nil {
+		path, _ := historyPath()
+		checks = append(checks, doctorCheck{name: "history", msg: fmt.Sprintf("can't read %s: %v", path, err)})
+	} else {
+		checks = append(checks, doctorCheck{name: "history", ok: true, msg: "ok"})
+	}
+	return checks
+}
+
+// checkConfig checks the config file, and the GOTESTPRETTY_* environment variables,
+// by loading them like parseFlags does, into a flag set of their own.
+func checkConfig() doctorCheck {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	defineFlags(fs)
+	if err := loadConfig(fs); err != nil {
+		return doctorCheck{name: "config", msg: err.Error()}
+	}
+	return doctorCheck{name: "config", ok: true, msg: "ok"}
+}
+
+// doctorMain implements the doctor subcommand, which checks the environment for
+// common problems.  Exits with 1 if any check failed.
+func doctorMain(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n\t%s doctor\n\tgo test -json ./... | %s doctor\n\n", os.Args[0], os.Args[0])
+		fmt.Fprintf(fs.Output(), "Checks the terminal, locale, go version, and gotestpretty's files, like its config file, for problems.\nIf input is piped in, checks it looks like 'go test -json' output.\n")
+	}
+	_ = fs.Parse(args)
+
+	if err := setupTheme("auto"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	checks := []doctorCheck{checkTerminal(), checkLocale()}
+	if out, err := exec.Command("go", "version").Output(); err == nil {
+		checks = append(checks, checkGoVersion(string(out)))
+	} else {
+		checks = append(checks, doctorCheck{name: "go", ok: true, warn: true, msg: "go isn't in the PATH: " + err.Error()})
+	}
+	if !isTerminal(os.Stdin) {
+		checks = append(checks, checkInput(os.Stdin))
+	}
+	checks = append(checks, checkFiles()...)
+
+	code := 0
+	for _, c := range checks {
+		fmt.Println(c)
+		if !c.ok {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGoVersion(t *testing.T) {
+	c := checkGoVersion("go version go1.19.5 linux/amd64\n")
+	assert.True(t, c.ok)
+	assert.True(t, c.warn)
+	assert.Contains(t, c.msg, "start events")
+
+	c = checkGoVersion("go version go1.23.1 darwin/arm64\n")
+	assert.True(t, c.ok)
+	assert.False(t, c.warn)
+	assert.Equal(t, "go1.23", c.msg)
+
+	c = checkGoVersion("garbage")
+	assert.True(t, c.warn)
+}
+
+func TestCheckInput(t *testing.T) {
+	c := checkInput(strings.NewReader(`{"Action":"start","Package":"a"}
+# building
+{"Action":"pass","Package":"a"}
+`))
+	assert.True(t, c.ok)
+	assert.Equal(t, "2 of the first 3 lines are test events, the rest are passed through", c.msg)
+
+	c = checkInput(strings.NewReader("=== RUN   TestA\n--- PASS: TestA (0.00s)\n"))
+	assert.False(t, c.ok)
+
+	c = checkInput(strings.NewReader(""))
+	assert.True(t, c.warn)
+}
+
+func TestCheckConfig(t *testing.T) {
+	keepFlags(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv("GOTESTPRETTY_CONFIG", path)
+
+	c := checkConfig()
+	assert.True(t, c.ok, "a missing config file is fine")
+
+	require.NoError(t, os.WriteFile(path, []byte("slow-threshold = \"2s\"\n"), 0o644))
+	c = checkConfig()
+	assert.True(t, c.ok, c.msg)
+
+	require.NoError(t, os.WriteFile(path, []byte("slow-threshold = \"2s\"\nno-such-flag = true\n"), 0o644))
+	c = checkConfig()
+	assert.False(t, c.ok)
+	assert.Contains(t, c.msg, `line 2: unknown setting "no-such-flag"`)
+
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	t.Setenv("GOTESTPRETTY_SLOW_THRESHOLD", "soon")
+	c = checkConfig()
+	assert.False(t, c.ok)
+	assert.Contains(t, c.msg, "$GOTESTPRETTY_SLOW_THRESHOLD")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Test names come from the stream, and t.Run accepts any string, so a name may
+// contain quotes, backticks, pipes, newlines, escape sequences, or bytes which
+// aren't UTF-8.  The helpers below make a name safe to embed in each output.
+
+// maxDisplayName is the most runes of a name shown in the live view.
+const maxDisplayName = 120
+
+// escapeControl escapes control characters and invalid UTF-8 like strconv.Quote,
+// e.g. "\n" and "\x00", and leaves everything else, including quotes, as is.  Many
+// of the control characters aren't allowed in XML, and in the terminal they break
+// lines or start escape sequences.
+func escapeControl(s string) string {
+	if !needsEscape(s) {
+		return s
+	}
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(s[i:], string(utf8.RuneError)):
+			fmt.Fprintf(&sb, `\x%02x`, s[i])
+		case escaped(r):
+			q := strconv.QuoteRune(r)
+			sb.WriteString(q[1 : len(q)-1])
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func needsEscape(s string) bool {
+	return !utf8.ValidString(s) || strings.IndexFunc(s, escaped) >= 0
+}
+
+// escaped returns true for the runes escapeControl escapes: control characters,
+// and the Unicode line and paragraph separators, which some terminals and
+// editors treat as line breaks.
+func escaped(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+}
+
+// displayName returns a name for the terminal, escaped, and truncated to
+// maxDisplayName runes so one name can't take over the live view.
+func displayName(s string) string {
+	s = escapeControl(s)
+	if utf8.RuneCountInString(s) <= maxDisplayName {
+		return s
+	}
+	return string([]rune(s)[:maxDisplayName-1]) + "…"
+}
+
+// markdownCode returns s as an inline code span which is safe in a Markdown table
+// cell: the span's delimiter is longer than any run of backticks in s, and pipes
+// are escaped, since tables split cells on them even inside code spans.
+func markdownCode(s string) string {
+	s = strings.ReplaceAll(escapeControl(s), "|", `\|`)
+	fence := strings.Repeat("`", longestRun(s, '`')+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// htmlCode returns s as an HTML code element, for names inside HTML in Markdown.
+func htmlCode(s string) string {
+	return "<code>" + html.EscapeString(escapeControl(s)) + "</code>"
+}
+
+// fencedBlock returns s as a Markdown code block, fenced with more backticks than
+// any run in s, so output which contains a fence can't end the block early.
+func fencedBlock(s string) string {
+	fence := strings.Repeat("`", max(longestRun(s, '`')+1, 3))
+	return fence + "\n" + s + "\n" + fence
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return longest
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeControl(t *testing.T) {
+	tests := map[string]string{
+		"TestFoo":              "TestFoo",
+		`Test"quoted"`:         `Test"quoted"`,
+		"TestNew\nline":        `TestNew\nline`,
+		"Test\r\n\t":           `Test\r\n\t`,
+		"Test\x00nul":          `Test\x00nul`,
+		"Test\x1b[31mred":      `Test\x1b[31mred`,
+		"Test\xffbad":          `Test\xffbad`,
+		"Test\u2028sep":        `Test\u2028sep`,
+		"Test\uFFFDreplaced":   "Test�replaced",
+		"Testüñíçødé/sub name": "Testüñíçødé/sub name",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, escapeControl(in), "%q", in)
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	assert.Equal(t, `Test\nfoo`, displayName("Test\nfoo"))
+	long := displayName(strings.Repeat("é", 500))
+	assert.Equal(t, maxDisplayName, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
+
+func TestMarkdownCode(t *testing.T) {
+	assert.Equal(t, "`TestFoo`", markdownCode("TestFoo"))
+	assert.Equal(t, "`Test\\|pipe`", markdownCode("Test|pipe"))
+	assert.Equal(t, "``Test`tick``", markdownCode("Test`tick"))
+	assert.Equal(t, "``` ``Test ```", markdownCode("``Test"))
+	assert.Equal(t, "`Test\\nrow\\n\\| x \\| y \\|`", markdownCode("Test\nrow\n| x | y |"))
+}
+
+func TestHTMLCode(t *testing.T) {
+	assert.Equal(t, "<code>a.Test&lt;/code&gt;&lt;script&gt;\\n&#34;x&#34;</code>", htmlCode("a.Test</code><script>\n\"x\""))
+}
+
+func TestFencedBlock(t *testing.T) {
+	assert.Equal(t, "```\nboom\n```", fencedBlock("boom"))
+	assert.Equal(t, "````\nwant:\n```\nx\n```\n````", fencedBlock("want:\n```\nx\n```"))
+}
+
+func TestStepSummaryAdversarialNames(t *testing.T) {
+	m := newModel()
+	name := "Test|pipe`tick\n</summary>"
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: name},
+		{Action: "output", Package: "a", Test: name, Output: "```\nboom\n"},
+		{Action: "fail", Package: "a", Test: name},
+		{Action: "fail", Package: "a"},
+	}...)
+	m.root.processChildren(true, true)
+
+	md := markdownReport(m.summary())
+	assert.Contains(t, md, "<details><summary><code>a.Test|pipe`tick\\n&lt;/summary&gt;</code></summary>\n\n````\n```\nboom\n````")
+	assert.Contains(t, md, "| ``Test\\|pipe`tick\\n</summary>`` | `a` |")
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(line, "|") {
+			assert.True(t, strings.HasSuffix(line, "|"), "table rows aren't broken: %q", line)
+		}
+	}
+	assert.Contains(t, clipboardSummary(m.summary()), "a Test|pipe`tick\\n</summary>")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// With -exit-codes extended, the exit code says why the run failed, so wrapper
+// scripts can tell the failures apart without parsing the output.  When more than
+// one happened, the first in this list wins: a timeout or abort, an error in
+// gotestpretty or reading the input, a build failure, then a test failure.
+
+// exitCodeStyles are the valid values of -exit-codes.
+var exitCodeStyles = []string{"simple", "extended"}
+
+// the exit codes used with -exit-codes extended
+const (
+	exitTestFailure  = 1
+	exitBuildFailure = 2
+	exitToolError    = 3
+	exitAborted      = 4
+)
+
+// timeoutPanic starts the panic of a test binary which ran longer than -timeout.
+const timeoutPanic = "panic: test timed out after"
+
+// noteTimeout records a test binary timing out.
+func (m *model) noteTimeout(output string) {
+	if strings.HasPrefix(output, timeoutPanic) {
+		m.timedOut = true
+	}
+}
+
+// exitCode returns the exit code of the finished run, or 0 if it passed.
+func (m *model) exitCode() int {
+	if flags.exitCodes != "extended" {
+		if m.overallFail {
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case m.aborted || m.aborts > 0 || m.timedOut || len(m.notStarted) > 0:
+		return exitAborted
+	case m.err != nil:
+		return exitToolError
+	case m.buildFails > 0:
+		return exitBuildFailure
+	case m.overallFail:
+		return exitTestFailure
+	}
+	return 0
+}
+
+// goTestExitCode returns the exit code for go test exiting with code, when no
+// test failed, e.g. because go test was given bad flags.
+func goTestExitCode(code int) int {
+	if code != 0 && flags.exitCodes == "extended" {
+		return exitToolError
+	}
+	return code
+}
+
+// exitToolFailure exits after an error in gotestpretty itself, like a file which
+// can't be written, or go test which can't be started.
+func exitToolFailure() {
+	if flags.exitCodes == "extended" {
+		os.Exit(exitToolError)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	keepFlags(t)
+
+	run := func(events ...TestEvent) *model {
+		m := newModel()
+		feed(m, events...)
+		return m
+	}
+	passed := []TestEvent{
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a"},
+	}
+	failed := []TestEvent{
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a"},
+	}
+	buildFailed := append(append([]TestEvent{}, failed...), TestEvent{Action: "fail", Package: "b", FailedBuild: "b [b.test]"})
+	timedOut := []TestEvent{
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "panic: test timed out after 1s\n"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a"},
+	}
+
+	flags.exitCodes = "simple"
+	assert.Equal(t, 0, run(passed...).exitCode())
+	assert.Equal(t, 1, run(failed...).exitCode())
+	assert.Equal(t, 1, run(timedOut...).exitCode())
+	assert.Equal(t, 7, goTestExitCode(7))
+
+	flags.exitCodes = "extended"
+	assert.Equal(t, 0, run(passed...).exitCode())
+	assert.Equal(t, exitTestFailure, run(failed...).exitCode())
+	assert.Equal(t, exitBuildFailure, run(buildFailed...).exitCode())
+	assert.Equal(t, exitAborted, run(timedOut...).exitCode())
+	assert.Equal(t, exitToolError, goTestExitCode(7))
+	assert.Equal(t, 0, goTestExitCode(0))
+
+	m := run(passed...)
+	m.Update(errors.New("read error"))
+	assert.Equal(t, exitToolError, m.exitCode())
+
+	m = run(failed[0])
+	m.abort()
+	assert.Equal(t, exitAborted, m.exitCode())
+}
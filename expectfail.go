@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Tests matching -expect-fail, or a pattern in -expect-fail-file, are expected to
+// fail, e.g. tests for known bugs.  When they fail, their status is "xfail", which
+// doesn't fail the run, and they're listed in the summary.  When they pass, they're
+// flagged XPASS, so the expectation can be removed.  Patterns are matched against
+// the package and test name, like "pkg TestFoo/sub".
+
+// expectFailFileFlag reads -expect-fail-file, a file with one pattern per line.
+// Blank lines and lines starting with # are ignored.
+func expectFailFileFlag(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		flags.expectFail = append(flags.expectFail, re)
+	}
+	return s.Err()
+}
+
+// expectedToFail returns true if the test matches an -expect-fail pattern.
+func expectedToFail(n *node) bool {
+	if !n.isTest || len(flags.expectFail) == 0 {
+		return false
+	}
+	return matchesAny(flags.expectFail, n.pkg().name+" "+n.testName())
+}
+
+// expectedFailure returns true if a failure was expected: the test is expected to
+// fail, or every test which failed under it, or in its package, was.  A package
+// which failed to build isn't.
+func expectedFailure(n *node, ev TestEvent) bool {
+	if len(flags.expectFail) == 0 {
+		return false
+	}
+	if expectedToFail(n) {
+		return true
+	}
+	if !n.isTest && (ev.FailedBuild != "" || strings.Contains(n.msg, "[build failed]")) {
+		return false
+	}
+	return !n.hasFailedChild() && n.hasExpectedFailure()
+}
+
+// hasExpectedFailure returns true if any of the node's children failed as expected.
+func (n *node) hasExpectedFailure() bool {
+	for _, c := range append(n.children, n.dropped...) {
+		if c.status == "xfail" {
+			return true
+		}
+	}
+	return false
+}
+
+func renderExpectedFailures(m *model) string {
+	var xfails, xpasses []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range append(n.children, n.dropped...) {
+			switch {
+			case c.status == "xfail" && expectedToFail(c):
+				xfails = append(xfails, c)
+			case c.xpass:
+				xpasses = append(xpasses, c)
+			}
+			walk(c)
+		}
+	}
+	walk(&m.root)
+	if len(xfails) == 0 && len(xpasses) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if len(xfails) > 0 {
+		sb.WriteString("Expected failures:\n")
+		for _, n := range xfails {
+			fmt.Fprintf(&sb, "  %s %s\t%s\n", iconXFail, n.testName(), gray.Render(n.pkg().name))
+		}
+	}
+	if len(xpasses) > 0 {
+		sb.WriteString("Expected to fail, but passed:\n")
+		for _, n := range xpasses {
+			fmt.Fprintf(&sb, "  %s %s\t%s\n", failedText.Render("XPASS"), n.testName(), gray.Render(n.pkg().name))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedFailures(t *testing.T) {
+	keepFlags(t)
+	flags.expectFail = []*regexp.Regexp{regexp.MustCompile(`^a TestKnownBug/sub$`), regexp.MustCompile(`TestFixed`)}
+	flags.sections = []summarySection{findSection("expected")}
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestKnownBug"},
+		{Action: "run", Package: "a", Test: "TestKnownBug/sub"},
+		{Action: "fail", Package: "a", Test: "TestKnownBug/sub"},
+		{Action: "fail", Package: "a", Test: "TestKnownBug"},
+		{Action: "run", Package: "a", Test: "TestFixed"},
+		{Action: "pass", Package: "a", Test: "TestFixed"},
+		{Action: "fail", Package: "a"},
+	}...)
+
+	assert.False(t, m.overallFail, "expected failures don't fail the run")
+	assert.Equal(t, 0, m.fails)
+	assert.Equal(t, 2, m.xfails, "the parent failed because of the expected failure")
+	assert.Equal(t, "xfail", m.root.children[0].status)
+	assert.Equal(t, "pass", m.summary().Result)
+
+	m.done = true
+	out := m.String()
+	assert.Contains(t, out, "PASSED 3 tests, 2 failed as expected")
+	assert.Contains(t, out, "sub\t0s\texpected failure")
+	assert.Contains(t, out, "Expected failures:\n  "+iconXFail+" TestKnownBug/sub\ta\nExpected to fail, but passed:\n  XPASS TestFixed\ta\n")
+
+	// a failure which wasn't expected still fails the package
+	m = newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "b"},
+		{Action: "run", Package: "b", Test: "TestKnownBug"},
+		{Action: "run", Package: "b", Test: "TestKnownBug/sub"},
+		{Action: "fail", Package: "b", Test: "TestKnownBug/sub"},
+		{Action: "fail", Package: "b", Test: "TestKnownBug"},
+		{Action: "fail", Package: "b"},
+	}...)
+	assert.True(t, m.overallFail)
+	assert.Equal(t, 2, m.fails)
+}
+
+func TestExpectFailFileFlag(t *testing.T) {
+	keepFlags(t)
+
+	path := filepath.Join(t.TempDir(), "xfail")
+	require.NoError(t, os.WriteFile(path, []byte("# known bugs\nTestA\n\n  pkg TestB/sub  \n"), 0o644))
+	require.NoError(t, expectFailFileFlag(path))
+	require.Len(t, flags.expectFail, 2)
+	assert.Equal(t, "pkg TestB/sub", flags.expectFail[1].String())
+
+	require.NoError(t, os.WriteFile(path, []byte("Test(\n"), 0o644))
+	assert.Error(t, expectFailFileFlag(path))
+}
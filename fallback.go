@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// renderSafely is render, except a panic in the renderer doesn't crash the run and
+// lose its results.  The panic is logged and saved to a file for a bug report, and
+// the rest of the run is rendered by the minimal renderer instead.
+func (m *model) renderSafely(fitToWindow bool) (s string) {
+	if m.renderPanic != "" {
+		return m.minimalRender()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.renderPanic = fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack())
+			log.Printf("render panicked, falling back to the minimal renderer: %s", m.renderPanic)
+			m.panicFile = savePanic(m.renderPanic)
+			recentInput.dump("render panicked")
+			s = m.minimalRender()
+		}
+	}()
+	return m.render(fitToWindow)
+}
+
+// savePanic writes the panic, with the version info, to a temp file, and returns its
+// path, or "" if it couldn't be written.
+func savePanic(panicMsg string) string {
+	f, err := os.CreateTemp("", "gotestpretty-panic-*.log")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintln(f, strings.Join(versionInfo(info), "\n"))
+		fmt.Fprintln(f)
+	}
+	fmt.Fprint(f, panicMsg)
+	return f.Name()
+}
+
+// minimalRender renders only the failed tests and the totals, without any styling,
+// to keep as little as possible in common with the renderer which panicked.
+func (m *model) minimalRender() string {
+	var sb strings.Builder
+	sb.WriteString("gotestpretty: the test tree couldn't be rendered, please report the bug")
+	if m.panicFile != "" {
+		fmt.Fprintf(&sb, ", with the panic saved in %s", m.panicFile)
+	}
+	sb.WriteString("\n\n")
+
+	for _, n := range m.failures() {
+		fmt.Fprintf(&sb, "FAIL %s %s\n", n.pkg().name, n.testName())
+	}
+
+	sb.WriteString("\n")
+	if m.done {
+		if m.overallFail {
+			sb.WriteString("FAILED ")
+		} else {
+			sb.WriteString("PASSED ")
+		}
+	}
+	fmt.Fprintf(&sb, "%d tests", m.total)
+	if m.skips > 0 {
+		fmt.Fprintf(&sb, ", %d skipped", m.skips)
+	}
+	if m.fails > 0 {
+		fmt.Fprintf(&sb, ", %d failed", m.fails)
+	}
+	elapsed := scaledTimeSince(m.start)
+	if !m.end.IsZero() {
+		elapsed = m.end.Sub(m.start)
+	}
+	fmt.Fprintf(&sb, " in %s", round(elapsed, 1))
+	return sb.String()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSafely(t *testing.T) {
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestA", Output: "boom\n"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA", Elapsed: 2})
+	assert.Contains(t, m.renderSafely(false), "TestA")
+	assert.Empty(t, m.renderPanic)
+
+	// break the renderer
+	defer func(s []lipgloss.Style) { durationStyles = s }(durationStyles)
+	durationStyles = nil
+
+	out := m.renderSafely(false)
+	require.Contains(t, m.renderPanic, "panic: runtime error: index out of range")
+	require.NotEmpty(t, m.panicFile)
+	defer os.Remove(m.panicFile)
+	assert.Contains(t, out, "couldn't be rendered, please report the bug, with the panic saved in "+m.panicFile)
+	assert.Contains(t, out, "FAIL a TestA\n")
+	assert.Contains(t, out, "\n1 tests, 1 failed in ")
+
+	saved, err := os.ReadFile(m.panicFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), "index out of range")
+	assert.Contains(t, string(saved), "renderSafely")
+
+	// events are still processed, and the rest of the run uses the minimal renderer
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestB"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestB"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a"})
+	m.done, m.overallFail = true, true
+	assert.Contains(t, m.String(), "FAILED 2 tests, 1 failed in ")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"container/list"
+)
+
+// liveFilter restricts which tests are shown in the live view, by status.  Like
+// -filter, it only affects what's shown, not the counts.
+type liveFilter int
+
+const (
+	// filterAll shows every test
+	filterAll liveFilter = iota
+	// filterActive shows the running and failed tests
+	filterActive
+	// filterFailed shows only the failed tests
+	filterFailed
+)
+
+// next returns the filter after f, cycling back to filterAll.
+func (f liveFilter) next() liveFilter {
+	return (f + 1) % (filterFailed + 1)
+}
+
+func (f liveFilter) String() string {
+	switch f {
+	case filterActive:
+		return "running and failed"
+	case filterFailed:
+		return "failed"
+	}
+	return "all"
+}
+
+// filtering returns true if the live view is filtered, by the status filter or -filter.
+func (m *model) filtering() bool {
+	return m.liveFilter != filterAll || flags.filter != nil
+}
+
+// matchesFilter returns true if the node itself passes the status filter and -filter.
+// -filter is matched against the package name and test name, like "pkg TestFoo/sub".
+func (m *model) matchesFilter(n *node) bool {
+	switch m.liveFilter {
+	case filterActive:
+		if n.done && n.status != "fail" {
+			return false
+		}
+	case filterFailed:
+		if n.status != "fail" {
+			return false
+		}
+	}
+	if flags.filter == nil {
+		return true
+	}
+	name := n.pkg().name
+	if n.isTest {
+		name += " " + n.testName()
+	}
+	return flags.filter.MatchString(name)
+}
+
+// visibleNodes returns the nodes which pass the filters, and their ancestors, so
+// the matching nodes are shown in place in the tree.
+func (m *model) visibleNodes() map[*node]bool {
+	visible := map[*node]bool{}
+	var walk func(n *node) bool
+	walk = func(n *node) bool {
+		show := m.matchesFilter(n)
+		for _, c := range n.children {
+			if walk(c) {
+				show = true
+			}
+		}
+		if show {
+			visible[n] = true
+		}
+		return show
+	}
+	for _, n := range m.root.children {
+		walk(n)
+	}
+	return visible
+}
+
+// applyFilter returns the nodes in l which are shown by the live view's filters.
+func (m *model) applyFilter(l *list.List) *list.List {
+	if !m.filtering() {
+		return l
+	}
+	visible := m.visibleNodes()
+	filtered := list.New()
+	for _, n := range listSeq(l) {
+		if visible[n] {
+			filtered.PushBack(n)
+		}
+	}
+	return filtered
+}
+
+// filterLabel describes the live view's filters, for the totals line.
+func (m *model) filterLabel() string {
+	label := "showing " + m.liveFilter.String()
+	if flags.filter != nil {
+		label += " matching " + flags.filter.String()
+	}
+	return label + " (f)"
+}
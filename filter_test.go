@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveFilter(t *testing.T) {
+	keepFlags(t)
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestPass"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestPass"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestFail"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestFail/sub"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestFail/sub"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestFail"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestRunning"})
+
+	assert.Contains(t, m.View(), "TestPass")
+	assert.NotContains(t, m.View(), "showing")
+
+	m.Update(key("f"))
+	assert.Equal(t, filterActive, m.liveFilter)
+	view := m.View()
+	assert.NotContains(t, view, "TestPass")
+	assert.Contains(t, view, "TestFail")
+	assert.Contains(t, view, "TestRunning")
+	assert.Contains(t, view, "showing running and failed (f)")
+
+	m.Update(key("f"))
+	view = m.View()
+	assert.NotContains(t, view, "TestRunning")
+	assert.Contains(t, view, "sub")
+	assert.Contains(t, view, "3 tests", "the totals count every test")
+
+	m.Update(key("f"))
+	assert.Equal(t, filterAll, m.liveFilter)
+
+	// f leaves the cursor before cycling the filter
+	m.Update(key("down"))
+	m.Update(key("f"))
+	assert.Nil(t, m.cursor)
+	assert.Equal(t, filterAll, m.liveFilter)
+
+	flags.filter = regexp.MustCompile(`^a TestRun`)
+	view = m.View()
+	assert.NotContains(t, view, "TestFail")
+	assert.Contains(t, view, "TestRunning")
+	assert.Contains(t, view, "showing all matching ^a TestRun (f)")
+	assert.Len(t, m.navNodes(), 2)
+
+	// the final view isn't filtered
+	assert.Contains(t, m.String(), "TestFail")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// countRun records the result of one run of a test.  A test which failed in
+// any run is failed, like go test reports it, so it isn't dropped from the tree,
+// even if a later run passed.
+func (m *model) countRun(n *node, action string) {
+	wasFlaky := n.flaky()
+	switch action {
+	case "pass":
+		n.passedRuns++
+	case "fail":
+		n.failedRuns++
+	}
+	if n.flaky() && !wasFlaky {
+		m.flaky = append(m.flaky, n)
+	}
+	if n.failedRuns > 0 {
+		n.status = "fail"
+	}
+}
+
+// runCounts are the results of a test's runs.
+type runCounts struct {
+	passed, failed int
+}
+
+// forget drops a finished test from the tree, for -low-memory.  Its run counts are
+// kept until its package finishes, which are much smaller than the node, so if it
+// runs again, e.g. with -count, its flakiness is still detected.
+func (m *model) forget(n *node) {
+	if m.forgotten == nil {
+		m.forgotten = map[string]map[string]runCounts{}
+	}
+	pkg := n.pkg().name
+	if m.forgotten[pkg] == nil {
+		m.forgotten[pkg] = map[string]runCounts{}
+	}
+	m.forgotten[pkg][n.testName()] = runCounts{passed: n.passedRuns, failed: n.failedRuns}
+	n.parent.children = slices.DeleteFunc(n.parent.children, func(c *node) bool { return c == n })
+}
+
+// remember restores the run counts of a forgotten test, when it runs again.
+func (m *model) remember(n *node) {
+	forgotten := m.forgotten[n.pkg().name]
+	if c, ok := forgotten[n.testName()]; ok {
+		n.passedRuns, n.failedRuns = c.passed, c.failed
+		delete(forgotten, n.testName())
+	}
+}
+
+func renderFlaky(m *model) string {
+	if len(m.flaky) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Flaky tests:\n")
+	for _, n := range m.flaky {
+		fmt.Fprintf(&sb, "  %s %s\t%s\t%s\n", failedText.Render("FLAKY"), n.testName(), n.runsMsg(), gray.Render(n.pkg().name))
+	}
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlaky(t *testing.T) {
+	m := newModel()
+	events := []TestEvent{{Action: "start", Package: "a"}}
+	// go test -count=3
+	for _, result := range []string{"pass", "fail", "pass"} {
+		events = append(events,
+			TestEvent{Action: "run", Package: "a", Test: "TestA"},
+			TestEvent{Action: result, Package: "a", Test: "TestA", Elapsed: 0.1},
+			TestEvent{Action: "run", Package: "a", Test: "TestB"},
+			TestEvent{Action: "pass", Package: "a", Test: "TestB", Elapsed: 0.1},
+		)
+	}
+	events = append(events, TestEvent{Action: "fail", Package: "a"})
+	feed(m, events...)
+
+	testA, _ := m.root.children[0].findChild([]string{"TestA"})
+	require.NotNil(t, testA)
+	assert.Equal(t, "fail", testA.status, "tests which failed in any run are failed")
+	assert.True(t, testA.done)
+	assert.Equal(t, "2/3 passed", testA.runsMsg())
+	assert.Equal(t, []*node{testA}, m.flaky)
+
+	testB, _ := m.root.children[0].findChild([]string{"TestB"})
+	assert.Nil(t, testB, "tests which always passed are dropped")
+
+	assert.Equal(t, 6, m.total)
+	assert.Equal(t, 1, m.fails)
+
+	out := m.String()
+	assert.Contains(t, out, "FLAKY 2/3 passed")
+	assert.Contains(t, out, ", 1 flaky in")
+	assert.Contains(t, renderFlaky(m), "FLAKY TestA\t2/3 passed\ta")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// With go test -fuzz, the fuzzing engine prints a status line every few seconds
+// until the fuzz target fails or is stopped.  Those lines aren't kept in the
+// target's output, instead the latest is shown on the target's line in the live
+// view.  When the fuzzer finds a failing input, it's written to testdata/fuzz, and
+// its path is shown next to the failure, and in the summary.
+
+var (
+	// fuzzStatusPattern matches the fuzzer's status lines, e.g.
+	// "fuzz: elapsed: 3s, execs: 325017 (108336/sec), new interesting: 11 (total: 202)"
+	fuzzStatusPattern = regexp.MustCompile(`^fuzz: elapsed: (\S+), (.*)$`)
+	fuzzExecsPattern  = regexp.MustCompile(`^execs: (\d+) \((\d+)/sec\), new interesting: (\d+) \(total: (\d+)\)`)
+	// fuzzInputPattern matches the line naming the file a failing input was written
+	// to, e.g. "Failing input written to testdata/fuzz/FuzzFoo/a878c3134fe0404d"
+	fuzzInputPattern = regexp.MustCompile(`^Failing input written to (\S+)$`)
+)
+
+// fuzzStatus is the latest status of a fuzz target.
+type fuzzStatus struct {
+	elapsed string
+	// phase is what the fuzzer is doing when it isn't fuzzing, e.g. "gathering
+	// baseline coverage: 0/192 completed"
+	phase                             string
+	execs, perSec, interesting, total int64
+	// inputs are the failing inputs written to testdata/fuzz
+	inputs []string
+}
+
+// fuzzOutput records the fuzzer's status lines and failing inputs.  Returns true
+// if the line shouldn't be kept in the node's output.
+func (m *model) fuzzOutput(n *node, line string) bool {
+	line = strings.TrimSpace(line)
+	if matches := fuzzInputPattern.FindStringSubmatch(line); matches != nil {
+		f := m.fuzzTarget(n)
+		f.inputs = append(f.inputs, matches[1])
+		return false
+	}
+	matches := fuzzStatusPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+	f := m.fuzzTarget(n)
+	f.elapsed = matches[1]
+	if c := fuzzExecsPattern.FindStringSubmatch(matches[2]); c != nil {
+		f.phase = ""
+		f.execs, _ = strconv.ParseInt(c[1], 10, 64)
+		f.perSec, _ = strconv.ParseInt(c[2], 10, 64)
+		f.interesting, _ = strconv.ParseInt(c[3], 10, 64)
+		f.total, _ = strconv.ParseInt(c[4], 10, 64)
+	} else {
+		f.phase = matches[2]
+	}
+	return true
+}
+
+// fuzzTarget returns the node's fuzz status, adding it to the run's fuzz targets
+// the first time.
+func (m *model) fuzzTarget(n *node) *fuzzStatus {
+	if n.fuzz == nil {
+		n.fuzz = &fuzzStatus{}
+		m.fuzzTargets = append(m.fuzzTargets, n)
+	}
+	return n.fuzz
+}
+
+func (f *fuzzStatus) String() string {
+	var parts []string
+	if f.elapsed != "" {
+		parts = append(parts, "fuzzing "+f.elapsed)
+	}
+	switch {
+	case f.phase != "":
+		parts = append(parts, f.phase)
+	case f.execs > 0:
+		parts = append(parts, fmt.Sprintf("%d execs/s", f.perSec), fmt.Sprintf("%d new interesting (%d total)", f.interesting, f.total))
+	}
+	switch len(f.inputs) {
+	case 0:
+	case 1:
+		parts = append(parts, "1 crasher")
+	default:
+		parts = append(parts, fmt.Sprintf("%d crashers", len(f.inputs)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fuzzRerunCommand returns the go test command which re-runs a failing input.  The
+// input's path is testdata/fuzz/<target>/<input>.
+func fuzzRerunCommand(n *node, input string) string {
+	return fmt.Sprintf("go test -run=%s/%s %s", path.Base(path.Dir(input)), path.Base(input), n.pkg().name)
+}
+
+// renderFuzz lists the fuzz targets, with their final stats, and the failing
+// inputs they found.
+func renderFuzz(m *model) string {
+	if len(m.fuzzTargets) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Fuzz targets:\n")
+	for _, n := range m.fuzzTargets {
+		fmt.Fprintf(&sb, "  %s %s\t%s\t%s\n", m.icon(n), n.testName(), gray.Render(n.pkg().name), n.fuzz)
+		for _, input := range n.fuzz.inputs {
+			fmt.Fprintf(&sb, "    %s %s\n", failedText.Render("failing input:"), input)
+			fmt.Fprintf(&sb, "    %s\n", gray.Render("re-run: "+fuzzRerunCommand(n, input)))
+		}
+	}
+	return sb.String()
+}
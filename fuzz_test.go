@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzz(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "FuzzParse"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "=== RUN   FuzzParse\n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "fuzz: elapsed: 0s, gathering baseline coverage: 0/192 completed\n"},
+	}...)
+	require.Len(t, m.fuzzTargets, 1)
+	target := m.fuzzTargets[0]
+	assert.Equal(t, "fuzzing 0s, gathering baseline coverage: 0/192 completed", target.fuzz.String())
+
+	feed(m, []TestEvent{
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "fuzz: elapsed: 3s, execs: 325017 (108336/sec), new interesting: 11 (total: 202)\n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "--- FAIL: FuzzParse (3.02s)\n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "    parse_test.go:12: index out of range\n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "    \n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "    Failing input written to testdata/fuzz/FuzzParse/a878c3134fe0404d\n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "    To re-run:\n"},
+		{Action: "output", Package: "a", Test: "FuzzParse", Output: "    go test -run=FuzzParse/a878c3134fe0404d\n"},
+		{Action: "fail", Package: "a", Test: "FuzzParse", Elapsed: 3.02},
+		{Action: "fail", Package: "a", Elapsed: 3.1},
+	}...)
+	assert.Equal(t, "fuzzing 3s, 108336 execs/s, 11 new interesting (202 total), 1 crasher", target.fuzz.String())
+	assert.Equal(t, []string{"testdata/fuzz/FuzzParse/a878c3134fe0404d"}, target.fuzz.inputs)
+
+	pkg := m.root.children[0]
+	assert.NotContains(t, pkg.log, "fuzz: elapsed", "the status lines are only shown on the target's line")
+	assert.Contains(t, pkg.log, "Failing input written to")
+
+	var sb strings.Builder
+	m.println(target, &sb)
+	assert.Contains(t, sb.String(), "1 crasher  failing input: testdata/fuzz/FuzzParse/a878c3134fe0404d")
+
+	out := renderFuzz(m)
+	assert.Contains(t, out, "Fuzz targets:\n")
+	assert.Contains(t, out, "FuzzParse\ta\tfuzzing 3s, 108336 execs/s")
+	assert.Contains(t, out, "failing input: testdata/fuzz/FuzzParse/a878c3134fe0404d\n")
+	assert.Contains(t, out, "re-run: go test -run=FuzzParse/a878c3134fe0404d a\n")
+}
+
+func TestFuzzNoTargets(t *testing.T) {
+	assert.Empty(t, renderFuzz(newModel()))
+}
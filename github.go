@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// githubActions returns true if workflow commands and the step summary should be
+// written, with -github, or when running in GitHub Actions.
+func githubActions() bool {
+	return flags.github || os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// writeGitHubAnnotations writes an ::error workflow command for each failed test,
+// which GitHub shows on the failing line in the PR.  Tests which only failed
+// because a subtest failed are skipped, since the subtest has the annotation.
+func writeGitHubAnnotations(m *model, w io.Writer) {
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range append(n.children, n.dropped...) {
+			walk(c)
+		}
+		if !n.isTest || n.status != "fail" || n.hasFailedChild() {
+			return
+		}
+		props := "title=" + escapeProperty(n.pkg().name+"."+n.testName())
+		if file, line := failingLine(n.log); file != "" {
+			props = fmt.Sprintf("file=%s,line=%s,", escapeProperty(githubPath(m, n.pkg().name, file)), line) + props
+		}
+		msg := strings.TrimSpace(n.log)
+		if msg == "" {
+			msg = "failed"
+		}
+		fmt.Fprintf(w, "::error %s::%s\n", props, escapeData(msg))
+	}
+	walk(&m.root)
+}
+
+// hasFailedChild returns true if any of the node's children failed.
+func (n *node) hasFailedChild() bool {
+	for _, c := range append(n.children, n.dropped...) {
+		if c.status == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+// githubPath returns the path of a file in the test output relative to the
+// repository root, which is where GitHub looks for annotated files.  Bare file
+// names are in the package's directory.
+func githubPath(m *model, pkg, file string) string {
+	if m.paths == nil || m.paths.module == "" {
+		return file
+	}
+	if !strings.Contains(file, "/") {
+		if pkg != m.paths.module && !strings.HasPrefix(pkg, m.paths.module+"/") {
+			return file
+		}
+		file = m.paths.trimModule(pkg + "/" + file)
+	}
+	// the module may be in a subdirectory of the repository
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" {
+		if dir, ok := strings.CutPrefix(m.paths.root, strings.TrimSuffix(ws, "/")+"/"); ok {
+			file = dir + "/" + file
+		}
+	}
+	return file
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// writeStepSummary appends a Markdown table of the results to the job's step
+// summary, at $GITHUB_STEP_SUMMARY.
+func writeStepSummary(m *model) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.WriteString(f, stepSummary(m.summary()))
+	return err
+}
+
+// stepSummary renders the summary as Markdown.
+func stepSummary(s summary) string {
+	var sb strings.Builder
+	icons := map[string]string{"pass": "✅", "fail": "❌", "skip": "⏭️", "aborted": "⛔"}
+
+	fmt.Fprintf(&sb, "### %s Tests %sed\n\n", icons[s.Result], s.Result)
+	fmt.Fprintf(&sb, "%d tests, %d passed, %d failed, %d skipped in %s", s.Tests, s.Passed, s.Failed, s.Skipped, round(secondsDuration(s.Elapsed), 1))
+	if flags.timeFormat != "" && !s.Start.IsZero() {
+		fmt.Fprintf(&sb, ", started %s", formatTime(s.Start, ""))
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString("| | Package | Tests | Failed | Elapsed |\n")
+	sb.WriteString("|---|---|---:|---:|---:|\n")
+	for _, p := range s.Packages {
+		var failed int
+		for _, t := range p.Tests {
+			if t.Status == "fail" {
+				failed++
+			}
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %d | %d | %s |\n", icons[p.Status], markdownCode(p.Name), p.testCount, failed, round(secondsDuration(p.Elapsed), 1))
+	}
+
+	var failures []summaryTest
+	for _, p := range s.Packages {
+		for _, t := range p.Tests {
+			if t.Status == "fail" {
+				t.Package = p.Name
+				failures = append(failures, t)
+			}
+		}
+	}
+	if len(failures) > 0 {
+		sb.WriteString("\n#### Failures\n\n")
+		for _, t := range failures {
+			fmt.Fprintf(&sb, "<details><summary>%s</summary>\n\n%s\n\n</details>\n\n", htmlCode(t.Package+"."+t.Name), fencedBlock(strings.TrimSpace(t.Output)))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
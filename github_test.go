@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubAnnotations(t *testing.T) {
+	m := newModel()
+	m.paths = &pathNormalizer{root: "/work/repo/mod", module: "example.com/mod", abs: map[string]string{}}
+	t.Setenv("GITHUB_WORKSPACE", "/work/repo")
+	feed(m, []TestEvent{
+		{Action: "start", Package: "example.com/mod/pkg"},
+		{Action: "run", Package: "example.com/mod/pkg", Test: "TestA"},
+		{Action: "run", Package: "example.com/mod/pkg", Test: "TestA/sub"},
+		{Action: "output", Package: "example.com/mod/pkg", Test: "TestA/sub", Output: "    a_test.go:12: 100% wrong\n"},
+		{Action: "fail", Package: "example.com/mod/pkg", Test: "TestA/sub"},
+		{Action: "output", Package: "example.com/mod/pkg", Test: "TestA", Output: "    --- FAIL: TestA/sub (0.00s)\n"},
+		{Action: "fail", Package: "example.com/mod/pkg", Test: "TestA"},
+		{Action: "run", Package: "example.com/mod/pkg", Test: "TestB"},
+		{Action: "fail", Package: "example.com/mod/pkg", Test: "TestB"},
+		{Action: "fail", Package: "example.com/mod/pkg"},
+	}...)
+	m.root.processChildren(true, true)
+
+	var sb strings.Builder
+	writeGitHubAnnotations(m, &sb)
+	assert.Equal(t, "::error file=mod/pkg/a_test.go,line=12,title=example.com/mod/pkg.TestA/sub::a_test.go:12: 100%25 wrong\n"+
+		"::error title=example.com/mod/pkg.TestB::failed\n", sb.String())
+}
+
+func TestStepSummary(t *testing.T) {
+	keepFlags(t)
+	path := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+	flags.github = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a", Elapsed: 1.5},
+	}...)
+	m.root.processChildren(true, true)
+	assert.Empty(t, m.root.children[0].dropped, "passed tests aren't retained for the step summary")
+	require.NoError(t, writeStepSummary(m))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	md := string(b)
+	assert.Contains(t, md, "### ❌ Tests failed")
+	assert.Contains(t, md, "| ❌ | `a` | 2 | 1 | 1.5s |")
+	assert.Contains(t, md, "<code>a.TestB</code>")
+	assert.Contains(t, md, "boom")
+}
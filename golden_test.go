@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Golden tests replay recorded `go test -json` fixtures through the model, and compare
+// the rendered frames and the final summary to golden files in testdata/golden.
+//
+// After an intended change to the rendering, rewrite the golden files, and review
+// the diff:
+//
+//	go test -run TestGolden -update
+//
+// To add a fixture, add a package of tests to testdata/golden/src/<name>, then record
+// its output, which also writes its golden file:
+//
+//	go test -run TestGolden -record <name>
+var (
+	updateGolden = flag.Bool("update", false, "rewrite the golden files")
+	recordGolden = flag.String("record", "", "record the fixture for the package in testdata/golden/src/`name`")
+)
+
+// goldenSize is the terminal size the golden frames are rendered at.
+const goldenWidth, goldenHeight = 80, 24
+
+func TestGolden(t *testing.T) {
+	if *recordGolden != "" {
+		recordFixture(t, *recordGolden)
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "golden", "*.jsonl"))
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".jsonl")
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(fixture)
+			require.NoError(t, err)
+			defer f.Close()
+
+			got := renderGolden(t, f)
+			path := strings.TrimSuffix(fixture, ".jsonl") + ".golden"
+			if *updateGolden || *recordGolden == name {
+				require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "run with -update to create the golden file")
+			assert.Equal(t, string(want), got, "rendering changed, if intended, run with -update and review the diff")
+		})
+	}
+}
+
+// renderGolden replays a fixture through the model, with the model's clock following
+// the event timestamps, and returns each distinct frame of the live view, followed by
+// the final summary.
+func renderGolden(t *testing.T, r io.Reader) string {
+	var simTime time.Time
+	now = func() time.Time { return simTime }
+	defer func() { now = time.Now }()
+
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: goldenWidth, Height: goldenHeight})
+
+	var sb strings.Builder
+	var lastFrame string
+	var start time.Time
+	frames := 0
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		ev, err := decodeEvent(s.Bytes())
+		require.NoError(t, err, "fixtures should only contain test events")
+
+		if start.IsZero() {
+			start = ev.Time
+			m.start = start
+		}
+		simTime = ev.Time
+		// the commands only print above the view
+		_ = m.processEvent(ev)
+
+		if frame := m.render(true); frame != lastFrame {
+			lastFrame = frame
+			frames++
+			fmt.Fprintf(&sb, "--- frame %d at %v ---\n%s\n", frames, simTime.Sub(start).Round(time.Millisecond), frame)
+		}
+	}
+	require.NoError(t, s.Err())
+
+	m.done = true
+	m.end = simTime
+	m.root.processChildren(true, true)
+	fmt.Fprintf(&sb, "--- final ---\n%s\n", m.String())
+	return sb.String()
+}
+
+// recordFixture runs the tests in testdata/golden/src/<name>, and saves the output as
+// the fixture testdata/golden/<name>.jsonl.
+func recordFixture(t *testing.T, name string) {
+	cmd := exec.Command("go", "test", "-json", "-count=1", "./"+filepath.ToSlash(filepath.Join("testdata", "golden", "src", name)))
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		require.NoError(t, err)
+	}
+	require.NotEmpty(t, out)
+	require.NoError(t, os.WriteFile(filepath.Join("testdata", "golden", name+".jsonl"), out, 0o644))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// grepEvents searches the output events in a recorded `go test -json` stream, and prints
+// the matching lines, prefixed with their timestamp, package, and test.  Returns the number
+// of matches.
+func grepEvents(r io.Reader, re *regexp.Regexp, w io.Writer) (int, error) {
+	var matches int
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64*1024*1024)
+	for s.Scan() {
+		ev, err := decodeEvent(s.Bytes())
+		if err != nil || ev.Action != "output" {
+			continue
+		}
+		line := strings.TrimRight(ev.Output, "\n")
+		if !re.MatchString(line) {
+			continue
+		}
+		matches++
+
+		name := ev.Package
+		if ev.Test != "" {
+			name += " " + ev.Test
+		}
+		var ts string
+		if !ev.Time.IsZero() {
+			ts = formatTime(ev.Time, time.RFC3339Nano) + " "
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", ts, name, line)
+	}
+	return matches, s.Err()
+}
+
+// grepMain implements the grep subcommand.  Like grep, exits with 1 if
+// nothing matched, and 2 on errors.
+func grepMain(args []string) int {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	infile := fs.String("f", "", "Read from <filename> instead of stdin")
+	ignoreCase := fs.Bool("i", false, "Case insensitive matching")
+	fs.StringVar(&flags.timeFormat, "time-format", "", "The `format` of the timestamps, one of "+strings.Join(timeFormats, ", "))
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n\t%s grep [flags] <regex>\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Searches the test output in a recorded 'go test -json' stream.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if flags.timeFormat != "" && !slices.Contains(timeFormats, flags.timeFormat) {
+		fmt.Fprintf(fs.Output(), "invalid -time-format %q, must be one of %v\n", flags.timeFormat, timeFormats)
+		return 2
+	}
+
+	pattern := fs.Arg(0)
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid regex:", err)
+		return 2
+	}
+
+	var r io.Reader = os.Stdin
+	if *infile != "" {
+		f, err := os.Open(*infile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer f.Close()
+		r = f
+	}
+
+	matches, err := grepEvents(r, re, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if matches == 0 {
+		return 1
+	}
+	return 0
+}
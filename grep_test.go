@@ -0,0 +1,24 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrepEvents(t *testing.T) {
+	in := `{"Time":"2024-08-31T18:12:41.334413-04:00","Action":"start","Package":"a"}
+{"Time":"2024-08-31T18:12:42.1-04:00","Action":"output","Package":"a","Test":"TestA","Output":"    a_test.go:10: connection refused\n"}
+not json: connection refused
+{"Time":"2024-08-31T18:12:42.2-04:00","Action":"output","Package":"a","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n"}
+{"Action":"output","Package":"b","Output":"Connection Refused\n"}
+`
+	var sb strings.Builder
+	n, err := grepEvents(strings.NewReader(in), regexp.MustCompile(`(?i)connection refused`), &sb)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "2024-08-31T18:12:42.1-04:00 a TestA:     a_test.go:10: connection refused\nb: Connection Refused\n", sb.String())
+}
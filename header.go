@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// shufflePattern matches the line a test binary prints first with -shuffle, with
+// the seed it shuffled the tests with, e.g. "-test.shuffle 1698765432123456789".
+var shufflePattern = regexp.MustCompile(`^-test\.shuffle (-?\d+)\n?$`)
+
+// envHeaders are the header lines go test prints before benchmarks, which describe
+// the machine they ran on.
+var envHeaders = []string{"goos", "goarch", "cpu"}
+
+// captureHeader records the header lines of a package's output: the -shuffle seed,
+// and the benchmarks' environment.  Returns true if the line shouldn't be kept in
+// the package's output, since it's shown in the summary instead.
+func (m *model) captureHeader(pkg *node, line string) bool {
+	if matches := shufflePattern.FindStringSubmatch(line); matches != nil {
+		pkg.shuffle = matches[1]
+		return true
+	}
+	key, value, ok := strings.Cut(strings.TrimSpace(line), ": ")
+	if ok && slices.Contains(envHeaders, key) {
+		if m.headers == nil {
+			m.headers = map[string]string{}
+		}
+		if _, seen := m.headers[key]; !seen {
+			m.headers[key] = value
+		}
+	}
+	return false
+}
+
+// renderReproduce shows how to reproduce the run: go test commands with the -shuffle
+// seeds of the failed packages, and the machine the benchmarks ran on.
+func renderReproduce(m *model) string {
+	var sb strings.Builder
+	var shuffled int
+	for _, n := range m.root.children {
+		if n.shuffle == "" {
+			continue
+		}
+		shuffled++
+		if n.status == "fail" {
+			// the order depends on which tests run, so the package's tests all have to be run again
+			fmt.Fprintf(&sb, "  go test -count=1 -shuffle=%s %s\n", n.shuffle, n.name)
+		}
+	}
+	if sb.Len() == 0 && shuffled > 0 {
+		fmt.Fprintf(&sb, "  shuffled packages: %d, none failed\n", shuffled)
+	}
+	var env []string
+	for _, key := range envHeaders {
+		if v, ok := m.headers[key]; ok {
+			env = append(env, key+": "+v)
+		}
+	}
+	if len(env) > 0 {
+		fmt.Fprintf(&sb, "  %s\n", gray.Render(strings.Join(env, "  ")))
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "Reproduce:\n" + sb.String()
+}
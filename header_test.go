@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShuffleSeed(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "output", Package: "a", Output: "-test.shuffle 1698765432123456789\n"},
+		{Action: "output", Package: "a", Output: "goos: linux\n"},
+		{Action: "output", Package: "a", Output: "cpu: Intel(R) Core(TM) i7\n"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Output: "FAIL\ta\t0.1s\n"},
+		{Action: "fail", Package: "a"},
+		{Action: "start", Package: "b"},
+		{Action: "output", Package: "b", Output: "-test.shuffle 42\n"},
+		{Action: "pass", Package: "b"},
+	}...)
+
+	pkg := m.root.children[0]
+	assert.Equal(t, "1698765432123456789", pkg.shuffle)
+	assert.NotContains(t, pkg.log, "-test.shuffle", "the seed is shown in the summary instead")
+	assert.Contains(t, pkg.log, "goos: linux")
+	assert.Equal(t, "Reproduce:\n  go test -count=1 -shuffle=1698765432123456789 a\n  goos: linux  cpu: Intel(R) Core(TM) i7\n", renderReproduce(m))
+	assert.Equal(t, "42", m.summary().Packages[1].Shuffle)
+
+	// without failures, only a count is shown
+	m.root.children = m.root.children[1:]
+	m.headers = nil
+	assert.Equal(t, "Reproduce:\n  shuffled packages: 1, none failed\n", renderReproduce(m))
+}
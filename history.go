@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The history database is a JSON lines file in the user's cache dir.  Each
+// line is the record of a single run.
+
+type historyResult struct {
+	Package string
+	Test    string
+	Action  string
+	Elapsed time.Duration
+}
+
+type historyRun struct {
+	Time    time.Time
+	Results []historyResult
+}
+
+func (m *model) historyRun() historyRun {
+	return historyRun{Time: m.start, Results: m.results}
+}
+
+// historyPath returns the location of the history database.  Can be
+// overridden with $GOTESTPRETTY_HISTORY.
+func historyPath() (string, error) {
+	return cachePath("GOTESTPRETTY_HISTORY", "history.jsonl")
+}
+
+// cachePath returns the path of a file in gotestpretty's cache dir, unless
+// overridden by the environment variable.
+func cachePath(envVar, name string) (string, error) {
+	if p := os.Getenv(envVar); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotestpretty", name), nil
+}
+
+func appendHistory(run historyRun) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// readHistory reads all the runs in the history database, oldest first.
+// Lines which can't be parsed are skipped.
+func readHistory() ([]historyRun, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []historyRun
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 64*1024*1024)
+	for s.Scan() {
+		var run historyRun
+		if err := json.Unmarshal(s.Bytes(), &run); err == nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs, s.Err()
+}
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders the values as a line of unicode block characters, scaled
+// between the min and max values.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkChars)-1))
+		}
+		sb.WriteRune(sparkChars[i])
+	}
+	return sb.String()
+}
+
+// resultline renders pass/fail/skip results as a line of blocks: a tall green block for
+// passes, a short red block for failures.
+func resultline(results []historyResult) string {
+	passed := lipgloss.NewStyle().Foreground(colorPassed)
+	failed := lipgloss.NewStyle().Foreground(colorFailed)
+	skipped := lipgloss.NewStyle().Foreground(colorSkipped)
+
+	var sb strings.Builder
+	for _, r := range results {
+		switch r.Action {
+		case "pass":
+			sb.WriteString(passed.Render("█"))
+		case "fail":
+			sb.WriteString(failed.Render("▁"))
+		default:
+			sb.WriteString(skipped.Render("·"))
+		}
+	}
+	return sb.String()
+}
+
+// testHistory collects the results for tests matching name, keyed by package and test,
+// in the order the tests were first seen.  Only the last n results are kept.
+func testHistory(runs []historyRun, name string, n int) (keys []string, results map[string][]historyResult) {
+	results = map[string][]historyResult{}
+	for _, run := range runs {
+		for _, r := range run.Results {
+			if r.Test != name && r.Package+"."+r.Test != name {
+				continue
+			}
+			key := r.Package + " " + r.Test
+			if _, ok := results[key]; !ok {
+				keys = append(keys, key)
+			}
+			results[key] = append(results[key], r)
+		}
+	}
+	for k, rs := range results {
+		if len(rs) > n {
+			results[k] = rs[len(rs)-n:]
+		}
+	}
+	return keys, results
+}
+
+func printSparklines(w io.Writer, keys []string, results map[string][]historyResult) {
+	for _, key := range keys {
+		rs := results[key]
+		var passes, fails int
+		durations := make([]float64, 0, len(rs))
+		var lo, hi time.Duration
+		for i, r := range rs {
+			switch r.Action {
+			case "pass":
+				passes++
+			case "fail":
+				fails++
+			}
+			durations = append(durations, float64(r.Elapsed))
+			if i == 0 || r.Elapsed < lo {
+				lo = r.Elapsed
+			}
+			if r.Elapsed > hi {
+				hi = r.Elapsed
+			}
+		}
+
+		fmt.Fprintln(w, key)
+		rate := "n/a"
+		if passes+fails > 0 {
+			rate = fmt.Sprintf("%.0f%%", float64(passes)/float64(passes+fails)*100)
+		}
+		fmt.Fprintf(w, "  results   %s  %s pass rate over %d runs\n", resultline(rs), rate, len(rs))
+		fmt.Fprintf(w, "  duration  %s  %s - %s\n", sparkline(durations), round(lo, 1), round(hi, 1))
+	}
+}
+
+// historyPanelRuns is the number of recent runs shown in the history panel.
+const historyPanelRuns = 30
+
+// historyLoaded is sent when the history has been read for the history panel.
+type historyLoaded []historyRun
+
+// toggleHistory shows or hides the history panel, reading the history the first
+// time it's shown.
+func (m *model) toggleHistory() tea.Cmd {
+	m.showHistory = !m.showHistory
+	if !m.showHistory || m.historyRuns != nil {
+		return nil
+	}
+	return func() tea.Msg {
+		runs, err := readHistory()
+		if err != nil {
+			log.Println("error reading history:", err)
+		}
+		return historyLoaded(runs)
+	}
+}
+
+// historyPanel renders the selected test's recent results and durations, like
+// history sparkline, for the live view to show below the tree.
+func (m *model) historyPanel() string {
+	if !m.showHistory {
+		return ""
+	}
+	n := m.selected()
+	switch {
+	case m.historyRuns == nil:
+		return gray.Render("history: loading...") + "\n"
+	case n == nil:
+		return gray.Render("history: select a test") + "\n"
+	}
+	keys, results := testHistory(m.historyRuns, n.pkg().name+"."+n.testName(), historyPanelRuns)
+	if len(keys) == 0 {
+		return gray.Render("history: no results for "+n.pkg().name+" "+n.testName()) + "\n"
+	}
+	var sb strings.Builder
+	printSparklines(&sb, keys, results)
+	return sb.String()
+}
+
+// historyMain implements the history subcommand.
+func historyMain(args []string) int {
+	usage := func() {
+		path, _ := historyPath()
+		fmt.Fprintf(os.Stderr, "Usage:\n\t%s history sparkline [-n runs] <test name>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Shows the recent results and durations of a test.  Results are recorded by running\n")
+		fmt.Fprintf(os.Stderr, "with -history.  The history is stored in %s\n", path)
+		fmt.Fprintf(os.Stderr, "(override with $GOTESTPRETTY_HISTORY).\n")
+	}
+
+	if len(args) == 0 || args[0] != "sparkline" {
+		usage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("sparkline", flag.ExitOnError)
+	n := fs.Int("n", 30, "Number of recent runs to show")
+	fs.Usage = usage
+	_ = fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		usage()
+		return 2
+	}
+
+	if err := setupTheme("auto"); err != nil {
+		fmt.Println("fatal:", err)
+		return 1
+	}
+
+	runs, err := readHistory()
+	if err != nil {
+		fmt.Println("fatal:", err)
+		return 1
+	}
+
+	keys, results := testHistory(runs, fs.Arg(0), *n)
+	if len(keys) == 0 {
+		fmt.Printf("no history for %s\n", fs.Arg(0))
+		return 1
+	}
+	printSparklines(os.Stdout, keys, results)
+	return 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "", sparkline(nil))
+	assert.Equal(t, "▁▁▁", sparkline([]float64{2, 2, 2}))
+	assert.Equal(t, "▁▄█", sparkline([]float64{0, 5, 10}))
+}
+
+func TestHistory(t *testing.T) {
+	t.Setenv("GOTESTPRETTY_HISTORY", filepath.Join(t.TempDir(), "history.jsonl"))
+
+	for _, action := range []string{"pass", "fail", "pass"} {
+		require.NoError(t, appendHistory(historyRun{
+			Time: time.Now(),
+			Results: []historyResult{
+				{Package: "a", Test: "TestA", Action: action, Elapsed: time.Second},
+				{Package: "b", Test: "TestB", Action: "pass"},
+			},
+		}))
+	}
+
+	runs, err := readHistory()
+	require.NoError(t, err)
+	assert.Len(t, runs, 3)
+
+	keys, results := testHistory(runs, "TestA", 2)
+	assert.Equal(t, []string{"a TestA"}, keys)
+	require.Len(t, results["a TestA"], 2)
+	assert.Equal(t, "fail", results["a TestA"][0].Action)
+
+	var sb strings.Builder
+	printSparklines(&sb, keys, results)
+	assert.Contains(t, sb.String(), "50% pass rate over 2 runs")
+}
+
+func TestHistoryPanel(t *testing.T) {
+	t.Setenv("GOTESTPRETTY_HISTORY", filepath.Join(t.TempDir(), "history.jsonl"))
+	for _, action := range []string{"pass", "fail"} {
+		require.NoError(t, appendHistory(historyRun{Results: []historyResult{
+			{Package: "a", Test: "TestA", Action: action, Elapsed: time.Second},
+		}}))
+	}
+
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestB"})
+
+	_, cmd := m.Update(key("h"))
+	require.NotNil(t, cmd)
+	assert.Contains(t, m.View(), "history: loading...")
+	m.Update(cmd())
+	assert.Contains(t, m.View(), "history: select a test")
+
+	m.Update(key("down"))
+	m.Update(key("down"))
+	require.Equal(t, "TestA", m.cursor.name)
+	assert.Contains(t, m.View(), "50% pass rate over 2 runs")
+
+	m.Update(key("down"))
+	assert.Contains(t, m.View(), "history: no results for a TestB")
+
+	// the history is only read once
+	_, cmd = m.Update(key("h"))
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.View(), "history:")
+	_, cmd = m.Update(key("h"))
+	assert.Nil(t, cmd)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// runHook runs a user supplied command with the shell.  vars are added to the
+// command's environment, and stdin is piped to the command.
+func runHook(command string, vars map[string]string, stdin string, stdout, stderr io.Writer) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = os.Environ()
+	for k, v := range vars {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// onFailHook returns a command which runs the -on-fail hook for a failure
+// event.  The output is also written to a temp file, named by GOTESTPRETTY_OUTPUT_PATH,
+// for commands which would rather open a file, like editors.
+func onFailHook(ev TestEvent, output *bytes.Buffer) tea.Cmd {
+	if flags.onFail == "" {
+		return nil
+	}
+	var out string
+	if output != nil {
+		out = output.String()
+	}
+	return func() tea.Msg {
+		vars := map[string]string{
+			"GOTESTPRETTY_EVENT":   "fail",
+			"GOTESTPRETTY_PACKAGE": ev.Package,
+			"GOTESTPRETTY_TEST":    ev.Test,
+			"GOTESTPRETTY_ELAPSED": strconv.FormatFloat(ev.Elapsed, 'f', -1, 64),
+		}
+		if f, err := os.CreateTemp("", "gotestpretty-*.out"); err != nil {
+			log.Println("error creating -on-fail output file:", err)
+		} else {
+			_, _ = f.WriteString(out)
+			_ = f.Close()
+			defer os.Remove(f.Name())
+			vars["GOTESTPRETTY_OUTPUT_PATH"] = f.Name()
+		}
+
+		// the hook's output would garble the live view, so it goes to the debug log
+		var buf bytes.Buffer
+		if err := runHook(flags.onFail, vars, out, &buf, &buf); err != nil {
+			log.Println("error running -on-fail:", err)
+		}
+		if buf.Len() > 0 {
+			log.Println("-on-fail output:", buf.String())
+		}
+		return nil
+	}
+}
+
+// runFinishHook runs the -on-finish hook, after the live view has exited.
+func runFinishHook(m *model) error {
+	result := "pass"
+	if m.overallFail {
+		result = "fail"
+	}
+	vars := map[string]string{
+		"GOTESTPRETTY_EVENT":   "finish",
+		"GOTESTPRETTY_RESULT":  result,
+		"GOTESTPRETTY_TOTAL":   strconv.Itoa(m.total),
+		"GOTESTPRETTY_PASSED":  strconv.Itoa(m.passes),
+		"GOTESTPRETTY_FAILED":  strconv.Itoa(m.fails),
+		"GOTESTPRETTY_SKIPPED": strconv.Itoa(m.skips),
+	}
+	return runHook(flags.onFinish, vars, m.String(), os.Stdout, os.Stderr)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	var out strings.Builder
+	err := runHook(`echo "$GOTESTPRETTY_TEST"; cat`, map[string]string{"GOTESTPRETTY_TEST": "TestA"}, "boom\n", &out, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "TestA\nboom\n", out.String())
+
+	assert.Error(t, runHook("exit 3", nil, "", &out, &out))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// maxStuckLines limits the tests listed as possibly hung in the live view.
+const maxStuckLines = 3
+
+// runningFor returns how long the test has been running, not counting time paused.
+func (n *node) runningFor() time.Duration {
+	if n.start.IsZero() {
+		return n.elapsed
+	}
+	return n.elapsed + scaledTimeSince(n.start)
+}
+
+// stuck returns true if the test has been running for longer than -stuck-threshold.
+// Tests waiting on running subtests aren't stuck themselves.
+func (n *node) stuck() bool {
+	if flags.stuckThreshold <= 0 || !n.isTest || n.done || n.start.IsZero() {
+		return false
+	}
+	for _, c := range n.children {
+		if !c.done {
+			return false
+		}
+	}
+	return n.runningFor() > flags.stuckThreshold
+}
+
+// stuckTests returns the tests which are possibly hung, see stuck.
+func (m *model) stuckTests() []*node {
+	var stuck []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			if c.stuck() {
+				stuck = append(stuck, c)
+			}
+			walk(c)
+		}
+	}
+	walk(&m.root)
+	return stuck
+}
+
+// renderStuck lists the possibly hung tests below the live view's tree.
+func (m *model) renderStuck(sb *strings.Builder, stuck []*node) {
+	for i, n := range stuck {
+		if i == maxStuckLines {
+			fmt.Fprintf(sb, "%s %s\n", iconStuck, gray.Render(fmt.Sprintf("+%d more possibly hung", len(stuck)-i)))
+			break
+		}
+		line := fmt.Sprintf("possibly hung: %s %s %s", n.pkg().name, n.testName(), round(n.runningFor(), 0))
+		if i == 0 && goTest != nil {
+			line += "  (d: dump goroutines)"
+		}
+		fmt.Fprintf(sb, "%s %s\n", iconStuck, gray.Render(line))
+	}
+}
+
+// dumpStuck sends SIGQUIT to go test, in wrapper mode, so the test binaries print
+// their goroutines and exit, like pressing ctrl+\.  The dump is attached to the
+// possibly hung tests.
+func (m *model) dumpStuck() tea.Cmd {
+	stuck := m.stuckTests()
+	if goTest == nil || len(stuck) == 0 {
+		return nil
+	}
+	for _, n := range stuck {
+		n.pkg().dumping = append(n.pkg().dumping, n)
+	}
+	run := goTest
+	return func() tea.Msg {
+		if err := run.quit(); err != nil {
+			log.Printf("dumping goroutines: %v", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func sendQuit(*os.Process) error {
+	return fmt.Errorf("SIGQUIT isn't supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStuckTests(t *testing.T) {
+	keepFlags(t)
+	defer func(f func() time.Time) { now = f }(now)
+	start := time.Now()
+	now = func() time.Time { return start }
+
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestHangs"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestParent"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestParent/sub"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestFast"})
+	now = func() time.Time { return start.Add(2 * time.Minute) }
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestLate"})
+
+	assert.Empty(t, m.stuckTests(), "disabled by default")
+
+	flags.stuckThreshold = time.Minute
+	var names []string
+	for _, n := range m.stuckTests() {
+		names = append(names, n.testName())
+	}
+	// TestParent is waiting on its subtest, and TestLate only just started
+	assert.Equal(t, []string{"TestHangs", "TestParent/sub", "TestFast"}, names)
+
+	out := m.render(false)
+	assert.Contains(t, out, iconStuck+" possibly hung: a TestHangs 2m0s\n")
+	assert.Contains(t, out, iconStuck+" possibly hung: a TestFast 2m0s\n")
+	assert.NotContains(t, out, "dump goroutines", "only in wrapper mode")
+
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestFast"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestMore"})
+	now = func() time.Time { return start.Add(5 * time.Minute) }
+	out = m.render(false)
+	assert.Contains(t, out, iconStuck+" +1 more possibly hung\n")
+	assert.NotContains(t, out, "TestFast 2m0s")
+
+	// nothing to dump without go test
+	assert.Nil(t, m.dumpStuck())
+}
+
+func TestDumpAttachedToStuckTests(t *testing.T) {
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestHangs"})
+	pkg := m.root.children[0]
+	hung := pkg.children[0]
+	pkg.dumping = []*node{hung}
+
+	m.processEvent(TestEvent{Action: "output", Package: "a", Output: "SIGQUIT: quit\n"})
+	m.processEvent(TestEvent{Action: "output", Package: "a", Output: "goroutine 7 [chan receive]:\n"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestHangs"})
+	assert.Contains(t, hung.log, "SIGQUIT: quit\ngoroutine 7 [chan receive]:\n")
+}
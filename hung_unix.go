@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// sendQuit sends SIGQUIT to p's process group, like ctrl+\ in a terminal.  go test
+// ignores it, and the test binaries print their goroutines and exit.
+func sendQuit(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGQUIT)
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoTestQuit(t *testing.T) {
+	// a shell standing in for go test, with a child standing in for a test binary
+	cmd := exec.Command("sh", "-c", "sleep 10 & wait")
+	setProcessGroup(cmd)
+	require.NoError(t, cmd.Start())
+	run := newGoTestRun(nil, nil)
+	run.running[cmd] = true
+
+	// only go test's process group gets the signal, not gotestpretty
+	require.NoError(t, run.quit())
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(cmd.Wait(), &exitErr))
+	status := exitErr.Sys().(syscall.WaitStatus)
+	assert.Equal(t, syscall.SIGQUIT, status.Signal())
+}
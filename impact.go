@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lineRange is an inclusive range of line numbers.
+type lineRange struct {
+	start, end int
+}
+
+// changedLines are the changed lines of each file, by module relative path.  A nil
+// slice means the whole file changed.
+type changedLines map[string][]lineRange
+
+// hunkPattern matches a hunk header in a unified diff.  The old file's line numbers
+// are used, since they match the cover profile of the earlier run.
+var hunkPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\S+ @@`)
+
+// parseDiff parses the changed lines from the output of git diff -U0.
+func parseDiff(r io.Reader) (changedLines, error) {
+	changed := changedLines{}
+	var file string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+			if file == "/dev/null" {
+				// a new file, which the earlier run couldn't have covered
+				file = ""
+			}
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			matches := hunkPattern.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(matches[1])
+			count := 1
+			if matches[2] != "" {
+				count, _ = strconv.Atoi(matches[2])
+			}
+			// lines added without removing any are inserted after start, and
+			// change the block they're inserted in
+			changed[file] = append(changed[file], lineRange{start, start + max(count, 1) - 1})
+		}
+	}
+	return changed, s.Err()
+}
+
+// gitChangedLines returns the uncommitted changes in the module at root.
+func gitChangedLines(root string) (changedLines, error) {
+	out, err := git(root, "diff", "-U0", "--no-color", "--no-ext-diff", "--relative", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(bytes.NewReader(out))
+}
+
+// changedFilesFlag parses -changed-files, a comma separated list of module relative paths.
+func changedFilesFlag(s string) changedLines {
+	changed := changedLines{}
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			changed[path.Clean(f)] = nil
+		}
+	}
+	return changed
+}
+
+// analyzeImpact compares the changed lines to the cover profile of an earlier run,
+// to tell whether the failures could run the changed code, or probably failed
+// before the change.  Cover profiles don't record which package's tests ran a
+// block: with -coverpkg, a package's blocks are also run by the tests of the
+// packages which import it, and the profiles are merged.  So the failures can only
+// be told apart from the change as a whole: they're possibly pre-existing when
+// none of the changed lines were run at all.
+func (m *model) analyzeImpact() error {
+	root, module := findModule(".")
+	if m.paths != nil && m.paths.module != "" {
+		root, module = m.paths.root, m.paths.module
+	}
+	if module == "" {
+		return fmt.Errorf("no go.mod found")
+	}
+
+	f, err := os.Open(flags.impactProfile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	blocks, err := parseCoverBlocks(f)
+	if err != nil {
+		return err
+	}
+
+	changed := changedFilesFlag(flags.changedFiles)
+	if flags.changedFiles == "" {
+		if changed, err = gitChangedLines(root); err != nil {
+			return err
+		}
+	}
+
+	// whether any tests ran the changed lines
+	var ran bool
+	for _, b := range blocks {
+		if !b.covered {
+			continue
+		}
+		rel, ok := strings.CutPrefix(b.file, module+"/")
+		if !ok {
+			continue
+		}
+		ranges, ok := changed[rel]
+		if !ok {
+			continue
+		}
+		if ranges == nil {
+			ran = true
+		}
+		for _, r := range ranges {
+			if r.start <= b.end && b.start <= r.end {
+				ran = true
+			}
+		}
+	}
+
+	m.impactAnalyzed = true
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range append(n.children, n.dropped...) {
+			walk(c)
+		}
+		if n.isTest && n.status == "fail" && !n.hasFailedChild() {
+			n.preexisting = !ran
+			if !n.preexisting {
+				m.relatedFailures++
+			}
+		}
+	}
+	walk(&m.root)
+	return nil
+}
+
+func renderImpact(m *model) string {
+	if !m.impactAnalyzed {
+		return ""
+	}
+	var preexisting []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			if c.preexisting {
+				preexisting = append(preexisting, c)
+			}
+			walk(c)
+		}
+	}
+	walk(&m.root)
+	if len(preexisting) == 0 && m.relatedFailures == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Change impact:\n")
+	fmt.Fprintf(&sb, "  failures which run the changed lines: %d\n", m.relatedFailures)
+	if len(preexisting) > 0 {
+		fmt.Fprintf(&sb, "  failures which don't, possibly pre-existing: %d\n", len(preexisting))
+		for _, n := range preexisting {
+			fmt.Fprintf(&sb, "    %s %s\n", n.pkg().name, n.testName())
+		}
+	}
+	return sb.String()
+}
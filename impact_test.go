@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiff(t *testing.T) {
+	diff := `diff --git a/a/x.go b/a/x.go
+index 1..2 100644
+--- a/a/x.go
++++ b/a/x.go
+@@ -3 +3 @@ func F() {
+-	return 1
++	return 2
+@@ -10,0 +11,2 @@ func G() {
++	a()
++	b()
+@@ -20,3 +22,0 @@
+diff --git a/b/new.go b/b/new.go
+new file mode 100644
+--- /dev/null
++++ b/b/new.go
+@@ -0,0 +1,3 @@
++package b
+`
+	changed, err := parseDiff(strings.NewReader(diff))
+	require.NoError(t, err)
+	assert.Equal(t, changedLines{"a/x.go": {{3, 3}, {10, 10}, {20, 22}}}, changed)
+}
+
+func TestAnalyzeImpact(t *testing.T) {
+	keepFlags(t)
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	require.NoError(t, os.WriteFile(profile, []byte(`mode: set
+example.com/m/a/x.go:1.1,5.2 2 1
+example.com/m/a/x.go:7.1,9.2 2 0
+example.com/m/b/y.go:1.1,5.2 2 1
+`), 0o644))
+	flags.impactProfile = profile
+
+	for _, tt := range []struct {
+		changed     string
+		preexisting []string
+	}{
+		// the profile doesn't say which package's tests ran a/x.go, so either
+		// failure could be related
+		{"a/x.go", nil},
+		{"a/x.go,b/y.go", nil},
+		{"c/z.go", []string{"TestA", "TestB"}},
+	} {
+		flags.changedFiles = tt.changed
+		m := newModel()
+		m.paths = &pathNormalizer{module: "example.com/m"}
+		feed(m, []TestEvent{
+			{Action: "start", Package: "example.com/m/a"},
+			{Action: "run", Package: "example.com/m/a", Test: "TestA"},
+			{Action: "fail", Package: "example.com/m/a", Test: "TestA"},
+			{Action: "fail", Package: "example.com/m/a"},
+			{Action: "start", Package: "example.com/m/b"},
+			{Action: "run", Package: "example.com/m/b", Test: "TestB"},
+			{Action: "fail", Package: "example.com/m/b", Test: "TestB"},
+			{Action: "fail", Package: "example.com/m/b"},
+		}...)
+		require.NoError(t, m.analyzeImpact())
+
+		var preexisting []string
+		for _, pkg := range m.root.children {
+			for _, c := range pkg.children {
+				if c.preexisting {
+					preexisting = append(preexisting, c.name)
+				}
+			}
+		}
+		assert.Equal(t, tt.preexisting, preexisting, tt.changed)
+		assert.Equal(t, 2-len(tt.preexisting), m.relatedFailures, tt.changed)
+	}
+}
+
+func TestRenderImpact(t *testing.T) {
+	m := newModel()
+	assert.Empty(t, renderImpact(m), "not analyzed")
+
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA"})
+	m.root.children[0].children[0].preexisting = true
+	m.impactAnalyzed, m.relatedFailures = true, 1
+
+	assert.Equal(t, "Change impact:\n  failures which run the changed lines: 1\n  failures which don't, possibly pre-existing: 1\n    a TestA\n", renderImpact(m))
+	assert.Contains(t, m.String(), "possibly pre-existing failure")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// With -debug and -debug-input-lines, the last lines of input are kept, and saved
+// to a temp file when processing them fails: reading the input fails, an event
+// which looks like JSON can't be decoded, or processing or rendering panics.  The
+// file can be read back with -f, to reproduce bugs with malformed streams.
+
+// inputLog is a ring buffer of the most recent lines of input.
+type inputLog struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+	// dumped is set once a malformed event was saved, so a stream full of them
+	// doesn't save a file per line
+	dumped bool
+}
+
+// recentInput is the input log, or nil unless -debug-input-lines is set.
+var recentInput *inputLog
+
+func newInputLog(size int) *inputLog {
+	return &inputLog{lines: make([]string, size)}
+}
+
+// add records a line of input.  It's safe to call on a nil log.
+func (l *inputLog) add(line string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines[l.next] = line
+	l.next = (l.next + 1) % len(l.lines)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// tail returns the recorded lines, oldest first.
+func (l *inputLog) tail() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.full {
+		return append([]string(nil), l.lines[:l.next]...)
+	}
+	return append(append([]string(nil), l.lines[l.next:]...), l.lines[:l.next]...)
+}
+
+// dump saves the recorded lines to a temp file, logging why, and returns the file's
+// path, or "" if there's no log or it couldn't be written.
+func (l *inputLog) dump(reason string) string {
+	if l == nil {
+		return ""
+	}
+	f, err := os.CreateTemp("", "gotestpretty-input-*.json")
+	if err != nil {
+		log.Println("saving the recent input failed:", err)
+		return ""
+	}
+	defer f.Close()
+	lines := l.tail()
+	for _, line := range lines {
+		fmt.Fprintln(f, line)
+	}
+	log.Printf("%s, the last %d lines of input were saved to %s", reason, len(lines), f.Name())
+	return f.Name()
+}
+
+// malformed dumps the log the first time a line which looks like an event can't
+// be decoded.
+func (l *inputLog) malformed(line string, err error) {
+	if l == nil || !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return
+	}
+	l.mu.Lock()
+	dumped := l.dumped
+	l.dumped = true
+	l.mu.Unlock()
+	if !dumped {
+		l.dump(fmt.Sprintf("malformed event: %v", err))
+	}
+}
+
+// dumpOnPanic dumps the log if the caller panics, and re-panics.  Use with defer.
+func (l *inputLog) dumpOnPanic() {
+	if l == nil {
+		return
+	}
+	if r := recover(); r != nil {
+		l.dump(fmt.Sprintf("panic: %v", r))
+		panic(r)
+	}
+}
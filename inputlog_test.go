@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputLog(t *testing.T) {
+	l := newInputLog(3)
+	assert.Empty(t, l.tail())
+	l.add("a")
+	l.add("b")
+	assert.Equal(t, []string{"a", "b"}, l.tail())
+	for i := range 5 {
+		l.add(fmt.Sprint(i))
+	}
+	assert.Equal(t, []string{"2", "3", "4"}, l.tail())
+
+	path := l.dump("test")
+	require.NotEmpty(t, path)
+	defer os.Remove(path)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "2\n3\n4\n", string(b))
+
+	var nilLog *inputLog
+	nilLog.add("ignored")
+	assert.Empty(t, nilLog.dump("test"))
+}
+
+func TestInputLogDumpOnPanic(t *testing.T) {
+	recentInput = newInputLog(10)
+	defer func() { recentInput = nil }()
+	recentInput.add(`{"Action":"run","Package":"a","Test":"TestA"}`)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer recentInput.dumpOnPanic()
+		panic("boom")
+	})
+}
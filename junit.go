@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+// junitTestSuites is the root of a JUnit XML report, written by -junit, in the
+// format CI systems like Jenkins and GitLab read.  Each package is a suite.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	// Error is set for tests which were still running when the run was aborted
+	Error   *junitMessage `xml:"error,omitempty"`
+	Skipped *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (m *model) junit() junitTestSuites {
+	s := m.summary()
+	j := junitTestSuites{Time: s.Elapsed}
+	for _, pkg := range s.Packages {
+		suite := junitTestSuite{
+			Name:      pkg.Name,
+			Time:      pkg.Elapsed,
+			Timestamp: s.Start.UTC().Format("2006-01-02T15:04:05"),
+		}
+		for _, t := range pkg.Tests {
+			tc := junitTestCase{ClassName: pkg.Name, Name: t.Name, Time: t.Elapsed}
+			switch t.Status {
+			case "fail":
+				tc.Failure = &junitMessage{Message: "Failed", Text: consoleText(t.Output)}
+				suite.Failures++
+			case "aborted":
+				tc.Error = &junitMessage{Message: "Aborted"}
+				suite.Errors++
+			case "skip":
+				tc.Skipped = &junitMessage{Message: "Skipped"}
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+		// a package which failed without a failed test, e.g. a build failure or a
+		// panic in TestMain, gets a test case of its own, so it isn't reported as passing
+		if pkg.Status == "fail" && suite.Failures == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				ClassName: pkg.Name,
+				Name:      "package " + pkg.Name[strings.LastIndex(pkg.Name, "/")+1:],
+				Time:      pkg.Elapsed,
+				Failure:   &junitMessage{Message: "Failed"},
+			})
+			suite.Tests++
+			suite.Failures++
+		}
+		j.Tests += suite.Tests
+		j.Failures += suite.Failures
+		j.Errors += suite.Errors
+		j.Skipped += suite.Skipped
+		j.Suites = append(j.Suites, suite)
+	}
+	return j
+}
+
+// writeJUnit writes a JUnit XML report of the run to path.
+func writeJUnit(m *model, path string) error {
+	b, err := xml.MarshalIndent(m.junit(), "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append([]byte(xml.Header), b...)
+	b = append(b, '\n')
+	return os.WriteFile(path, b, 0o644)
+}
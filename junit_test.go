@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "junit.xml")
+	keepFlags(t)
+	flags.junit = path
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 1},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestB", Elapsed: 2},
+		{Action: "run", Package: "a", Test: "TestC"},
+		{Action: "skip", Package: "a", Test: "TestC"},
+		{Action: "fail", Package: "a", Elapsed: 3},
+		{Action: "start", Package: "b"},
+		{Action: "run", Package: "b", Test: "TestD"},
+	}...)
+	m.Update(Abort{Signal: syscall.SIGTERM})
+	m.root.processChildren(true, true)
+
+	require.NoError(t, writeJUnit(m, flags.junit))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var j junitTestSuites
+	require.NoError(t, xml.Unmarshal(b, &j))
+
+	assert.Equal(t, 4, j.Tests)
+	assert.Equal(t, 1, j.Failures)
+	assert.Equal(t, 1, j.Errors)
+	assert.Equal(t, 1, j.Skipped)
+	require.Len(t, j.Suites, 2)
+
+	a := j.Suites[0]
+	assert.Equal(t, "a", a.Name)
+	require.Len(t, a.Cases, 3)
+	assert.Equal(t, junitTestCase{ClassName: "a", Name: "TestA", Time: 1}, a.Cases[0])
+	if assert.NotNil(t, a.Cases[1].Failure) {
+		assert.Contains(t, a.Cases[1].Failure.Text, "boom")
+	}
+	assert.NotNil(t, a.Cases[2].Skipped)
+
+	// the test still running when the run was aborted
+	bs := j.Suites[1]
+	require.Len(t, bs.Cases, 1)
+	assert.Equal(t, "TestD", bs.Cases[0].Name)
+	assert.NotNil(t, bs.Cases[0].Error)
+}
+
+func TestJUnitPackageFailure(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "example.com/a"},
+		{Action: "output", Package: "example.com/a", Output: "panic: boom\n"},
+		{Action: "fail", Package: "example.com/a", Elapsed: 1},
+	}...)
+
+	j := m.junit()
+	assert.Equal(t, 1, j.Failures)
+	require.Len(t, j.Suites, 1)
+	require.Len(t, j.Suites[0].Cases, 1)
+	assert.Equal(t, "package a", j.Suites[0].Cases[0].Name)
+}
@@ -5,41 +5,222 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"regexp"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 )
 
 var flags struct {
-	replay         bool
-	rate           float64
-	infile         string
-	includePassed  bool
-	includeSkipped bool
-	includeSlow    bool
-	slowThreshold  time.Duration
-	noTTY          bool
-	debug          bool
+	replay            bool
+	rate              float64
+	infile            string
+	infiles           []string
+	tee               string
+	includePassed     bool
+	lowMemory         bool
+	notify            bool
+	maxOutputLines    int
+	maxOutputBytes    int
+	spillOutput       bool
+	maxOutputRate     outputRate
+	topSlow           int
+	showOutputFor     string
+	includeSkipped    bool
+	includeSlow       bool
+	slowThreshold     time.Duration
+	stuckThreshold    time.Duration
+	noTTY             bool
+	debug             bool
+	debugInputLines   int
+	theme             string
+	snapshot          string
+	summaryJSON       string
+	junit             string
+	copySummary       bool
+	github            bool
+	history           bool
+	timings           bool
+	regressionPct     int
+	pin               []*regexp.Regexp
+	onlyPkg           []*regexp.Regexp
+	excludePkg        []*regexp.Regexp
+	countFiltered     bool
+	plain             bool
+	failuresToStderr  bool
+	maxDepth          int
+	bellOnFail        bool
+	flashOnFail       bool
+	sounds            map[string]string
+	exportCast        string
+	castSize          string
+	onFail            string
+	onFinish          string
+	reportVerbosity   string
+	sections          []summarySection
+	html              string
+	markdown          string
+	prComment         bool
+	openReport        bool
+	statusFile        string
+	web               string
+	streamFailures    bool
+	splitSubtests     bool
+	tuiDelay          time.Duration
+	keepLastFrame     bool
+	pager             bool
+	timeFormat        string
+	diffColor         bool
+	inline            int
+	minCoverage       []coverageThreshold
+	coverageThreshold float64
+	coverProfile      string
+	impactProfile     string
+	changedFiles      string
+	newTests          bool
+	skipCause         *regexp.Regexp
+	filter            *regexp.Regexp
+	expectFail        []*regexp.Regexp
+	absolutePaths     bool
+	fieldMap          map[string]string
+	extraFields       bool
+	timeBudget        time.Duration
+	stopOverBudget    bool
+	durationColors    []time.Duration
+	ascii             bool
+	packages          string
+	rerunFails        int
+	format            string
+	ciGroups          string
+	summaryStyle      string
+	exitCodes         string
+	finalView         string
 }
 
-func parseFlags() {
-	flag.BoolVar(&flags.replay, "replay", false, "Use with -f, replay events with pauses to simulate original test run")
-	flag.Float64Var(&flags.rate, "rate", 1, "Use with -replay, set rate to replay\nDefaults to 1 (original speed), 0.5 = double speed, 0 = no pauses")
-	flag.StringVar(&flags.infile, "f", "", "Read from <filename> instead of stdin")
-	flag.BoolVar(&flags.includePassed, "include-passed", false, "Include passed tests in summary")
-	flag.BoolVar(&flags.includeSlow, "include-slow", false, "Include slow tests tests in summary")
-	flag.BoolVar(&flags.includeSkipped, "include-skipped", true, "Include skipped tests in summary")
-	flag.DurationVar(&flags.slowThreshold, "slow-threshold", time.Second, "Set slow test threshold")
-	flag.BoolVar(&flags.noTTY, "notty", false, "Don't open a tty (not typically needed)")
-	flag.BoolVar(&flags.debug, "debug", false, "Enable debugging, logs are saved to debug.log")
+// regexpsFlag returns a flag.Func which compiles each value of a repeatable flag
+// and appends it to dest.
+func regexpsFlag(dest *[]*regexp.Regexp) func(string) error {
+	return func(s string) error {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return err
+		}
+		*dest = append(*dest, re)
+		return nil
+	}
+}
+
+// defineFlags defines the flags on fs, which are stored in flags.  Returns -sections,
+// which is parsed once the flags are.
+func defineFlags(fs *flag.FlagSet) *string {
+	flags.skipCause = regexp.MustCompile(defaultSkipCause)
+	flags.durationColors = []time.Duration{100 * time.Millisecond, time.Second, 10 * time.Second}
+
+	fs.BoolVar(&flags.replay, "replay", false, "Use with -f, replay events with pauses to simulate original test run")
+	fs.Float64Var(&flags.rate, "rate", 1, "Use with -replay, set rate to replay\nDefaults to 1 (original speed), 0.5 = double speed, 0 = no pauses")
+	fs.Func("f", "Read from <filename> instead of stdin\nRepeat to merge several streams into one run, e.g. -f shard1.json -f shard2.json", inputFlag)
+	fs.StringVar(&flags.tee, "tee", "", "Write the unmodified input, the go test -json events and any other lines, to `file`, e.g. to -replay it later")
+	fs.BoolVar(&flags.includePassed, "include-passed", false, "Include passed tests in summary")
+	fs.BoolVar(&flags.lowMemory, "low-memory", false, "Forget passed and skipped tests as soon as they finish, instead of when their package finishes\nOnly the totals are kept, so it can't be used with reports.  For runs with huge numbers of tests")
+	fs.IntVar(&flags.maxOutputLines, "max-output-lines", 0, "Buffer at most `n` lines of each test's output, keeping the first and last lines, and omitting the lines in between\n0 buffers all of it")
+	fs.IntVar(&flags.maxOutputBytes, "max-output-bytes", 0, "Buffer at most `n` bytes of each test's output, like -max-output-lines")
+	fs.BoolVar(&flags.spillOutput, "spill-output", false, "Use with -max-output-lines or -max-output-bytes, write the omitted output to a temp file instead of dropping it")
+	fs.Func("max-output-rate", "Drop the output of tests which write more than this `rate`, in lines per second, or bytes per second like 5MB/s,\nso a runaway test can't lock up the live view", outputRateFlag)
+	fs.IntVar(&flags.topSlow, "top-slow", 0, "List the `n` slowest tests in the final summary, whether they passed or failed")
+	fs.StringVar(&flags.showOutputFor, "show-output-for", "", "Print the output of tests with this `status`, one of "+strings.Join(showOutputFors, ", ")+"\nBy default, the output of tests shown in the summary is printed")
+	fs.BoolVar(&flags.includeSlow, "include-slow", false, "Include slow tests tests in summary")
+	fs.BoolVar(&flags.includeSkipped, "include-skipped", true, "Include skipped tests in summary")
+	fs.DurationVar(&flags.slowThreshold, "slow-threshold", time.Second, "Set slow test threshold")
+	fs.DurationVar(&flags.stuckThreshold, "stuck-threshold", 0, "Mark tests which have been running longer than this `duration` as possibly hung, and list them in the live view\nWhen gotestpretty runs go test, press d to dump their goroutines")
+	fs.DurationVar(&flags.tuiDelay, "tui-delay", 200*time.Millisecond, "Wait this long before starting the live view.  If the run finishes sooner, only the result is printed")
+	fs.BoolVar(&flags.pager, "pager", false, "When the run finishes with failures, show their output in a pager, instead of quitting right away")
+	fs.StringVar(&flags.timeFormat, "time-format", "", "Show the run's start time in the totals line, and format timestamps in reports and grep output, as one of "+strings.Join(timeFormats, ", ")+"\n24h is the local time of day")
+	fs.BoolVar(&flags.keepLastFrame, "keep-last-frame", false, "Leave the last frame of the live view in the scrollback when the run finishes, instead of clearing it")
+	fs.IntVar(&flags.inline, "inline", 0, "Limit the live view to the bottom `lines` of the terminal, so the output above it stays on screen\n0 uses the whole window")
+	fs.BoolVar(&flags.diffColor, "diff-color", true, "Color the expected and actual values of failed assertions red and green, as well as the lines of\n-/+ diffs, like testify's and go-cmp's")
+	fs.BoolVar(&flags.absolutePaths, "absolute-paths", false, "Don't rewrite absolute file paths in test output to module-relative paths")
+	fs.Func("duration-colors", "Color durations green, yellow, orange, or red, split by these comma separated `thresholds` (default \"100ms,1s,10s\")", durationColorsFlag)
+	fs.BoolVar(&flags.ascii, "ascii", false, "Only write ASCII in the final summary, test output, and reports, with icons like [PASS] and [FAIL]\nfor consoles which mangle unicode")
+	fs.StringVar(&flags.packages, "packages", "", "The space separated package `patterns` passed to go test, e.g. \"./...\", or @file to read the package list from a file,\nor just the number of packages.  Packages which haven't started yet are shown as queued, and a progress bar shows\nthe finished packages, with an ETA")
+	fs.IntVar(&flags.rerunFails, "rerun-fails", 0, "Re-run failed tests up to `n` times, in wrapper mode or with -packages\nPress r during the run to re-run them once")
+	fs.StringVar(&flags.ciGroups, "ci-groups", "auto", "Wrap the output of each failed top level test in a collapsible group in the CI log, one of "+strings.Join(ciGroupStyles, ", ")+"\nauto picks github in GitHub Actions, and gitlab in GitLab CI")
+	fs.StringVar(&flags.summaryStyle, "summary-style", "line", "How to show the totals at the end of the run, one of "+strings.Join(summaryStyles, ", ")+"\nline is a single line, table is an aligned table with a row per count")
+	fs.StringVar(&flags.exitCodes, "exit-codes", "simple", "How the exit code reports a failed run, one of "+strings.Join(exitCodeStyles, ", ")+"\nsimple exits with 1, extended exits with 1 for test failures, 2 for build failures, 3 for errors running gotestpretty or go test, and 4 if the run timed out or was aborted")
+	fs.StringVar(&flags.format, "format", "auto", "The output `format`, one of "+strings.Join(formats, ", ")+"\ntui shows the live view, ci prints a line as each test finishes, auto picks ci when stdout isn't a terminal")
+	fs.StringVar(&flags.finalView, "view", "tree", "The `format` of the final summary, one of "+strings.Join(finalViews, ", ")+"\nstarts lists every test in the order it started, with its start time relative to the start of the run")
+	fs.BoolVar(&flags.noTTY, "notty", false, "Don't open a tty (not typically needed)")
+	fs.BoolVar(&flags.debug, "debug", false, "Enable debugging, logs are saved to debug.log")
+	fs.IntVar(&flags.debugInputLines, "debug-input-lines", 0, "Use with -debug, keep the last `n` lines of input, and save them to a temp file if they can't be processed,\ne.g. a malformed event or a panic.  The file is logged to debug.log, and can be read back with -f")
+	fs.StringVar(&flags.snapshot, "snapshot", "", "Save the final test tree to <filename>, view it later with 'view <filename>'")
+	fs.StringVar(&flags.html, "html", "", "Write an HTML report of the run to <filename>")
+	fs.StringVar(&flags.markdown, "markdown", "", "Write a Markdown report of the run to `file`, or - for stdout after the final summary,\nwith a summary table, the output of failures, and the slowest tests, e.g. for a PR comment")
+	fs.BoolVar(&flags.prComment, "github-pr-comment", false, "Post the Markdown report as a comment on the pull request, updating the comment from earlier runs\nUses $GITHUB_TOKEN, $GITHUB_REPOSITORY, and the pull request GitHub Actions is running on")
+	fs.StringVar(&flags.web, "web", "", "Serve a page mirroring the test tree and summary at `addr`, e.g. localhost:8080, to watch the run in a browser")
+	fs.BoolVar(&flags.openReport, "open-report", false, "Use with -html, open the report in the default browser after the run\nIgnored when $CI is set")
+	fs.BoolVar(&flags.copySummary, "copy-summary", false, "Copy the totals and the failed tests to the clipboard when the run finishes")
+	fs.StringVar(&flags.summaryJSON, "summary-json", "", "Write a JSON summary of the results to `file`, or - for stdout after the final summary,\nwith each test's status and elapsed time, the slowest tests, and the output of failures")
+	fs.StringVar(&flags.junit, "junit", "", "Write a JUnit XML report of the results to `file`, for CI systems which display test results from it\nTests still running when the run is aborted are reported as errors")
+	fs.BoolVar(&flags.github, "github", false, "Write GitHub Actions annotations for failed tests, and a table of results to the step summary\n(default true when $GITHUB_ACTIONS is true)")
+	fs.StringVar(&flags.reportVerbosity, "report-verbosity", "normal", "Which tests' output to include in reports like -snapshot, regardless of what the console shows\nfailed: only failed tests, normal: the same tests as the console, all: all tests, including passed")
+	fs.BoolVar(&flags.plain, "plain", false, "Parse plain 'go test' or 'go test -v' output, for runs which didn't use -json")
+	fs.BoolVar(&flags.failuresToStderr, "failures-to-stderr", false, "Write the output of failed packages, and the final summary if the run failed, to stderr")
+	fs.BoolVar(&flags.streamFailures, "stream-failures", false, "Print the output of each failed test as soon as it fails, instead of when its package finishes")
+	fs.BoolVar(&flags.splitSubtests, "split-subtests", false, "Count top level tests separately from subtests in the summary, e.g. \"42 tests (1204 subtests)\"")
+	fs.IntVar(&flags.maxDepth, "max-depth", 0, "Only show subtests nested up to this depth, deeper subtests are counted on their ancestor\n0 means no limit, 1 shows only top level tests")
+	fs.BoolVar(&flags.bellOnFail, "bell-on-fail", false, "Ring the terminal bell when a test fails")
+	fs.BoolVar(&flags.flashOnFail, "flash-on-fail", false, "Flash the screen when a test fails")
+	fs.BoolVar(&flags.notify, "notify", false, "Show a desktop notification with the totals when the run finishes, with osascript on macOS or notify-send on Linux")
+	fs.Func("sound", "Play a sound on an `event=sound`, repeatable.  Events are fail, when a test fails, and done-pass\nand done-fail, when the run finishes.  Sounds are bell, bell*<n> to ring it n times, or a sound file", soundFlag)
+	fs.StringVar(&flags.exportCast, "export-cast", "", "Render the live view of a recorded run to an asciinema cast file at <path>, instead of displaying it\nUse with -f, or pipe the recording to stdin.  Honors -rate")
+	fs.StringVar(&flags.castSize, "cast-size", "120x30", "Use with -export-cast, the terminal size of the cast, as <width>x<height>")
+	fs.StringVar(&flags.onFail, "on-fail", "", "Run shell `command` each time a test or package fails\nThe failure details are passed in GOTESTPRETTY_* env vars, and the output on stdin")
+	fs.StringVar(&flags.onFinish, "on-finish", "", "Run shell `command` when the run finishes\nThe results are passed in GOTESTPRETTY_* env vars, and the summary on stdin")
+	fs.StringVar(&flags.statusFile, "status-file", "", "Keep a status summary in <filename> while the tests run, in xbar/SwiftBar/Argos plugin format\nfor showing the run's status in the menu bar or system tray")
+	fs.DurationVar(&flags.timeBudget, "time-budget", 0, "Warn when the run is projected to take longer than this, based on the package durations in the history database")
+	fs.BoolVar(&flags.stopOverBudget, "stop-over-budget", false, "Use with -time-budget when gotestpretty runs go test itself: stop starting packages once the run is projected\nto exceed the budget, and list the packages which weren't run.  Each package is run with its own go test")
+	fs.BoolVar(&flags.timings, "timings", false, "Compare test durations to their recent runs, and highlight tests which got slower\nThe durations of passed tests are recorded in gotestpretty's cache dir, or $GOTESTPRETTY_TIMINGS")
+	fs.IntVar(&flags.regressionPct, "regression-threshold", 50, "Use with -timings, highlight tests which took this `percent` longer than usual")
+	fs.BoolVar(&flags.history, "history", false, "Record test results in the history database, see 'history -h'")
+	fs.Func("skip-cause", "Skipped tests whose output matches `regex` are linked to the failure which caused them\nThe first capture group, if any, names the failed test, otherwise it's the package's most recent failure\nAn empty regex disables linking (default \""+defaultSkipCause+"\")", func(s string) (err error) {
+		flags.skipCause = nil
+		if s != "" {
+			flags.skipCause, err = regexp.Compile(s)
+		}
+		return err
+	})
+	fs.Func("filter", "Only show the packages and tests matching `regex` in the live view, matched against \"<package> <test>\"\nThe totals still count every test.  Press f during the run to also filter by status", func(s string) (err error) {
+		flags.filter, err = regexp.Compile(s)
+		return err
+	})
+	fs.Func("expect-fail", "Expect the tests matching `regex` to fail, matched against \"<package> <test>\", may be repeated\nTheir failures don't fail the run, and they're flagged XPASS if they pass", regexpsFlag(&flags.expectFail))
+	fs.Func("expect-fail-file", "Read -expect-fail patterns from a `file`, one per line", expectFailFileFlag)
+	fs.Func("field-map", "Read `field=EventField` from events, for wrappers which rename go test's JSON fields, may be repeated\ne.g. -field-map ts=Time -field-map pkg=Package", fieldMapFlag)
+	fs.BoolVar(&flags.extraFields, "extra-fields", false, "Accept events with fields which aren't in go test's JSON, and keep them as metadata on the test")
+	fs.Func("min-coverage", "Fail packages matching `regex=percent` with less coverage than percent, may be repeated\nThe first matching pattern applies, e.g. -min-coverage internal/=80 -min-coverage .=60", coverageThresholdFlag)
+	fs.Float64Var(&flags.coverageThreshold, "coverage-threshold", 0, "Fail the run if its total coverage is below `percent`")
+	fs.StringVar(&flags.impactProfile, "impact-profile", "", "Compare the changed lines to the cover profile `file` from an earlier run, and flag failures which don't run them as possibly pre-existing")
+	fs.BoolVar(&flags.newTests, "new-tests", false, "Tag the tests added since HEAD in git as new, and list their results in the summary, including the new tests which didn't run")
+	fs.StringVar(&flags.changedFiles, "changed-files", "", "Use with -impact-profile, a comma separated list of the changed `files`, relative to the module root\nDefaults to the lines changed since HEAD, from git diff")
+	fs.StringVar(&flags.coverProfile, "coverprofile", "", "The cover profile `file` written by go test -coverprofile, to weight the total coverage by statements\nOtherwise it's the average of the packages' coverage")
+	fs.Func("pin", "Pin packages matching `regex` to the top of the view, may be repeated", regexpsFlag(&flags.pin))
+	fs.Func("only-pkg", "Only show packages matching `regex`, may be repeated", regexpsFlag(&flags.onlyPkg))
+	fs.Func("exclude-pkg", "Don't show packages matching `regex`, may be repeated", regexpsFlag(&flags.excludePkg))
+	fs.BoolVar(&flags.countFiltered, "count-filtered", false, "Include the results of packages hidden by -only-pkg and -exclude-pkg in the totals and exit code")
+	fs.StringVar(&flags.theme, "theme", "auto", "Color theme: auto, dark, or light\nauto detects the terminal's background color")
+	return fs.String("sections", defaultSections, "Comma separated list of sections to include in the final summary, in order\nAvailable: "+sectionNames())
+}
+
+func parseFlags(args []string) {
+	sections := defineFlags(flag.CommandLine)
 
 	flag.Usage = func() {
 		var sb strings.Builder
@@ -47,6 +228,13 @@ func parseFlags() {
 		fmt.Fprintf(&sb, "\tgo test -json ./... | %s [flags]\n", os.Args[0])
 		fmt.Fprintf(&sb, "\tgo test -json ./... 2>&1 | %s [flags]\n", os.Args[0])
 		fmt.Fprintf(&sb, "\t%s -f <path> [flags]\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s [flags] [packages] [-- go test flags]\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s view [flags] <snapshot>\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s stress [flags] [packages] [go test flags]\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s history sparkline [flags] <test name>\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s grep [-f <path>] [flags] <regex>\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s doctor\n", os.Args[0])
+		fmt.Fprintf(&sb, "\t%s version [-check-update]\n", os.Args[0])
 		fmt.Fprintf(&sb, `
 %[1]s formats and summarizes the output of 'go test -json'.  Test output can be piped
 to stdin for real-time progress.
@@ -58,77 +246,440 @@ the test output, and pass the rest of the output through.`, os.Args[0])
 		flag.PrintDefaults()
 	}
 
-	flag.Parse()
+	// the config file and environment set the defaults, which the command line overrides
+	if err := loadConfig(flag.CommandLine); err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid config: %v\n", err)
+		os.Exit(2)
+	}
+	_ = flag.CommandLine.Parse(args)
+
+	var err error
+	if flags.sections, err = parseSections(*sections); err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -sections: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(formats, flags.format) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -format %q, must be one of %v\n", flags.format, formats)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(ciGroupStyles, flags.ciGroups) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -ci-groups %q, must be one of %v\n", flags.ciGroups, ciGroupStyles)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flags.lowMemory && reporting() {
+		fmt.Fprintln(flag.CommandLine.Output(), "-low-memory can't be used with reports, which list every test")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flags.tee != "" && len(flags.infiles) > 1 {
+		fmt.Fprintln(flag.CommandLine.Output(), "-tee can't be used with more than one -f, the files are the input already")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(exitCodeStyles, flags.exitCodes) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -exit-codes %q, must be one of %v\n", flags.exitCodes, exitCodeStyles)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(summaryStyles, flags.summaryStyle) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -summary-style %q, must be one of %v\n", flags.summaryStyle, summaryStyles)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flags.showOutputFor != "" && !slices.Contains(showOutputFors, flags.showOutputFor) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -show-output-for %q, must be one of %v\n", flags.showOutputFor, showOutputFors)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(finalViews, flags.finalView) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -view %q, must be one of %v\n", flags.finalView, finalViews)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flags.timeFormat != "" && !slices.Contains(timeFormats, flags.timeFormat) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -time-format %q, must be one of %v\n", flags.timeFormat, timeFormats)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(reportVerbosities, flags.reportVerbosity) {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -report-verbosity %q, must be one of %v\n", flags.reportVerbosity, reportVerbosities)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// subcommands maps the first argument to an alternate entrypoint.  Each
+// returns the exit code.
+var subcommands = map[string]func(args []string) int{
+	"stress":  stressMain,
+	"view":    viewMain,
+	"history": historyMain,
+	"grep":    grepMain,
+	"doctor":  doctorMain,
+	"version": versionMain,
 }
 
 func main() {
-	parseFlags()
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
+	parseFlags(os.Args[1:])
 	if flags.debug {
 		f, err := tea.LogToFile("debug.log", "debug")
 		if err != nil {
 			fmt.Println("fatal:", err)
-			os.Exit(1)
+			exitToolFailure()
 		}
 		defer f.Close()
+		if flags.debugInputLines > 0 {
+			recentInput = newInputLog(flags.debugInputLines)
+		}
 	} else {
 		log.Default().SetOutput(io.Discard)
 	}
 
+	if err := setupTheme(flags.theme); err != nil {
+		fmt.Println("fatal:", err)
+		exitToolFailure()
+	}
+
+	if flags.exportCast != "" {
+		if err := exportCastFile(flags.exportCast); err != nil {
+			fmt.Println("fatal:", err)
+			exitToolFailure()
+		}
+		return
+	}
+
+	// in wrapper mode, gotestpretty runs go test itself
+	if flag.NArg() > 0 && flags.infile == "" {
+		pkgs, testFlags := splitWrapperArgs(flag.Args())
+		if flags.packages == "" {
+			flags.packages = strings.Join(pkgs, " ")
+		}
+		var err error
+		if flags.stopOverBudget && flags.timeBudget > 0 {
+			// the packages are started one at a time, so they're listed up front
+			var listed []string
+			if listed, err = listPackages(pkgs); err != nil {
+				fmt.Println("fatal:", err)
+				exitToolFailure()
+			}
+			goTest = startGoTestPackages(listed, testFlags)
+		} else if goTest, err = startGoTest(pkgs, testFlags); err != nil {
+			fmt.Println("fatal: running go test:", err)
+			exitToolFailure()
+		}
+	}
+
 	m := newModel()
-	var p *tea.Program
-	if flags.noTTY {
-		p = tea.NewProgram(m, tea.WithInput(nil))
+	if !flags.absolutePaths {
+		m.paths = newPathNormalizer()
+	}
+	if flags.packages != "" {
+		if pkgs, count, err := resolvePackages(flags.packages); err == nil {
+			m.packages, m.packageCount = pkgs, count
+		} else {
+			fmt.Println("error listing packages:", err)
+		}
+	}
+	if flags.timeBudget > 0 || len(m.packages) > 0 {
+		// the expected durations of the packages are used for the ETA too
+		if runs, err := readHistory(); err == nil {
+			m.expected = expectedDurations(runs)
+		} else {
+			log.Println("error reading history:", err)
+		}
+	}
+	if flags.web != "" {
+		w, url, err := startWeb(flags.web)
+		if err != nil {
+			fmt.Println("fatal: serving -web:", err)
+			exitToolFailure()
+		}
+		fmt.Println("Serving the results at", url)
+		m.web = w
+	}
+	if flags.timings {
+		if t, err := loadTimings(); err == nil {
+			m.timings = t
+		} else {
+			log.Println("error loading timings:", err)
+		}
+	}
+	if flags.newTests {
+		if err := m.loadNewTests(); err != nil {
+			log.Println("error finding new tests:", err)
+		}
+	}
+	if a, err := loadAnnotations(); err == nil {
+		m.annotations = a
 	} else {
-		p = tea.NewProgram(m)
+		log.Println("error loading annotations:", err)
+	}
+
+	// signals are handled here rather than by the program, so the run can be
+	// finalized and reports written before exiting
+	opts := []tea.ProgramOption{tea.WithoutSignalHandler()}
+	if flags.noTTY {
+		opts = append(opts, tea.WithInput(nil))
 	}
+	if ciFormat() {
+		ci := newCIOutput()
+		m.input = ci
+		go notifyAbort(ci)
+		go process(ci)
+		ci.run(m)
+	} else {
+		input := newStartupBuffer()
+		m.input = input
+		go process(input)
 
-	m.prog = p
+		// short runs skip the live view entirely
+		select {
+		case <-input.done:
+			input.finish(m)
+		case <-time.After(flags.tuiDelay):
+			p := tea.NewProgram(m, opts...)
 
-	go process(p)
+			m.prog = p
+			go notifyAbort(p)
+			go input.forward(p)
 
-	if _, err := p.Run(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+			_, err := p.Run()
+			m.flushStderr()
+			if err != nil {
+				fmt.Println(err)
+				exitToolFailure()
+			}
+		}
+	}
+
+	if err := closeTee(); err != nil {
+		fmt.Println("error writing -tee file:", err)
+	}
+
+	m.checkTotalCoverage()
+	if flags.impactProfile != "" {
+		if err := m.analyzeImpact(); err != nil {
+			fmt.Println("error analyzing change impact:", err)
+		}
 	}
 
 	// print final summary
 	m.root.processChildren(true, true)
-	fmt.Println(m.String())
+	if flags.failuresToStderr && m.overallFail {
+		fmt.Fprintln(os.Stderr, consoleText(m.String()))
+	} else {
+		fmt.Println(consoleText(m.String()))
+	}
+
+	if flags.snapshot != "" {
+		if err := writeSnapshot(m, flags.snapshot); err != nil {
+			fmt.Println("error writing snapshot:", err)
+		}
+	}
+
+	if githubActions() {
+		writeGitHubAnnotations(m, os.Stdout)
+		if err := writeStepSummary(m); err != nil {
+			fmt.Println("error writing step summary:", err)
+		}
+	}
+
+	if flags.summaryJSON != "" {
+		if err := writeSummary(m, flags.summaryJSON); err != nil {
+			fmt.Println("error writing summary:", err)
+		}
+	}
+
+	if flags.junit != "" {
+		if err := writeJUnit(m, flags.junit); err != nil {
+			fmt.Println("error writing JUnit report:", err)
+		}
+	}
+
+	if flags.copySummary {
+		// the escape sequence fallback would end up in the output if it's redirected
+		w := io.Discard
+		if isTerminal(os.Stdout) {
+			w = os.Stdout
+		}
+		if err := writeClipboard(clipboardSummary(m.summary()), w); err != nil {
+			fmt.Println("error copying summary:", err)
+		}
+	}
+
+	if flags.markdown != "" {
+		if err := writeMarkdown(m, flags.markdown); err != nil {
+			fmt.Println("error writing markdown report:", err)
+		}
+	}
+
+	if flags.prComment {
+		if err := postPRComment(m); err != nil {
+			fmt.Println("error posting pull request comment:", err)
+		}
+	}
+
+	m.updateStatusFile(true)
+	m.updateWeb(true)
+
+	if flags.html != "" {
+		if err := writeHTMLReport(m, flags.html); err != nil {
+			fmt.Println("error writing html report:", err)
+		} else if flags.openReport && os.Getenv("CI") == "" {
+			if err := openBrowser(flags.html); err != nil {
+				fmt.Println("error opening html report:", err)
+			}
+		}
+	}
+
+	if flags.onFinish != "" {
+		if err := runFinishHook(m); err != nil {
+			fmt.Println("error running -on-finish:", err)
+		}
+	}
+
+	if m.timings != nil {
+		if err := m.timings.save(); err != nil {
+			fmt.Println("error recording timings:", err)
+		}
+	}
+
+	if flags.history {
+		if err := appendHistory(m.historyRun()); err != nil {
+			fmt.Println("error recording history:", err)
+		}
+	}
 
 	if m.overallFail {
-		os.Exit(1)
+		playSound("done-fail")
+	} else {
+		playSound("done-pass")
+	}
+
+	if flags.notify {
+		if err := notifyFinished(m); err != nil {
+			fmt.Println("error sending notification:", err)
+		}
+	}
+
+	if code := m.exitCode(); code != 0 {
+		if goTest != nil {
+			// it was killed if the run was aborted, wait for it to exit
+			_, _ = goTest.result()
+		}
+		os.Exit(code)
+	}
+
+	if goTest != nil {
+		// e.g. go test failed before running any tests, because of bad flags
+		code, err := goTest.result()
+		if err != nil {
+			fmt.Println("error running go test:", err)
+			exitToolFailure()
+		}
+		if code == 1 && m.xfails > 0 {
+			// go test failed because of the expected failures
+			code = 0
+		}
+		os.Exit(goTestExitCode(code))
+	}
+}
+
+// notifyAbort sends Abort to s on SIGTERM or an interrupt.
+func notifyAbort(s sender) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, os.Interrupt)
+	s.Send(Abort{Signal: <-sigs})
+}
+
+// goTest is the go test command, in wrapper mode
+var goTest *goTestRun
+
+// openInput opens the file named by -f, or stdin.
+func openInput() (io.Reader, error) {
+	if goTest != nil {
+		return bufio.NewReader(goTest), nil
+	}
+	if flags.infile == "" {
+		return bufio.NewReader(os.Stdin), nil
 	}
+	f, err := os.Open(flags.infile)
+	if err != nil {
+		return nil, err
+	}
+	return bufio.NewReader(f), nil
+}
+
+// sender is where the input is sent, e.g. the tea.Program, or the startupBuffer
+// which holds it until the program starts.
+type sender interface {
+	Send(msg tea.Msg)
 }
 
 // process reads the input until EOF.
 // Lines which appear to be gotest output are sent to the event loop for
 // further processing and rendering.  Other lines are just dumped to
 // the terminal output.
-func process(p *tea.Program) {
-	var r io.Reader
-	if flags.infile != "" {
-		f, err := os.Open(flags.infile)
-		if err != nil {
-			p.Send(err)
-			return
-		}
-		r = f
-	} else {
-		r = os.Stdin
+func process(p sender) {
+	if goTest == nil && len(flags.infiles) > 1 {
+		processStreams(p, flags.infiles)
+		return
 	}
-	r = bufio.NewReader(r)
+	r, err := openInput()
+	if err == nil {
+		r, err = teeInput(r)
+	}
+	if err != nil {
+		p.Send(err)
+		return
+	}
+	readEvents(r, p, "")
+	p.Send(Done{})
+}
 
+// readEvents reads the input until EOF, and sends the events, labeled with the
+// stream they came from when there's more than one.
+func readEvents(r io.Reader, p sender, stream string) {
 	var lastTs time.Time
+	var plain plainParser
 
-	s := bufio.NewScanner(r)
+	s := newLineScanner(r)
 	for s.Scan() {
-		var e TestEvent
-		decoder := json.NewDecoder(bytes.NewReader(s.Bytes()))
-		decoder.DisallowUnknownFields()
-		err := decoder.Decode(&e)
+		recentInput.add(s.Text())
+		e, err := decodeEvent(s.Bytes())
 		if err != nil {
+			line := validUTF8(s.Text())
+			if flags.plain {
+				if events, ok := plain.parse(line); ok {
+					for _, e := range events {
+						e.Stream = stream
+						p.Send(e)
+					}
+					continue
+				}
+			}
 			// this line wasn't valid json, so just print it
-			p.Println(s.Text())
+			recentInput.malformed(s.Text(), err)
+			p.Send(Unattributed(line))
 			continue
 		}
 
@@ -143,7 +694,18 @@ func process(p *tea.Program) {
 			lastTs = e.Time
 		}
 
+		e.Stream = stream
 		p.Send(e)
 	}
-	p.Send(Done{})
+	if err := s.Err(); err != nil {
+		log.Println("error reading input:", err)
+		recentInput.dump("reading the input failed")
+	}
+	if len(plain.pending) > 0 {
+		// the output ended before the package result line
+		for _, e := range plain.flush("unknown") {
+			e.Stream = stream
+			p.Send(e)
+		}
+	}
 }
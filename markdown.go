@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// markdownReport renders the -markdown report: the same summary table and failures
+// as the GitHub step summary, plus a table of the slowest tests, ready to be posted
+// as a PR comment.
+func markdownReport(s summary) string {
+	var sb strings.Builder
+	sb.WriteString(stepSummary(s))
+	if len(s.Slowest) > 0 {
+		sb.WriteString("#### Slowest tests\n\n")
+		sb.WriteString("| Test | Package | Elapsed |\n")
+		sb.WriteString("|---|---|---:|\n")
+		for _, t := range s.Slowest {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", markdownCode(t.Name), markdownCode(t.Package), round(secondsDuration(t.Elapsed), 1))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// writeMarkdown writes the Markdown report to path, or stdout if path is "-".
+func writeMarkdown(m *model, path string) error {
+	report := markdownReport(m.summary())
+	if path == "-" {
+		_, err := os.Stdout.WriteString(report)
+		return err
+	}
+	return os.WriteFile(path, []byte(report), 0o644)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownReport(t *testing.T) {
+	keepFlags(t)
+	path := filepath.Join(t.TempDir(), "report.md")
+	flags.markdown = path
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 2},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestB", Elapsed: 0.5},
+		{Action: "fail", Package: "a", Elapsed: 2.5},
+	}...)
+	m.root.processChildren(true, true)
+	require.NoError(t, writeMarkdown(m, path))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	md := string(b)
+	assert.Contains(t, md, "| ❌ | `a` | 2 | 1 | 2.5s |", "passed tests are kept for the report")
+	assert.Contains(t, md, "<details><summary><code>a.TestB</code></summary>\n\n```\nboom\n```")
+	assert.Contains(t, md, "#### Slowest tests\n\n| Test | Package | Elapsed |\n|---|---|---:|\n| `TestA` | `a` | 2s |\n| `TestB` | `a` | 500ms |\n")
+}
@@ -1,16 +1,108 @@
 package main
 
 import (
-	"time"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ansel1/gotestpretty/pretty"
 )
 
-type TestEvent struct {
-	Time    time.Time // encodes as an RFC3339-format string
-	Action  string
-	Package string
-	Test    string
-	Elapsed float64 // seconds
-	Output  string
-}
+// TestEvent is an event of `go test -json`.  Its Extra field is only captured with
+// -extra-fields, and Stream is set by processStreams.
+type TestEvent = pretty.Event
 
 type Done struct{}
+
+// Unattributed is a line of input which isn't a test event, like build output or
+// stray prints.  It's passed through to the output.
+type Unattributed string
+
+// Abort is sent when the process receives SIGTERM or SIGINT.  The run is finalized
+// early, so reports can still be written before exiting.
+type Abort struct {
+	Signal os.Signal
+}
+
+// decodeEvent parses a single line of `go test -json` output.  Returns an error
+// if the line isn't a test event.
+func decodeEvent(line []byte) (TestEvent, error) {
+	if len(flags.fieldMap) > 0 || flags.extraFields {
+		return decodeMappedEvent(line)
+	}
+	return pretty.Decode(line)
+}
+
+// eventFields are the fields of TestEvent in go test's output.
+var eventFields = []string{"Time", "Action", "Package", "Test", "Elapsed", "Output", "OutputType", "ImportPath", "FailedBuild"}
+
+// eventField returns the TestEvent field matching name, which like encoding/json,
+// is matched case insensitively.
+func eventField(name string) (string, bool) {
+	for _, f := range eventFields {
+		if strings.EqualFold(f, name) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// fieldMapFlag parses values of -field-map, like "ts=Time".
+func fieldMapFlag(s string) error {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok || from == "" {
+		return fmt.Errorf("must be <field>=<event field>, got %q", s)
+	}
+	field, ok := eventField(to)
+	if !ok {
+		return fmt.Errorf("unknown event field %q, must be one of %v", to, eventFields)
+	}
+	if flags.fieldMap == nil {
+		flags.fieldMap = map[string]string{}
+	}
+	flags.fieldMap[from] = field
+	return nil
+}
+
+// decodeMappedEvent is decodeEvent for events from wrappers which rename fields,
+// per -field-map, or add fields, which are kept in Extra with -extra-fields.  An
+// Action field is still required, so other JSON isn't mistaken for events.
+func decodeMappedEvent(line []byte) (TestEvent, error) {
+	var e TestEvent
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return e, err
+	}
+	known := map[string]json.RawMessage{}
+	for name, v := range fields {
+		if mapped, ok := flags.fieldMap[name]; ok {
+			name = mapped
+		}
+		if f, ok := eventField(name); ok {
+			known[f] = v
+			continue
+		}
+		if !flags.extraFields {
+			return e, fmt.Errorf("unknown field %q", name)
+		}
+		if e.Extra == nil {
+			e.Extra = map[string]string{}
+		}
+		var str string
+		if err := json.Unmarshal(v, &str); err == nil {
+			e.Extra[name] = str
+		} else {
+			e.Extra[name] = string(v)
+		}
+	}
+	if _, ok := known["Action"]; !ok {
+		return e, errors.New("missing Action field")
+	}
+	b, err := json.Marshal(known)
+	if err != nil {
+		return e, err
+	}
+	return e, json.Unmarshal(b, &e)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMappedEvent(t *testing.T) {
+	keepFlags(t)
+	require.NoError(t, fieldMapFlag("pkg=Package"))
+	require.NoError(t, fieldMapFlag("name=test"))
+	assert.Error(t, fieldMapFlag("x=Nope"))
+
+	e, err := decodeEvent([]byte(`{"Action":"run","pkg":"a","name":"TestA"}`))
+	require.NoError(t, err)
+	assert.Equal(t, TestEvent{Action: "run", Package: "a", Test: "TestA"}, e)
+
+	_, err = decodeEvent([]byte(`{"Action":"run","pkg":"a","shard":3}`))
+	assert.Error(t, err, "extra fields should be rejected without -extra-fields")
+
+	flags.extraFields = true
+
+	e, err = decodeEvent([]byte(`{"Action":"run","pkg":"a","shard":3,"host":"ci-1"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"shard": "3", "host": "ci-1"}, e.Extra)
+
+	_, err = decodeEvent([]byte(`{"level":"info","msg":"hi"}`))
+	assert.Error(t, err, "json without an Action isn't an event")
+}
+
+func TestExtraFieldsMetadata(t *testing.T) {
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA", Extra: map[string]string{"shard": "3"}})
+
+	testA := m.root.children[0].children[0]
+	assert.Equal(t, map[string]string{"shard": "3"}, testA.meta)
+}
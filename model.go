@@ -1,37 +1,178 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"container/list"
 	"fmt"
 	"io"
 	"iter"
+	"log"
+	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/v2/spinner"
 	tea "github.com/charmbracelet/bubbletea/v2"
-	"github.com/charmbracelet/lipgloss"
 )
 
+// program is the part of tea.Program the model uses, once the live view is running.
+type program interface {
+	Println(args ...any)
+}
+
 type model struct {
 	root                        node
 	err                         error
 	done                        bool
 	spinner                     spinner.Model
-	prog                        *tea.Program
+	prog                        program
 	passes, fails, skips, total int
-	overallFail                 bool
-	start                       time.Time
-	windowHeight                int
-	maxPrintedLines             int
+	aborts                      int
+	// aborted is set when the run was finished early, by a signal or by quitting
+	// the live view
+	aborted     bool
+	overallFail bool
+	start       time.Time
+	end         time.Time
+	// firstTest and lastEvent are when the first test event, and the last event,
+	// were received, to split the run into build and test phases
+	firstTest time.Time
+	lastEvent time.Time
+	// runStart is when the test run started, used to estimate how long each package took to
+	// compile and set up.  It's the time gotestpretty started when reading from stdin, otherwise the
+	// timestamp of the first event.
+	runStart        time.Time
+	windowHeight    int
+	windowWidth     int
+	maxPrintedLines int
+	// filteredPkgs caches which packages are hidden by -only-pkg and -exclude-pkg
+	filteredPkgs map[string]bool
+	// statusWritten is when the -status-file was last written
+	statusWritten time.Time
+	// web serves the -web page, and webSent is when it was last updated
+	web     *webServer
+	webSent time.Time
+	// coverageViolations are the packages below their -min-coverage, and
+	// belowCoverageThreshold is set if the run is below -coverage-threshold
+	coverageViolations     []*node
+	belowCoverageThreshold bool
+	// errorSignatures are the failed tests with each error signature
+	errorSignatures map[string][]*node
+	// slowest are the longest running tests, longest first, see -top-slow
+	slowest []*node
+	// flaky are the tests which both passed and failed when run more than once
+	flaky []*node
+	// heldStderr is the output for stderr, with -failures-to-stderr, held until the
+	// live view ends
+	heldStderr []string
+	// forgotten are the run counts of the tests dropped by -low-memory, by package
+	// and test name, see forget
+	forgotten map[string]map[string]runCounts
+	// timings are the recent durations of each test, and regressions are the tests
+	// which were slower than usual, see -timings
+	timings     timings
+	regressions []*node
+	// impactAnalyzed is set once the failures are compared to the changed lines, and
+	// relatedFailures counts the failures which ran them, see -impact-profile
+	impactAnalyzed  bool
+	relatedFailures int
+	// build collects compiler output, and buildFails counts the packages which
+	// failed to build
+	build      buildState
+	buildFails int
+	// benchmarks are the benchmarks which reported a result, in the order they finished
+	benchmarks []*node
+	// eventNode is the node of the last event, if it wasn't filtered
+	eventNode *node
+	// input is where the input is sent, used to send the results of re-runs
+	input sender
+	// reruns counts the re-runs of failed tests, rerunning is set once the first
+	// starts, rerunRequested is set by the r key, and rerunTest by the R key
+	reruns         int
+	rerunning      bool
+	rerunRequested bool
+	rerunTest      *node
+	// showHistory is toggled by the h key, and historyRuns are read the first time
+	// the history panel is shown
+	showHistory bool
+	historyRuns []historyRun
+	// pager shows a failure's output, when it's open, and finished is set when the
+	// run finished while it was open, so the program quits when it's closed
+	pager    *pager
+	finished bool
+	// browsing is set when the live view shows a loaded snapshot, whose run is
+	// already finished
+	browsing bool
+	// packages are all the packages in the run, if known, see -packages, and
+	// packageCount is how many there are, which may be known without the list
+	packages     []string
+	packageCount int
+	// expected is the expected duration of each package, from the history, used to
+	// project whether the run will exceed -time-budget
+	expected map[string]time.Duration
+	// notStarted are the packages which weren't run, because of -stop-over-budget
+	notStarted []string
+	// paths rewrites absolute paths in test output, nil if -absolute-paths
+	paths *pathNormalizer
+	// unattributed collects the input lines which weren't test events
+	unattributed unattributedOutput
+	// cursor is the selected node, when navigating the live view, or nil when the
+	// view is following the run.  cursorIdx is its row, and scroll is the first row
+	// shown.
+	cursor    *node
+	cursorIdx int
+	scroll    int
+	// viewMode is the format of the live view, cycled with the t key
+	viewMode viewMode
+	// liveFilter hides tests from the live view by status, cycled with the f key
+	liveFilter liveFilter
+	// lastFrame is the last live view rendered, kept for -keep-last-frame
+	lastFrame string
+	// renderPanic is set if rendering panicked, and panicFile is where the panic was
+	// saved, see renderSafely
+	renderPanic string
+	panicFile   string
+	// subtests counts the finished tests which are subtests, included in total
+	subtests int
+	// skewed counts events whose timestamps went backwards, within a package
+	skewed int
+	// results of finished tests, only collected when recording history
+	results []historyResult
+	// annotations of known failures, and the last failed test, which is the one
+	// annotated by a keypress
+	annotations annotations
+	lastFailure *node
+	// newTests are the tests added in the working tree, and added are their nodes,
+	// see -new-tests
+	newTests newTests
+	added    []*node
+	// xfails counts the tests which failed as expected, see -expect-fail
+	xfails int
+	// ciGroups counts the groups of failure output, see -ci-groups
+	ciGroups int
+	// headers are the benchmarks' environment, like goos and cpu, see captureHeader
+	headers map[string]string
+	// slow counts the finished tests slower than -slow-threshold
+	slow int
+	// timedOut is set if a test binary panicked because it ran past its -timeout
+	timedOut bool
+	// fuzzTargets are the nodes which reported fuzzing status, see fuzzOutput
+	fuzzTargets []*node
+}
+
+// now returns the current time.  It's a variable so the clock can be driven by
+// event timestamps when rendering a recorded run offline.
+var now = time.Now
+
+func since(t time.Time) time.Duration {
+	return now().Sub(t)
 }
 
 func newModel() *model {
 	return &model{
-		start:   time.Now(),
+		start:   now(),
 		spinner: spinner.New(spinner.WithSpinner(spinner.MiniDot)),
 	}
 }
@@ -86,18 +227,89 @@ func (m *model) nodeFor(ev TestEvent) *node {
 		name:       strings.Join(nameParts, "/"),
 		parent:     last,
 		isTest:     ev.Test != "",
-		start:      time.Now(),
+		start:      now(),
 		lvl:        last.lvl + 1,
-		firstStart: time.Now(),
+		firstStart: now(),
+	}
+
+	node.startOffset = m.sinceRunStart(ev)
+	if ev.Time.IsZero() {
+		node.startOffset = since(m.start)
+	}
+	if last == &m.root {
+		node.setup = m.sinceRunStart(ev)
+		node.stream = ev.Stream
+		node.pinned = pinned(node.name)
 	}
 
 	last.children = append(last.children, &node)
+	if m.forgotten != nil && node.isTest {
+		m.remember(&node)
+	}
+	if m.newTests != nil {
+		m.markNew(&node)
+	}
 
 	return &node
 }
 
+// sinceRunStart returns the time between the start of the test run and the event.
+// If the event timestamps don't line up with the run start, e.g. when old output
+// is piped in, returns 0.
+func (m *model) sinceRunStart(ev TestEvent) time.Duration {
+	if ev.Time.IsZero() {
+		return 0
+	}
+	if m.runStart.IsZero() {
+		if flags.infile == "" {
+			m.runStart = m.start
+		} else {
+			m.runStart = ev.Time
+		}
+	}
+	return max(ev.Time.Sub(m.runStart), 0)
+}
+
 func (m *model) processEvent(ev TestEvent) tea.Cmd {
+	defer recentInput.dumpOnPanic()
+	m.eventNode = nil
+	m.build.capturing = false
+	m.lastEvent = now()
+	if m.firstTest.IsZero() && ev.Test != "" {
+		m.firstTest = m.lastEvent
+	}
+	switch ev.Action {
+	case "build-output", "build-fail":
+		// these aren't for a package node, see buildFailed
+		m.buildEvent(ev)
+		return nil
+	}
+	if m.filtered(ev.Package) {
+		if flags.countFiltered {
+			m.count(ev)
+		}
+		return nil
+	}
+
 	currNode := m.nodeFor(ev)
+	m.eventNode = currNode
+
+	m.checkTimestamp(currNode.pkg(), ev)
+
+	if m.rerunning && currNode.done && (ev.Action == "start" || ev.Action == "run") {
+		m.resetNode(currNode)
+	}
+	if !m.rerunning && currNode.isTest && currNode.done && ev.Action == "run" {
+		// the test is being run again in the same stream, e.g. with -count
+		currNode.nextRun()
+	}
+
+	for k, v := range ev.Extra {
+		if currNode.meta == nil {
+			currNode.meta = map[string]string{}
+		}
+		currNode.meta[k] = v
+	}
 
 	if ev.Elapsed > 0 {
 		currNode.elapsed = time.Duration(ev.Elapsed * float64(time.Second))
@@ -105,47 +317,170 @@ func (m *model) processEvent(ev TestEvent) tea.Cmd {
 	}
 
 	if ev.Action == "output" {
+		if currNode.isTest && !strings.HasPrefix(ev.Output, "=== PAUSE") {
+			// test2json only attributes output to the test whose framing says it's
+			// running, so a paused test which has output was resumed, even if its cont
+			// hasn't arrived yet, and the output isn't counted in its wait
+			resume(currNode)
+		}
+		if m.paths != nil {
+			ev.Output = m.paths.normalize(ev.Output, ev.Package)
+		}
+		m.noteTimeout(ev.Output)
+		if len(currNode.dumping) > 0 {
+			// the goroutine dump, and anything after it, is for the hung tests
+			for _, n := range currNode.dumping {
+				n.output(ev.Output)
+			}
+			return nil
+		}
+		if currNode.rateLimited(ev) {
+			return nil
+		}
+		if !currNode.isTest && m.captureHeader(currNode, ev.Output) {
+			return nil
+		}
+		if m.fuzzOutput(currNode, ev.Output) {
+			return nil
+		}
 		currNode.output(ev.Output)
+		if currNode.isTest && !currNode.done {
+			if r, ok := parseBenchLine(ev.Output); ok {
+				m.finishBench(currNode, r)
+			}
+		}
 		// for output, return immediately.  not a node state.
 		return nil
 	}
 
+	if ev.Action == "bench" && currNode.done {
+		// the benchmark's logs follow its result, which already finished it
+		return nil
+	}
+
 	currNode.status = ev.Action
+	if currNode.isTest {
+		currNode.pkg().testsStarted = true
+	}
+	switch ev.Action {
+	case "pass", "fail", "skip":
+		currNode.flushOutput()
+		currNode.noteDroppedOutput()
+	}
+
+	var hook tea.Cmd
 
 	switch ev.Action {
 	case "fail":
+		if expectedFailure(currNode, ev) {
+			// see -expect-fail
+			currNode.status = "xfail"
+			if currNode.isTest {
+				m.xfails++
+				m.total++
+			}
+			currNode.done = true
+			currNode.doneTs = now()
+			break
+		}
+		hook = onFailHook(ev, currNode.outputBuf)
 		if currNode.isTest {
 			m.fails++
 			m.total++
+			currNode.signature = failureSignature(ev.Package, ev.Test, currNode.outputBuf)
+			currNode.annotation = m.annotations[currNode.signature].Note
+			m.lastFailure = currNode
+			currNode.pkg().lastFailed = currNode
+			m.linkSimilar(currNode)
 		} else {
 			// if a package fails, the overall result of the
 			// test run is failed
 			m.overallFail = true
+			if ev.FailedBuild != "" || strings.Contains(currNode.msg, "[build failed]") {
+				m.buildFailed(currNode, ev)
+			}
 		}
 		currNode.done = true
-		currNode.doneTs = time.Now()
+		currNode.doneTs = now()
 	case "skip":
 		if currNode.isTest {
 			m.skips++
 			m.total++
+			m.linkSkip(currNode)
 		}
 		currNode.done = true
-		currNode.doneTs = time.Now()
+		currNode.doneTs = now()
 	case "pause":
-		if currNode.isTest {
-			currNode.elapsed = time.Since(currNode.start)
-			currNode.start = time.Now()
+		// time spent paused, waiting for other parallel tests, is tracked separately from
+		// the test's runtime, the same way go test reports elapsed time
+		if currNode.isTest && currNode.pausedAt.IsZero() {
+			if !currNode.start.IsZero() {
+				currNode.elapsed += since(currNode.start)
+			}
+			currNode.start = time.Time{}
+			currNode.pausedAt = now()
+			if !currNode.parallel {
+				// the first pause is from calling t.Parallel()
+				currNode.parallel = true
+				currNode.parent.parallelDeclared++
+			}
 		}
 	case "cont":
-		currNode.start = time.Now()
+		// test2json also emits cont when output switches between tests, in which case
+		// the test was never paused
+		resume(currNode)
 	case "start", "run", "bench":
 	case "pass":
 		if currNode.isTest {
 			m.passes++
 			m.total++
+			currNode.xpass = expectedToFail(currNode)
 		}
 		currNode.done = true
-		currNode.doneTs = time.Now()
+		currNode.doneTs = now()
+	}
+
+	if currNode.done && currNode.isTest {
+		m.recordSlow(currNode)
+		m.countSlow(currNode)
+		currNode.pkg().addDot(currNode.status)
+		currNode.pkg().testCount++
+		if currNode.lvl > 2 {
+			m.subtests++
+		}
+		if currNode.lvl == 2 {
+			currNode.parent.testElapsed += currNode.elapsed
+		}
+		top := currNode
+		for top.lvl > 2 {
+			top = top.parent
+		}
+		if top.tally == nil {
+			top.tally = map[string]int{}
+		}
+		top.tally[currNode.status]++
+		if currNode.parallel {
+			currNode.pkg().parallelCount++
+			top.parallelTally++
+		}
+		if flags.history {
+			m.results = append(m.results, historyResult{
+				Package: ev.Package,
+				Test:    ev.Test,
+				Action:  ev.Action,
+				Elapsed: currNode.elapsed,
+			})
+		}
+		m.countRun(currNode, currNode.status)
+		m.checkTiming(currNode, ev)
+	}
+
+	if currNode.done && !currNode.isTest {
+		finishBenchmarks(currNode)
+	}
+
+	if currNode.done && !currNode.isTest && len(flags.minCoverage) > 0 && !m.rerunning {
+		m.checkCoverage(currNode)
 	}
 
 	if currNode.done {
@@ -153,9 +488,33 @@ func (m *model) processEvent(ev TestEvent) tea.Cmd {
 		currNode.processChildren(true, false)
 	}
 
+	if currNode.done && !currNode.isTest {
+		attributeDeadlock(currNode)
+		// the package's tests won't run again
+		delete(m.forgotten, currNode.name)
+	}
+
 	// if node is finished, dump its output if appropriate
 	if currNode.done && currNode.outputBuf != nil {
-		if !drop(currNode) {
+		if reporting() || currNode.status == "fail" {
+			// failures' output is kept for the copy and edit actions.  Tests which
+			// run more than once keep the output of every run.
+			currNode.log += currNode.outputBuf.String()
+		}
+		if flags.streamFailures && currNode.isTest && ev.Action == "fail" {
+			// print the failure right away, instead of waiting for the package to finish.
+			// the output isn't rolled up into the parent, so it isn't printed again.
+			header := iconFailed + " " + currNode.pkg().name + " " + ev.Test
+			output := header + "\n" + highlightDiffs(strings.TrimRight(currNode.outputBuf.String(), "\n"))
+			output = highlightStacks(explainSynctest(output), m.stackModule(ev.Package))
+			if start, end := m.ciGroup(currNode); start != "" {
+				output = start + output + "\n" + end
+			}
+			currNode.outputBuf = nil
+			currNode.parent.processChildren(false, false)
+			return tea.Batch(hook, m.printOutput(output, flags.failuresToStderr))
+		}
+		if showOutput(currNode) {
 			// rollup the output of tests into their parents
 			// eventually this will be rolled up into the output
 			// of the package node, then finally dumped to stdout
@@ -163,30 +522,129 @@ func (m *model) processEvent(ev TestEvent) tea.Cmd {
 				if currNode.parent.outputBuf == nil {
 					currNode.parent.outputBuf = bytes.NewBuffer(nil)
 				}
+				start, end := m.ciGroup(currNode)
+				currNode.parent.outputBuf.WriteString(start)
 				copyWithIndent(currNode.outputBuf, currNode.parent.outputBuf)
+				currNode.parent.outputBuf.WriteString(end)
 			} else {
 				// this is a package node, and all it's children are done,
 				// so it is safe to dump this output to the console
 				output := currNode.outputBuf.String()
-				output = strings.TrimRight(output, "\n")
-				return func() tea.Msg {
-					m.prog.Println(output)
-					return nil
-				}
+				output = highlightDiffs(strings.TrimRight(output, "\n"))
+				output = highlightStacks(explainSynctest(output), m.stackModule(ev.Package))
+				toStderr := flags.failuresToStderr && currNode.status == "fail"
+				return tea.Batch(hook, m.printOutput(output, toStderr))
 			}
 		}
 		// we can drop the output now to free up memory.
 		currNode.outputBuf = nil
 	}
 
+	if flags.lowMemory && currNode.done && drop(currNode) {
+		// don't wait for the parent to finish to drop the node
+		m.forget(currNode)
+	}
+
 	// re-sort and filter this node's siblings based on the status change
 	currNode.parent.processChildren(false, false)
 
-	return nil
+	return hook
+}
+
+// printOutput returns a command which prints output above the live view, or to stderr.
+// While the live view is running, output for stderr is held until it ends, since the
+// live view would garble it, and be garbled by it, on the same terminal.
+func (m *model) printOutput(output string, toStderr bool) tea.Cmd {
+	output = consoleText(output)
+	if toStderr && m.prog != nil {
+		m.heldStderr = append(m.heldStderr, output)
+		return nil
+	}
+	return func() tea.Msg {
+		switch {
+		case toStderr:
+			fmt.Fprintln(os.Stderr, output)
+		case m.prog == nil:
+			// the live view was skipped
+			fmt.Println(output)
+		default:
+			m.prog.Println(output)
+		}
+		return nil
+	}
+}
+
+// flushStderr writes the output held for stderr while the live view was running.
+func (m *model) flushStderr() {
+	for _, output := range m.heldStderr {
+		fmt.Fprintln(os.Stderr, output)
+	}
+	m.heldStderr = nil
+}
+
+// filtered returns true if the package is hidden by -only-pkg or -exclude-pkg.
+func (m *model) filtered(pkg string) bool {
+	if len(flags.onlyPkg) == 0 && len(flags.excludePkg) == 0 {
+		return false
+	}
+	if f, ok := m.filteredPkgs[pkg]; ok {
+		return f
+	}
+	f := len(flags.onlyPkg) > 0 && !matchesAny(flags.onlyPkg, pkg) || matchesAny(flags.excludePkg, pkg)
+	if m.filteredPkgs == nil {
+		m.filteredPkgs = map[string]bool{}
+	}
+	m.filteredPkgs[pkg] = f
+	return f
+}
+
+// count updates the totals for an event, without adding it to the tree.
+func (m *model) count(ev TestEvent) {
+	if ev.Test == "" {
+		if ev.Action == "fail" {
+			m.overallFail = true
+		}
+		return
+	}
+	switch ev.Action {
+	case "pass":
+		m.passes++
+	case "fail":
+		m.fails++
+	case "skip":
+		m.skips++
+	default:
+		return
+	}
+	m.total++
+	if strings.Contains(ev.Test, "/") {
+		m.subtests++
+	}
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// pinned returns true if the package name matches one of the -pin patterns.
+func pinned(pkg string) bool {
+	return matchesAny(flags.pin, pkg)
 }
 
 func nodeSorter(final bool) func(*node, *node) int {
 	return func(a, b *node) int {
+		// sort pinned packages to the top, regardless of status
+		if a.pinned != b.pinned {
+			if a.pinned {
+				return -1
+			}
+			return 1
+		}
 		if final {
 			// sort dropped nodes to the end
 			if da, db := drop(a), drop(b); da != db {
@@ -219,29 +677,216 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.windowHeight = msg.Height
+		m.windowWidth = msg.Width
+		if m.pager != nil {
+			m.pager.resize(msg.Width, m.viewHeight())
+		}
 		m.maxPrintedLines = 0
 	case error:
 		m.err = msg
 		return m, tea.Quit
 	case tea.KeyMsg:
+		if m.pager != nil {
+			if m.pager.update(msg) {
+				m.pager = nil
+				if m.finished {
+					// the run finished while the pager was open, see -pager
+					m.done = true
+					return m, tea.Quit
+				}
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "q", "esc", "ctrl+c":
+			if !m.done && !m.finished && !m.browsing {
+				// the run isn't finished, so it can't be summarized as if it were
+				m.abort()
+			}
 			m.done = true
 			return m, tea.Quit
+		case "up":
+			m.moveCursor(-1)
+		case "down":
+			m.moveCursor(1)
+		case "pgup":
+			m.moveCursor(-max(m.viewHeight()-3, 1))
+		case "pgdown":
+			m.moveCursor(max(m.viewHeight()-3, 1))
+		case "home":
+			m.moveCursor(-len(m.navNodes()))
+		case "end":
+			m.moveCursor(len(m.navNodes()))
+		case "left":
+			m.setCollapsed(true)
+		case "right":
+			m.setCollapsed(false)
+		case "enter":
+			// show a failure's output, or expand or collapse the test
+			if m.cursor != nil && !m.openPager(m.cursor) {
+				m.setCollapsed(!m.cursor.collapsed)
+			}
+		case "space":
+			if m.cursor != nil {
+				m.setCollapsed(!m.cursor.collapsed)
+			}
+		case "f":
+			// go back to following the run, or if already following, filter the view
+			if m.cursor != nil {
+				m.cursor = nil
+			} else {
+				m.liveFilter = m.liveFilter.next()
+			}
+		case "r":
+			// re-run the failed tests when the run finishes
+			m.rerunRequested = m.canRerun()
+		case "R":
+			// re-run just the selected test when the run finishes
+			if n := m.selected(); n != nil && m.canRerun() {
+				m.rerunTest = n
+			}
+		case "t":
+			m.viewMode = m.viewMode.next()
+		case "h":
+			return m, m.toggleHistory()
+		case "c":
+			if n := m.selected(); n != nil {
+				return m, copyToClipboard(reproCommand(n))
+			}
+		case "y":
+			if n := m.selected(); n != nil {
+				return m, copyToClipboard(n.log)
+			}
+		case "s":
+			return m, copyToClipboard(clipboardSummary(m.summary()))
+		case "e":
+			if n := m.selected(); n != nil {
+				return m, editFailure(n)
+			}
+		case "d":
+			return m, m.dumpStuck()
+		case "k":
+			m.annotate("known")
+		case "i":
+			m.annotate("investigating")
 		}
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+	case historyLoaded:
+		// an empty history is still loaded
+		m.historyRuns = append([]historyRun{}, msg...)
 	case TestEvent:
-		return m, m.processEvent(msg)
+		cmd := m.processEvent(msg)
+		m.checkBudget()
+		if msg.Action == "fail" {
+			cmd = tea.Batch(cmd, alert(), soundCmd("fail"))
+		}
+		m.updateStatusFile(false)
+		m.updateWeb(false)
+		return m, cmd
+	case Unattributed:
+		if m.captureBuildOutput(string(msg)) {
+			return m, nil
+		}
+		m.unattributed.add(string(msg))
+		return m, m.printOutput(string(msg), false)
 	case Done:
+		if goTest != nil && !m.rerunning {
+			if pkgs := goTest.notStarted(); len(pkgs) > 0 {
+				// the tests which weren't run can't be counted as passed
+				m.notStarted = pkgs
+				m.overallFail = true
+			}
+		}
+		if m.wantRerun() {
+			return m, m.startRerun()
+		}
+		if m.prog != nil && (m.pager != nil || flags.pager && m.openPager(nil)) {
+			// keep running until the pager is closed
+			m.finished = true
+			return m, nil
+		}
 		m.done = true
 		return m, tea.Quit
+	case Abort:
+		log.Println("aborting on signal:", msg.Signal)
+		m.abort()
+		return m, tea.Quit
 	}
 	return m, nil
 }
 
+// resume ends a paused test's wait, and starts its runtime again.
+func resume(n *node) {
+	if n.pausedAt.IsZero() {
+		return
+	}
+	n.waiting += since(n.pausedAt)
+	n.pausedAt = time.Time{}
+	n.start = now()
+	n.parent.parallelMax = max(n.parent.parallelMax, n.parent.runningParallel())
+}
+
+// alert rings the terminal bell and/or flashes the screen, if enabled, to get
+// the user's attention when a failure happens.
+func alert() tea.Cmd {
+	if !flags.bellOnFail && !flags.flashOnFail {
+		return nil
+	}
+	return func() tea.Msg {
+		if flags.bellOnFail {
+			_, _ = os.Stdout.WriteString("\a")
+		}
+		if flags.flashOnFail {
+			// switch to reverse video briefly
+			_, _ = os.Stdout.WriteString("\x1b[?5h")
+			time.Sleep(100 * time.Millisecond)
+			_, _ = os.Stdout.WriteString("\x1b[?5l")
+		}
+		return nil
+	}
+}
+
+// abort finishes the run early, marking all the unfinished packages and tests
+// as aborted, and kills go test in wrapper mode.
+func (m *model) abort() {
+	if goTest != nil {
+		goTest.kill()
+	}
+	ts := now()
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			walk(c)
+			if c.done {
+				continue
+			}
+			if !c.start.IsZero() {
+				c.elapsed += since(c.start)
+				c.start = time.Time{}
+			}
+			if !c.pausedAt.IsZero() {
+				c.waiting += since(c.pausedAt)
+				c.pausedAt = time.Time{}
+			}
+			c.flushOutput()
+			c.status = "aborted"
+			c.done = true
+			c.doneTs = ts
+			if c.isTest {
+				m.aborts++
+			}
+		}
+	}
+	walk(&m.root)
+	m.aborted = true
+	m.overallFail = true
+	m.done = true
+	m.end = ts
+}
+
 // printNode prints a line to the writer representing this node, then recursive prints
 // each of the child nodes.  Returns the total number of lines printed.
 func (m *model) printNode(n *node, writer io.Writer) {
@@ -251,32 +896,18 @@ func (m *model) printNode(n *node, writer io.Writer) {
 	m.println(n, writer)
 }
 
-var iconPassed = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true).Render("✓")
-var iconSkipped = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true).Render("⍉")
-var iconFailed = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true).Render("✖")
-var gray = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-
 func (m *model) println(n *node, writer io.Writer) {
 	elapsed := n.elapsed
 
-	var icon string
-
 	switch n.status {
 	case "start", "run", "cont", "bench":
-		elapsed = n.elapsed + scaledTimeSince(n.start)
-		icon = m.spinner.View()
-	case "pause":
-		icon = "⏸"
-	case "fail":
-		icon = iconFailed
-	case "skip":
-		icon = iconSkipped
-	case "pass":
-		icon = iconPassed
-	default:
-		icon = "??? " + n.status + " ???"
+		if !n.start.IsZero() {
+			elapsed = n.elapsed + scaledTimeSince(n.start)
+		}
 	}
 
+	icon := m.icon(n)
+
 	// the min elapsed time.  If elapsed is less then this, the elapsed time will not be rendered
 	var minElapsed time.Duration
 	digits := 3
@@ -287,7 +918,141 @@ func (m *model) println(n *node, writer io.Writer) {
 		digits = 1
 	}
 
-	fmt.Fprintf(writer, "%s %s\t%s\t%s\n", icon, n.name, formatElapsed(elapsed, minElapsed, digits), gray.Render(n.msg))
+	msg := n.msg
+	if n.parallelDeclared > 0 {
+		msg = strings.TrimSpace(fmt.Sprintf("parallel %d/%d  %s", n.parallelMax, n.parallelDeclared, msg))
+	}
+	if len(n.env) > 0 {
+		msg = strings.TrimSpace(strings.Join(n.env, " ") + "  " + msg)
+	}
+	if link := n.skipLink(); link != "" {
+		msg = strings.TrimSpace(link + "  " + msg)
+	}
+	if link := n.similarLink(); link != "" {
+		msg = strings.TrimSpace(link + "  " + msg)
+	}
+	if n.bench != nil {
+		msg = strings.TrimSpace(n.bench.String() + "  " + msg)
+	}
+	if runs := n.runsMsg(); runs != "" {
+		msg = strings.TrimSpace(runs + "  " + msg)
+	}
+	if n.annotation != "" {
+		msg = "[" + n.annotation + "] " + msg
+	}
+	if n.hidesChildren() && len(n.children) > 0 {
+		total, failed := n.descendantCounts()
+		hidden := fmt.Sprintf("+%d subtests", total)
+		if failed > 0 {
+			hidden += fmt.Sprintf(", %d failed", failed)
+		}
+		msg = strings.TrimSpace(hidden + "  " + msg)
+	}
+	if setup := formatElapsed(n.setup, 100*time.Millisecond, 1); setup != "" {
+		msg = strings.TrimSpace("setup " + setup + "  " + msg)
+	}
+
+	elapsedStr := formatElapsed(elapsed, minElapsed, digits)
+	if elapsedStr != "" {
+		elapsedStr = durationStyle(elapsed).Render(elapsedStr)
+	}
+	if delta := n.timingDelta(); delta != "" && n.done {
+		style := gray
+		if n.slower() {
+			style = failedText
+		}
+		elapsedStr = strings.TrimSpace(elapsedStr + " " + style.Render("("+delta+")"))
+	}
+	if waiting := n.waitingTime(); waiting >= time.Second {
+		elapsedStr = strings.TrimSpace(fmt.Sprintf("%s (+%s waiting)", elapsedStr, round(waiting, 0)))
+	}
+
+	if n.hasCoverage && !strings.Contains(msg, "coverage") {
+		// with -v, coverage is reported on its own line
+		msg = strings.TrimSpace(msg + fmt.Sprintf("  coverage: %.1f%% of statements", n.coverage))
+	}
+	if n.stream != "" {
+		msg = strings.TrimSpace("[" + n.stream + "]  " + msg)
+	}
+	msg = gray.Render(msg)
+	if v := n.coverageViolation(); v != "" {
+		msg = strings.TrimSpace(msg + " " + failedText.Render(v))
+	}
+	if n.flaky() {
+		msg = strings.TrimSpace(failedText.Render("FLAKY") + " " + msg)
+	}
+	if n.preexisting {
+		msg = strings.TrimSpace(msg + "  possibly pre-existing failure")
+	}
+	if n.status == "xfail" && expectedToFail(n) {
+		msg = strings.TrimSpace(msg + "  expected failure")
+	}
+	if n.xpass {
+		msg = strings.TrimSpace(failedText.Render("XPASS") + " " + msg)
+	}
+	if n.outputDropped() > 0 {
+		msg = strings.TrimSpace(msg + "  " + failedText.Render("output rate-limited"))
+	}
+	if flags.slowThreshold > 0 && n.isTest && !n.done && n.runningFor() > flags.slowThreshold {
+		// chatty tests, which will dump a lot of output if they fail
+		if size := printBufBytes(n); size != "" {
+			msg = strings.TrimSpace(msg + "  " + gray.Render(size+" output"))
+		}
+	}
+	if n.fuzz != nil {
+		msg = strings.TrimSpace(msg + "  " + n.fuzz.String())
+		for _, input := range n.fuzz.inputs {
+			msg += "  " + failedText.Render("failing input: "+input)
+		}
+	}
+	if n.isNew {
+		msg = strings.TrimSpace(newText.Render("new") + " " + msg)
+	}
+	if n.crash != "" && n.status == "fail" {
+		msg = strings.TrimSpace(failedText.Render(n.crash) + " " + msg)
+	}
+
+	fmt.Fprintf(writer, "%s %s\t%s\t%s\n", icon, displayName(n.name), elapsedStr, msg)
+}
+
+// icon returns the icon for the node's status.
+func (m *model) icon(n *node) string {
+	switch n.status {
+	case "bench":
+		if n.done {
+			return iconPassed
+		}
+		return m.spinner.View()
+	case "start", "run", "cont":
+		if !n.isTest && !n.testsStarted {
+			// no tests have started yet, so the package is still building or queued
+			return iconQueued
+		}
+		if n.stuck() {
+			return iconStuck
+		}
+		return m.spinner.View()
+	case "pause":
+		return "⏸"
+	case "fail":
+		if n.buildFailure != "" {
+			return iconBuild
+		}
+		if n.crash != "" {
+			return crashIcon(n.crash)
+		}
+		return iconFailed
+	case "aborted":
+		return iconAborted
+	case "xfail":
+		return iconXFail
+	case "skip":
+		return iconSkipped
+	case "pass":
+		return iconPassed
+	default:
+		return "??? " + n.status + " ???"
+	}
 }
 
 func (m *model) View() string {
@@ -297,10 +1062,18 @@ func (m *model) View() string {
 	// once we're done, we don't want to print any view.  The final
 	// summary will be dumped to the terminal with tea.Program#Println()
 	if m.done {
+		if flags.keepLastFrame {
+			// leave the last live frame in the scrollback, above the summary
+			return m.lastFrame
+		}
 		return ""
 	}
 
-	return m.render(true)
+	if m.pager != nil {
+		return m.pager.view()
+	}
+	m.lastFrame = m.renderSafely(true)
+	return m.lastFrame
 }
 
 func elide(l *list.List, max int) *list.List {
@@ -322,6 +1095,14 @@ func elide(l *list.List, max int) *list.List {
 	slices.SortStableFunc(candidates, func(a, b *list.Element) int {
 		an, bn := a.Value.(*node), b.Value.(*node)
 
+		// elide pinned packages last
+		if an.pinned != bn.pinned {
+			if an.pinned {
+				return 1
+			}
+			return -1
+		}
+
 		if i := statusRank(an.status) - statusRank(bn.status); i != 0 {
 			return i
 		}
@@ -377,7 +1158,7 @@ func statusRank2(s string) int {
 		return 1
 	case "skip":
 		return 1
-	case "fail":
+	case "fail", "aborted":
 		return 4
 	case "cont", "start", "run", "bench":
 		return 5
@@ -393,7 +1174,7 @@ func statusRank(s string) int {
 		return 1
 	case "skip":
 		return 1
-	case "fail":
+	case "fail", "aborted":
 		return 5
 	case "cont", "start", "run", "bench":
 		return 5
@@ -429,7 +1210,7 @@ func collectNodes(nodes []*node) *list.List {
 
 		// if current node has children, push the children into the stack
 		// and bump i to process the children next
-		if len(n.children) > 0 {
+		if len(n.children) > 0 && !n.hidesChildren() {
 			stack = append(stack, n.children)
 			i++
 		}
@@ -439,7 +1220,7 @@ func collectNodes(nodes []*node) *list.List {
 }
 
 func (m *model) String() string {
-	return m.render(false)
+	return m.renderSafely(false)
 }
 
 func (m *model) render(fitToWindow bool) string {
@@ -455,15 +1236,72 @@ func (m *model) render(fitToWindow bool) string {
 	origLen := l.Len()
 
 	if fitToWindow {
-		l = elide(l, m.windowHeight-2)
+		l = m.applyFilter(l)
+	}
+	if fitToWindow && m.viewMode != viewTree {
+		l = packagesOnly(l)
 	}
 
-	for _, n := range listSeq(l) {
-		m.printNode(n, &sb)
+	// lines used by the summary below the tree
+	reserved := 2
+	queued, done, total := 0, 0, 0
+	var stuck []*node
+	if !m.done {
+		queued = m.queued()
+		stuck = m.stuckTests()
+		done, total = m.packageProgress()
+	}
+	if queued > 0 {
+		reserved++
+	}
+	if total > 0 {
+		reserved++
+	}
+	reserved += min(len(stuck), maxStuckLines+1)
+	var history string
+	if fitToWindow {
+		history = m.historyPanel()
+		reserved += strings.Count(history, "\n")
 	}
 
 	if fitToWindow {
-		printedLines := l.Len() + 2
+		if m.cursor != nil {
+			l = m.scrollTo(l, m.viewHeight()-reserved)
+		} else {
+			l = elide(l, m.viewHeight()-reserved)
+		}
+	}
+
+	if !fitToWindow && flags.finalView == "starts" {
+		m.printStarts(&sb)
+	} else {
+		for _, n := range listSeq(l) {
+			if fitToWindow && m.cursor != nil {
+				if n == m.cursor {
+					sb.WriteString(iconCursor + " ")
+				} else {
+					sb.WriteString("  ")
+				}
+			}
+			if fitToWindow && m.viewMode == viewDots {
+				m.printDots(n, &sb)
+			} else {
+				m.printNode(n, &sb)
+			}
+		}
+	}
+
+	if queued > 0 {
+		fmt.Fprintf(&sb, "%s %s\n", iconQueued, gray.Render(fmt.Sprintf("+%d queued", queued)))
+	}
+	m.renderStuck(&sb, stuck)
+	sb.WriteString(history)
+	if total > 0 {
+		m.renderProgress(&sb, done, total)
+	}
+
+	if fitToWindow {
+		printedLines := l.Len() + reserved
 
 		if printedLines >= m.maxPrintedLines {
 			m.maxPrintedLines = printedLines
@@ -475,23 +1313,67 @@ func (m *model) render(fitToWindow bool) string {
 		}
 	}
 
+	if m.done {
+		m.renderSections(&sb)
+	}
+
 	fmt.Fprintf(&sb, "\n")
+	if m.done && flags.summaryStyle == "table" {
+		m.renderSummaryTable(&sb)
+		return sb.String()
+	}
 	if m.done {
-		if m.overallFail {
+		if m.aborted || m.aborts > 0 {
+			sb.WriteString("ABORTED ")
+		} else if m.overallFail {
 			sb.WriteString("FAILED ")
 		} else {
 			sb.WriteString("PASSED ")
 		}
 	}
 
-	fmt.Fprintf(&sb, "%d tests", m.total)
+	if flags.splitSubtests {
+		fmt.Fprintf(&sb, "%d tests (%d subtests)", m.total-m.subtests, m.subtests)
+	} else {
+		fmt.Fprintf(&sb, "%d tests", m.total)
+	}
 	if m.skips > 0 {
 		fmt.Fprintf(&sb, ", %d skipped", m.skips)
 	}
 	if m.fails > 0 {
 		fmt.Fprintf(&sb, ", %d failed", m.fails)
 	}
-	fmt.Fprintf(&sb, " in %s", round(scaledTimeSince(m.start), 1))
+	if m.xfails > 0 {
+		fmt.Fprintf(&sb, ", %d failed as expected", m.xfails)
+	}
+	if m.aborts > 0 {
+		fmt.Fprintf(&sb, ", %d aborted", m.aborts)
+	}
+	if m.buildFails > 0 {
+		fmt.Fprintf(&sb, ", %d packages failed to build", m.buildFails)
+	}
+	if len(m.flaky) > 0 {
+		fmt.Fprintf(&sb, ", %d flaky", len(m.flaky))
+	}
+	elapsed := scaledTimeSince(m.start)
+	if !m.end.IsZero() {
+		elapsed = m.end.Sub(m.start)
+	}
+	fmt.Fprintf(&sb, " in %s", round(elapsed, 1))
+	if build, test, ok := m.phases(); ok && m.done {
+		fmt.Fprintf(&sb, " (build %s, test %s)", round(build, 1), round(test, 1))
+	}
+	if flags.timeFormat != "" && !m.start.IsZero() {
+		fmt.Fprintf(&sb, ", started %s", formatTime(m.start, ""))
+	}
+	if !m.done {
+		if warning := m.budgetWarning(); warning != "" {
+			sb.WriteString("  " + warning)
+		}
+	}
+	if fitToWindow && m.filtering() {
+		sb.WriteString("  " + gray.Render(m.filterLabel()))
+	}
 	if flags.debug {
 		fmt.Fprintf(&sb, " h: %v maxPrinted: %v origLen: %v printedLen: %v", m.windowHeight, m.maxPrintedLines, origLen, l.Len())
 	}
@@ -499,8 +1381,31 @@ func (m *model) render(fitToWindow bool) string {
 	return sb.String()
 }
 
+// phases splits the run into the time before the first test started, mostly spent
+// compiling and vetting, and the time from then until the last event.  Input read
+// from a file arrives all at once, so it has no phases, unless it's replayed.
+func (m *model) phases() (build, test time.Duration, ok bool) {
+	if m.firstTest.IsZero() || flags.infile != "" && !flags.replay {
+		return 0, 0, false
+	}
+	build, test = m.firstTest.Sub(m.start), m.lastEvent.Sub(m.firstTest)
+	if flags.replay && flags.rate > 0 {
+		build = time.Duration(float64(build) / flags.rate)
+		test = time.Duration(float64(test) / flags.rate)
+	}
+	return build, test, true
+}
+
+// viewHeight returns the number of lines available to the live view.
+func (m *model) viewHeight() int {
+	if flags.inline > 0 && (m.windowHeight == 0 || flags.inline < m.windowHeight) {
+		return flags.inline
+	}
+	return m.windowHeight
+}
+
 func scaledTimeSince(t time.Time) time.Duration {
-	s := time.Since(t)
+	s := since(t)
 	if flags.replay && flags.rate > 0 {
 		s = time.Duration(float64(s) / flags.rate)
 
@@ -508,27 +1413,36 @@ func scaledTimeSince(t time.Time) time.Duration {
 	return s
 }
 
-// func printBufBytes(buf *bytes.Buffer) string {
-// 	if buf == nil {
-// 		return ""
-// 	}
-
-// 	return byteCountDecimal(buf.Len())
-// }
+// printBufBytes returns the size of the node's buffered output, e.g. "1.4MB", or ""
+// if it has none.  Output past -max-output-lines or -max-output-bytes which was
+// dropped from memory isn't counted.
+func printBufBytes(n *node) string {
+	size := 0
+	if n.outputBuf != nil {
+		size += n.outputBuf.Len()
+	}
+	if n.overflow != nil {
+		size += n.overflow.size
+	}
+	if size == 0 {
+		return ""
+	}
+	return byteCountDecimal(size)
+}
 
-// func byteCountDecimal(b int) string {
-// 	const unit = 1000
-// 	if b < unit {
-// 		return fmt.Sprintf("%dB", b)
-// 	}
-// 	div, exp := int64(unit), 0
-// 	for n := b / unit; n >= unit; n /= unit {
-// 		div *= unit
-// 		exp++
-// 	}
+func byteCountDecimal(b int) string {
+	const unit = 1000
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
 
-// 	return fmt.Sprintf("%.9g%cB", float64(b)/float64(div), "kMGTPE"[exp])
-// }
+	return fmt.Sprintf("%.1f%cB", float64(b)/float64(div), "kMGTPE"[exp])
+}
 
 func formatElapsed(d, min time.Duration, digits int) string {
 	if d < min {
@@ -554,7 +1468,7 @@ func round(d time.Duration, digits int) time.Duration {
 }
 
 func copyWithIndent(from, to *bytes.Buffer) {
-	s := bufio.NewScanner(from)
+	s := newLineScanner(from)
 	for s.Scan() {
 		to.WriteString("    ")
 		to.WriteString(s.Text())
@@ -562,6 +1476,27 @@ func copyWithIndent(from, to *bytes.Buffer) {
 	}
 }
 
+// showOutputFors are the valid values of -show-output-for.
+var showOutputFors = []string{"pass", "fail", "all", "none"}
+
+// showOutput returns true if a finished node's output should be printed.  By
+// default, that's when the node is shown in the tree, unless -show-output-for
+// picks the tests by status.
+func showOutput(n *node) bool {
+	if !n.isTest {
+		return true
+	}
+	switch flags.showOutputFor {
+	case "all":
+		return true
+	case "none":
+		return false
+	case "pass", "fail":
+		return n.status == flags.showOutputFor
+	}
+	return !drop(n)
+}
+
 func drop(n *node) bool {
 	switch {
 	case !n.isTest:
@@ -575,7 +1510,7 @@ func drop(n *node) bool {
 		return false
 	case !flags.includeSkipped && n.status == "skip":
 		return true
-	case !flags.includePassed && n.status == "pass":
+	case !flags.includePassed && n.status == "pass" && !n.xpass:
 		return true
 	}
 	return false
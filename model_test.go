@@ -0,0 +1,628 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feed processes the events in order, as if they were read from the input.
+func feed(m *model, events ...TestEvent) {
+	for _, ev := range events {
+		m.processEvent(ev)
+	}
+}
+
+// keepFlags restores all the flags when the test ends, so the test can set
+// whichever it needs.
+func keepFlags(t *testing.T) {
+	saved := flags
+	// copy the maps and slices too, so changes made to them in place, like
+	// flags.fieldMap["Test"] = ..., don't outlive the test either
+	v := reflect.ValueOf(&saved).Elem()
+	for i := range v.NumField() {
+		f := v.Field(i)
+		f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+		switch {
+		case f.IsZero():
+		case f.Kind() == reflect.Map:
+			c := reflect.MakeMapWithSize(f.Type(), f.Len())
+			for it := f.MapRange(); it.Next(); {
+				c.SetMapIndex(it.Key(), it.Value())
+			}
+			f.Set(c)
+		case f.Kind() == reflect.Slice:
+			c := reflect.MakeSlice(f.Type(), f.Len(), f.Len())
+			reflect.Copy(c, f)
+			f.Set(c)
+		}
+	}
+	t.Cleanup(func() { flags = saved })
+}
+
+func TestEmptyPackages(t *testing.T) {
+	m := newModel()
+
+	events := []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a"},
+		{Action: "start", Package: "b"},
+		{Action: "output", Package: "b", Output: "ok  \tb\t0.230s [no tests to run]\n"},
+		{Action: "pass", Package: "b"},
+		{Action: "start", Package: "c"},
+		{Action: "output", Package: "c", Output: "?   \tc\t[no test files]\n"},
+		{Action: "skip", Package: "c"},
+	}
+	feed(m, events...)
+
+	empty := m.emptyPackages()
+	if assert.Len(t, empty, 1) {
+		assert.Equal(t, "b", empty[0].name)
+		assert.Equal(t, "[no tests to run]", empty[0].msg)
+	}
+}
+
+func TestPackageSetup(t *testing.T) {
+	keepFlags(t)
+	flags.infile = "test.out"
+
+	start := time.Now()
+	m := newModel()
+	m.processEvent(TestEvent{Time: start, Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Time: start.Add(3 * time.Second), Action: "start", Package: "b"})
+	m.processEvent(TestEvent{Time: start.Add(4 * time.Second), Action: "run", Package: "b", Test: "TestB"})
+
+	assert.Equal(t, time.Duration(0), m.root.children[0].setup)
+	assert.Equal(t, 3*time.Second, m.root.children[1].setup)
+	assert.Equal(t, time.Duration(0), m.root.children[1].children[0].setup, "only set on packages")
+}
+
+func TestPinnedPackages(t *testing.T) {
+	keepFlags(t)
+	flags.pin = []*regexp.Regexp{regexp.MustCompile(`/core$`)}
+
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "x/a"})
+	m.processEvent(TestEvent{Action: "pass", Package: "x/a"})
+	m.processEvent(TestEvent{Action: "start", Package: "x/b"})
+	m.processEvent(TestEvent{Action: "start", Package: "x/core"})
+
+	names := func() []string {
+		var names []string
+		for _, n := range m.root.children {
+			names = append(names, n.name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"x/core", "x/a", "x/b"}, names())
+
+	l := elide(collectNodes(m.root.children), 1)
+	assert.Equal(t, "x/core", l.Front().Value.(*node).name)
+}
+
+func TestMaxDepth(t *testing.T) {
+	keepFlags(t)
+	flags.maxDepth = 1
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/b"},
+		{Action: "run", Package: "a", Test: "TestA/b/c"},
+		{Action: "fail", Package: "a", Test: "TestA/b/c"},
+	}...)
+
+	var names []string
+	for _, n := range listSeq(collectNodes(m.root.children)) {
+		names = append(names, n.name)
+	}
+	assert.Equal(t, []string{"a", "TestA"}, names)
+
+	total, failed := m.root.children[0].children[0].descendantCounts()
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 1, failed)
+}
+
+func TestAbort(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+	}...)
+
+	m.Update(Abort{Signal: syscall.SIGTERM})
+
+	assert.True(t, m.done)
+	assert.True(t, m.overallFail)
+	assert.Equal(t, 1, m.aborts)
+	pkg := m.root.children[0]
+	assert.Equal(t, "aborted", pkg.status)
+	testB, _ := pkg.findChild([]string{"TestB"})
+	assert.Equal(t, "aborted", testB.status)
+	assert.Contains(t, m.String(), "ABORTED 1 tests, 1 aborted")
+}
+
+func TestQuitAborts(t *testing.T) {
+	m := newModel()
+	feed(m,
+		TestEvent{Action: "start", Package: "a"},
+		TestEvent{Action: "run", Package: "a", Test: "TestA"},
+		TestEvent{Action: "pass", Package: "a", Test: "TestA"},
+	)
+
+	// quitting before the run finished doesn't count as passing, even when every
+	// test which started passed
+	m.Update(tea.KeyPressMsg{Code: 'c', Mod: tea.ModCtrl})
+
+	assert.True(t, m.done)
+	assert.True(t, m.aborted)
+	assert.True(t, m.overallFail)
+	assert.Equal(t, "aborted", m.root.children[0].status)
+	assert.Contains(t, m.String(), "ABORTED 1 tests in ")
+	assert.Equal(t, 1, m.exitCode())
+}
+
+func TestQuitFinished(t *testing.T) {
+	keepFlags(t)
+	flags.pager = true
+	m := newModel()
+	m.prog = &fakeProgram{}
+	feed(m,
+		TestEvent{Action: "start", Package: "a"},
+		TestEvent{Action: "run", Package: "a", Test: "TestA"},
+		TestEvent{Action: "output", Package: "a", Test: "TestA", Output: "boom\n"},
+		TestEvent{Action: "fail", Package: "a", Test: "TestA"},
+		TestEvent{Action: "fail", Package: "a"},
+	)
+	m.Update(Done{})
+	require.True(t, m.finished)
+
+	// quitting the finished run, once the pager is closed, doesn't abort it
+	m.pager = nil
+	m.Update(key("q"))
+
+	assert.True(t, m.done)
+	assert.False(t, m.aborted)
+	assert.Zero(t, m.aborts)
+	assert.Equal(t, "fail", m.root.children[0].status)
+}
+
+func TestCacheSummary(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/sub"},
+		{Action: "pass", Package: "a", Test: "TestA/sub", Elapsed: 1},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 2},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "pass", Package: "a", Test: "TestB", Elapsed: 3},
+		{Action: "output", Package: "a", Output: "ok  \ta\t(cached)\n"},
+		{Action: "pass", Package: "a"},
+		{Action: "start", Package: "b"},
+		{Action: "output", Package: "b", Output: "ok  \tb\t0.1s\n"},
+		{Action: "pass", Package: "b", Elapsed: 0.1},
+		{Action: "start", Package: "c"},
+		{Action: "output", Package: "c", Output: "?   \tc\t[no test files]\n"},
+		{Action: "skip", Package: "c"},
+	}...)
+
+	cached, total, saved := m.cacheSummary()
+	assert.Equal(t, 1, cached)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 5*time.Second, saved)
+}
+
+func TestReportVerbosity(t *testing.T) {
+	keepFlags(t)
+	flags.snapshot = "x"
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "chatty\n"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a"},
+	}...)
+
+	for verbosity, expected := range map[string]string{"normal": "", "failed": "", "all": "chatty\n"} {
+		flags.reportVerbosity = verbosity
+		s := m.snapshot()
+		require.Len(t, s.Packages[0].Children, 1)
+		assert.Equal(t, expected, s.Packages[0].Children[0].Output, verbosity)
+	}
+}
+
+func TestPausedTime(t *testing.T) {
+	clock := time.Now()
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	m := newModel()
+	step := func(d time.Duration, ev TestEvent) {
+		clock = clock.Add(d)
+		m.processEvent(ev)
+	}
+	step(0, TestEvent{Action: "start", Package: "a"})
+	step(0, TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	step(time.Second, TestEvent{Action: "pause", Package: "a", Test: "TestA"})
+	n := m.root.children[0].children[0]
+	assert.Equal(t, time.Second, n.elapsed)
+
+	clock = clock.Add(8 * time.Second)
+	assert.Equal(t, 8*time.Second, n.waitingTime())
+
+	step(0, TestEvent{Action: "cont", Package: "a", Test: "TestA"})
+	// cont without a pause, because of interleaved output, doesn't reset anything
+	step(time.Second, TestEvent{Action: "cont", Package: "a", Test: "TestA"})
+	assert.Equal(t, 8*time.Second, n.waitingTime())
+	assert.Equal(t, time.Second, n.elapsed)
+
+	var sb strings.Builder
+	m.printNode(n, &sb)
+	assert.Contains(t, sb.String(), "TestA\t2s (+8s waiting)")
+
+	step(time.Second, TestEvent{Action: "pass", Package: "a", Test: "TestA", Elapsed: 3})
+	assert.Equal(t, 3*time.Second, n.elapsed)
+	assert.Equal(t, 8*time.Second, n.waitingTime())
+}
+
+func TestPausedOutput(t *testing.T) {
+	clock := time.Now()
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	m := newModel()
+	step := func(d time.Duration, ev TestEvent) {
+		clock = clock.Add(d)
+		m.processEvent(ev)
+	}
+	step(0, TestEvent{Action: "start", Package: "a"})
+	step(0, TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	step(time.Second, TestEvent{Action: "pause", Package: "a", Test: "TestA"})
+	step(0, TestEvent{Action: "output", Package: "a", Test: "TestA", Output: "=== PAUSE TestA\n"})
+	n := m.root.children[0].children[0]
+	assert.False(t, n.pausedAt.IsZero(), "the pause's own framing doesn't resume the test")
+
+	// the test's output means it's running again, even before its cont
+	step(5*time.Second, TestEvent{Action: "output", Package: "a", Test: "TestA", Output: "    a_test.go:5: working\n"})
+	assert.True(t, n.pausedAt.IsZero())
+	step(2*time.Second, TestEvent{Action: "cont", Package: "a", Test: "TestA"})
+	assert.Equal(t, 5*time.Second, n.waitingTime())
+	assert.Equal(t, 3*time.Second, n.elapsed+since(n.start))
+}
+
+func TestPackageFilters(t *testing.T) {
+	keepFlags(t)
+	flags.onlyPkg = []*regexp.Regexp{regexp.MustCompile(`^x/`)}
+	flags.excludePkg = []*regexp.Regexp{regexp.MustCompile(`/gen$`)}
+
+	events := []TestEvent{
+		{Action: "start", Package: "x/a"},
+		{Action: "run", Package: "x/a", Test: "TestA"},
+		{Action: "pass", Package: "x/a", Test: "TestA"},
+		{Action: "pass", Package: "x/a"},
+		{Action: "start", Package: "x/gen"},
+		{Action: "run", Package: "x/gen", Test: "TestGen"},
+		{Action: "fail", Package: "x/gen", Test: "TestGen"},
+		{Action: "fail", Package: "x/gen"},
+		{Action: "start", Package: "y/b"},
+		{Action: "run", Package: "y/b", Test: "TestB"},
+		{Action: "pass", Package: "y/b", Test: "TestB"},
+		{Action: "pass", Package: "y/b"},
+	}
+
+	m := newModel()
+	feed(m, events...)
+	require.Len(t, m.root.children, 1)
+	assert.Equal(t, "x/a", m.root.children[0].name)
+	assert.Equal(t, 1, m.total)
+	assert.False(t, m.overallFail)
+
+	flags.countFiltered = true
+	m = newModel()
+	feed(m, events...)
+	assert.Len(t, m.root.children, 1)
+	assert.Equal(t, 3, m.total)
+	assert.Equal(t, 1, m.fails)
+	assert.True(t, m.overallFail)
+}
+
+func TestParallelism(t *testing.T) {
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	for _, sub := range []string{"1", "2", "3"} {
+		m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA/" + sub})
+		m.processEvent(TestEvent{Action: "pause", Package: "a", Test: "TestA/" + sub})
+	}
+	// only two subtests end up running at the same time
+	m.processEvent(TestEvent{Action: "cont", Package: "a", Test: "TestA/1"})
+	m.processEvent(TestEvent{Action: "cont", Package: "a", Test: "TestA/2"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA/1"})
+	m.processEvent(TestEvent{Action: "cont", Package: "a", Test: "TestA/3"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA/2"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA/3"})
+
+	testA := m.root.children[0].children[0]
+	assert.Equal(t, 3, testA.parallelDeclared)
+	assert.Equal(t, 2, testA.parallelMax)
+
+	var sb strings.Builder
+	m.printNode(testA, &sb)
+	assert.Contains(t, sb.String(), "parallel 2/3")
+}
+
+func TestStreamFailures(t *testing.T) {
+	keepFlags(t)
+	flags.streamFailures = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "=== RUN   TestA\n"},
+		{Action: "run", Package: "a", Test: "TestA/b"},
+		{Action: "output", Package: "a", Test: "TestA/b", Output: "    boom\n"},
+	}...)
+
+	prog := &fakeProgram{}
+	m.prog = prog
+	runCmd(m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA/b"}))
+
+	require.Len(t, prog.printed, 1, "the failure is printed as soon as the subtest fails")
+	header, output, _ := strings.Cut(prog.printed[0], "\n")
+	assert.Equal(t, iconFailed+" a TestA/b", header)
+	assert.Equal(t, "    boom", output)
+
+	// the streamed output isn't rolled up into the parent, so it isn't printed twice
+	testA := m.root.children[0].children[0]
+	assert.NotContains(t, testA.outputBuf.String(), "boom")
+}
+
+func TestSplitSubtests(t *testing.T) {
+	keepFlags(t)
+	flags.splitSubtests = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/b"},
+		{Action: "pass", Package: "a", Test: "TestA/b"},
+		{Action: "run", Package: "a", Test: "TestA/c"},
+		{Action: "run", Package: "a", Test: "TestA/c/d"},
+		{Action: "pass", Package: "a", Test: "TestA/c/d"},
+		{Action: "pass", Package: "a", Test: "TestA/c"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "pass", Package: "a", Test: "TestB"},
+	}...)
+
+	assert.Equal(t, 5, m.total)
+	assert.Contains(t, m.String(), "2 tests (3 subtests)")
+}
+
+func TestKeepLastFrame(t *testing.T) {
+	keepFlags(t)
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	frame := m.View()
+	assert.NotEmpty(t, frame)
+
+	m.Update(Done{})
+	assert.Empty(t, m.View())
+
+	flags.keepLastFrame = true
+	assert.Equal(t, frame, m.View())
+}
+
+func TestInline(t *testing.T) {
+	keepFlags(t)
+	flags.inline = 6
+
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	for i := range 20 {
+		m.processEvent(TestEvent{Action: "run", Package: "a", Test: fmt.Sprintf("Test%d", i)})
+	}
+
+	assert.Equal(t, 6, m.viewHeight())
+	lines := strings.Count(m.View(), "\n") + 1
+	assert.True(t, lines <= 6, "view has %d lines", lines)
+}
+
+func TestShowOutputFor(t *testing.T) {
+	keepFlags(t)
+
+	for _, tt := range []struct {
+		showOutputFor string
+		want          []string
+	}{
+		{"", []string{"failing output"}},
+		{"pass", []string{"passing output"}},
+		{"fail", []string{"failing output"}},
+		{"all", []string{"passing output", "failing output"}},
+		{"none", nil},
+	} {
+		flags.showOutputFor = tt.showOutputFor
+		m := newModel()
+		feed(m, []TestEvent{
+			{Action: "start", Package: "a"},
+			{Action: "run", Package: "a", Test: "TestA"},
+			{Action: "output", Package: "a", Test: "TestA", Output: "passing output\n"},
+			{Action: "pass", Package: "a", Test: "TestA"},
+			{Action: "run", Package: "a", Test: "TestB"},
+			{Action: "output", Package: "a", Test: "TestB", Output: "failing output\n"},
+			{Action: "fail", Package: "a", Test: "TestB"},
+		}...)
+
+		var out string
+		if buf := m.root.children[0].outputBuf; buf != nil {
+			out = buf.String()
+		}
+		for _, s := range []string{"passing output", "failing output"} {
+			if slices.Contains(tt.want, s) {
+				assert.Contains(t, out, s, "-show-output-for %q", tt.showOutputFor)
+			} else {
+				assert.NotContains(t, out, s, "-show-output-for %q", tt.showOutputFor)
+			}
+		}
+	}
+}
+
+func TestLowMemory(t *testing.T) {
+	keepFlags(t)
+	flags.lowMemory = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/sub"},
+		{Action: "pass", Package: "a", Test: "TestA/sub"},
+		{Action: "run", Package: "a", Test: "TestB"},
+	}...)
+
+	pkg := m.root.children[0]
+	testA, _ := pkg.findChild([]string{"TestA"})
+	require.NotNil(t, testA)
+	assert.Empty(t, testA.children, "passed tests are dropped as soon as they finish")
+
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestB"})
+
+	require.Len(t, pkg.children, 1)
+	assert.Equal(t, "TestB", pkg.children[0].name, "failed tests are kept")
+	assert.Equal(t, 3, m.total)
+	assert.Equal(t, 2, m.passes)
+}
+
+func TestLowMemoryCount(t *testing.T) {
+	keepFlags(t)
+	flags.lowMemory = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+	}...)
+
+	testA, _ := m.root.children[0].findChild([]string{"TestA"})
+	require.NotNil(t, testA)
+	assert.True(t, testA.flaky(), "the forgotten run should still count, with -count")
+	assert.Equal(t, "1/2 passed", testA.runsMsg())
+	assert.Equal(t, []*node{testA}, m.flaky)
+
+	// the counts of the tests which weren't run again are kept until the package
+	// finishes
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestB"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestB"})
+	assert.Len(t, m.forgotten["a"], 1)
+	m.processEvent(TestEvent{Action: "fail", Package: "a"})
+	assert.Empty(t, m.forgotten)
+}
+
+func TestPhases(t *testing.T) {
+	keepFlags(t)
+	clock := time.Now()
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	m := newModel()
+	clock = clock.Add(20 * time.Second)
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	clock = clock.Add(10 * time.Second)
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	clock = clock.Add(time.Minute)
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a"})
+	m.done = true
+
+	build, test, ok := m.phases()
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, build)
+	assert.Equal(t, time.Minute, test)
+	assert.Contains(t, m.String(), "PASSED 1 tests in 1m30s (build 30s, test 1m0s)")
+
+	flags.infile = "run.json"
+	_, _, ok = m.phases()
+	assert.False(t, ok, "files are read all at once")
+}
+
+// fakeProgram records what's printed above the live view.
+type fakeProgram struct {
+	printed []string
+}
+
+func (p *fakeProgram) Println(args ...any) {
+	p.printed = append(p.printed, fmt.Sprint(args...))
+}
+
+// captureStderr returns what f writes to stderr.
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	f()
+	require.NoError(t, w.Close())
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestFailuresToStderr(t *testing.T) {
+	keepFlags(t)
+	flags.failuresToStderr = true
+
+	events := []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "fail", Package: "a"},
+	}
+	run := func(m *model) string {
+		return captureStderr(t, func() {
+			for _, ev := range events {
+				runCmd(m.processEvent(ev))
+			}
+		})
+	}
+
+	m := newModel()
+	assert.Contains(t, run(m), "boom", "without the live view, failures are written right away")
+
+	m = newModel()
+	prog := &fakeProgram{}
+	m.prog = prog
+	assert.Empty(t, run(m), "writing to stderr would garble the live view")
+	assert.Empty(t, prog.printed)
+	assert.Contains(t, captureStderr(t, m.flushStderr), "boom")
+}
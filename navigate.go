@@ -0,0 +1,102 @@
+package main
+
+import (
+	"container/list"
+	"slices"
+)
+
+// The live view follows the run by default, eliding nodes to fit the window.  Once the
+// cursor is moved, the view scrolls to keep the cursor visible instead, and packages
+// and tests can be collapsed.  Press f to go back to following the run.  While following,
+// f cycles the filters, see liveFilter.
+
+// navNodes returns the nodes the cursor can move between, i.e. the nodes in the
+// live view before it's fit to the window.
+func (m *model) navNodes() []*node {
+	l := m.applyFilter(collectNodes(m.root.children))
+	if m.viewMode != viewTree {
+		l = packagesOnly(l)
+	}
+	return listNodes(l)
+}
+
+// moveCursor moves the cursor by delta rows.  If the view is following the run, the
+// cursor starts at the top when moving down, and at the bottom when moving up.
+func (m *model) moveCursor(delta int) {
+	nodes := m.navNodes()
+	if len(nodes) == 0 {
+		return
+	}
+	idx := slices.Index(nodes, m.cursor)
+	switch {
+	case idx < 0 && delta > 0:
+		idx = 0
+	case idx < 0:
+		idx = len(nodes) - 1
+	default:
+		idx += delta
+	}
+	m.cursorIdx = max(min(idx, len(nodes)-1), 0)
+	m.cursor = nodes[m.cursorIdx]
+}
+
+// setCollapsed collapses or expands the node at the cursor.  Collapsing a node which
+// is already collapsed, or has no children, moves the cursor to its parent.
+func (m *model) setCollapsed(collapsed bool) {
+	n := m.cursor
+	if n == nil {
+		return
+	}
+	if collapsed && (n.collapsed || len(n.children) == 0) {
+		if n.parent != nil && n.parent != &m.root {
+			m.cursor = n.parent
+		}
+		return
+	}
+	n.collapsed = collapsed
+}
+
+// scrollTo returns the rows of l which fit in height lines, scrolled so the cursor is
+// visible.  If the cursor's node was removed from the view, e.g. because it passed,
+// the cursor moves to the node which took its place.
+func (m *model) scrollTo(l *list.List, height int) *list.List {
+	nodes := listNodes(l)
+	if len(nodes) == 0 {
+		return l
+	}
+	idx := slices.Index(nodes, m.cursor)
+	if idx < 0 {
+		idx = max(min(m.cursorIdx, len(nodes)-1), 0)
+		m.cursor = nodes[idx]
+	}
+	m.cursorIdx = idx
+
+	height = max(height, 1)
+	if idx < m.scroll {
+		m.scroll = idx
+	}
+	if idx >= m.scroll+height {
+		m.scroll = idx - height + 1
+	}
+	m.scroll = max(min(m.scroll, len(nodes)-height), 0)
+
+	visible := list.New()
+	for _, n := range nodes[m.scroll:min(m.scroll+height, len(nodes))] {
+		visible.PushBack(n)
+	}
+	return visible
+}
+
+func listNodes(l *list.List) []*node {
+	nodes := make([]*node, 0, l.Len())
+	for _, n := range listSeq(l) {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// hidesChildren returns true if the node's children aren't shown, because it's
+// collapsed or at the -max-depth limit.
+func (n *node) hidesChildren() bool {
+	return n.collapsed || n.atMaxDepth()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var namedKeys = map[string]rune{
+	"up":    tea.KeyUp,
+	"down":  tea.KeyDown,
+	"left":  tea.KeyLeft,
+	"right": tea.KeyRight,
+	"home":  tea.KeyHome,
+	"end":   tea.KeyEnd,
+}
+
+func key(s string) tea.KeyMsg {
+	if code, ok := namedKeys[s]; ok {
+		return tea.KeyPressMsg{Code: code}
+	}
+	return tea.KeyPressMsg{Code: rune(s[0]), Text: s}
+}
+
+func TestNavigation(t *testing.T) {
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 7})
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	for i := range 10 {
+		m.processEvent(TestEvent{Action: "run", Package: "a", Test: fmt.Sprintf("Test%d", i)})
+	}
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "Test9/sub"})
+
+	// moving down starts at the top
+	m.Update(key("down"))
+	require.NotNil(t, m.cursor)
+	assert.Equal(t, "a", m.cursor.name)
+	assert.True(t, strings.HasPrefix(m.View(), "› "))
+
+	// scrolls to keep the cursor visible
+	m.Update(key("end"))
+	assert.Equal(t, "sub", m.cursor.name)
+	assert.Contains(t, m.View(), "sub")
+	assert.NotContains(t, m.View(), "Test0")
+
+	// left on a leaf moves to the parent, then collapses it
+	m.Update(key("left"))
+	assert.Equal(t, "Test9", m.cursor.name)
+	m.Update(key("left"))
+	assert.True(t, m.cursor.collapsed)
+	assert.NotContains(t, m.View(), " sub\t")
+	assert.Contains(t, m.View(), "+1 subtests")
+	m.Update(key("right"))
+	assert.Len(t, m.navNodes(), 12)
+
+	// the cursor stays on the same row when its node is removed from the view
+	m.Update(key("up"))
+	assert.Equal(t, "Test8", m.cursor.name)
+	m.root.children[0].collapsed = true
+	m.View()
+	assert.Equal(t, "a", m.cursor.name)
+
+	// actions apply to the test at the cursor
+	assert.Nil(t, m.selected(), "packages have no actions")
+	m.Update(key("right"))
+	m.Update(key("down"))
+	assert.Equal(t, "Test0", m.selected().name)
+
+	m.Update(key("f"))
+	assert.Nil(t, m.cursor)
+	assert.False(t, strings.HasPrefix(m.View(), "› "))
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// With -new-tests, the top level tests which were added in the working tree, i.e.
+// whose functions are in the uncommitted changes to _test.go files, are tagged
+// "new" in the tree, and listed in the summary, so reviewers can see that the new
+// tests ran and passed.  Subtests can't be told apart in the source, so only the
+// top level tests are tagged.
+
+// testFuncPattern matches the declaration of a test, benchmark, fuzz test, or example.
+var testFuncPattern = regexp.MustCompile(`^func ((?:Test|Benchmark|Fuzz|Example)\w*)\(`)
+
+// newTests are the tests added in the working tree, by package import path and
+// test name.
+type newTests map[string]map[string]bool
+
+func (t newTests) has(pkg, test string) bool {
+	return t[pkg][test]
+}
+
+func (t newTests) add(pkg, test string) {
+	if t[pkg] == nil {
+		t[pkg] = map[string]bool{}
+	}
+	t[pkg][test] = true
+}
+
+// parseNewTests returns the test functions which a diff of _test.go files adds.
+// Functions which are also removed were only moved or changed, and aren't new.
+func parseNewTests(r io.Reader, module string) (newTests, error) {
+	added, removed := newTests{}, newTests{}
+	var pkg string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			// the file's package, after the change
+			pkg = ""
+			if file := strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/"); strings.HasSuffix(file, "_test.go") {
+				pkg = packagePath(module, file)
+			}
+		case strings.HasPrefix(line, "--- "):
+			continue
+		case strings.HasPrefix(line, "+") && pkg != "":
+			if matches := testFuncPattern.FindStringSubmatch(line[1:]); matches != nil {
+				added.add(pkg, matches[1])
+			}
+		case strings.HasPrefix(line, "-") && pkg != "":
+			if matches := testFuncPattern.FindStringSubmatch(line[1:]); matches != nil {
+				removed.add(pkg, matches[1])
+			}
+		}
+	}
+	for pkg, tests := range added {
+		for test := range tests {
+			if removed.has(pkg, test) {
+				delete(tests, test)
+			}
+		}
+	}
+	return added, s.Err()
+}
+
+// packagePath returns the import path of the package of a module relative file.
+func packagePath(module, file string) string {
+	if dir := path.Dir(file); dir != "." {
+		return module + "/" + dir
+	}
+	return module
+}
+
+// gitNewTests returns the tests added in the module at root since HEAD, including
+// those in untracked test files.
+func gitNewTests(root, module string) (newTests, error) {
+	out, err := git(root, "diff", "-U0", "--no-color", "--no-ext-diff", "--relative", "HEAD", "--", "*_test.go")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(root, "ls-files", "--others", "--exclude-standard", "--", "*_test.go")
+	if err != nil {
+		return nil, err
+	}
+	// untracked files are added in their entirety
+	var diff bytes.Buffer
+	diff.Write(out)
+	for _, file := range strings.Fields(string(untracked)) {
+		b, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&diff, "+++ b/%s\n", file)
+		for _, line := range strings.Split(string(b), "\n") {
+			diff.WriteString("+" + line + "\n")
+		}
+	}
+	return parseNewTests(&diff, module)
+}
+
+// git runs a git command in dir, and returns its output.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// loadNewTests finds the tests added in the working tree, for -new-tests.
+func (m *model) loadNewTests() error {
+	root, module := findModule(".")
+	if m.paths != nil && m.paths.module != "" {
+		root, module = m.paths.root, m.paths.module
+	}
+	if module == "" {
+		return fmt.Errorf("no go.mod found")
+	}
+	t, err := gitNewTests(root, module)
+	if err != nil {
+		return err
+	}
+	m.newTests = t
+	return nil
+}
+
+// markNew tags a top level test if it's new, and keeps track of it for the summary.
+func (m *model) markNew(n *node) {
+	if n.lvl != 2 || !m.newTests.has(n.pkg().name, n.name) {
+		return
+	}
+	n.isNew = true
+	m.added = append(m.added, n)
+}
+
+// renderNewTests lists the results of the new tests, and the new tests in the
+// run's packages which didn't run at all.
+func renderNewTests(m *model) string {
+	if len(m.newTests) == 0 {
+		return ""
+	}
+	ran := newTests{}
+	var sb strings.Builder
+	for _, n := range m.added {
+		ran.add(n.pkg().name, n.name)
+		fmt.Fprintf(&sb, "  %s %s\t%s\t%s\n", m.icon(n), displayName(n.name), formatElapsed(n.elapsed, 0, 3), gray.Render(n.pkg().name))
+	}
+	for _, pkg := range m.root.children {
+		for _, test := range slices.Sorted(maps.Keys(m.newTests[pkg.name])) {
+			if !ran.has(pkg.name, test) {
+				fmt.Fprintf(&sb, "  %s %s\t%s\t%s\n", iconSkipped, test, failedText.Render("didn't run"), gray.Render(pkg.name))
+			}
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "New tests:\n" + sb.String()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const newTestsDiff = `diff --git a/a/a_test.go b/a/a_test.go
+--- a/a/a_test.go
++++ b/a/a_test.go
+@@ -10,0 +11,4 @@ func TestOld(t *testing.T) {
++func TestAdded(t *testing.T) {
++}
++func FuzzAdded(f *testing.F) {
++}
+@@ -20 +24 @@
+-func TestRenamed(t *testing.T) {
++func TestMoved(t *testing.T) {
+diff --git a/b_test.go b/b_test.go
+--- a/b_test.go
++++ b/b_test.go
+@@ -1 +1 @@
+-func TestMoved(t *testing.T) {
++func TestRoot(t *testing.T) {
+diff --git a/a/helper.go b/a/helper.go
+--- a/a/helper.go
++++ b/a/helper.go
+@@ -1 +1 @@
++func TestNotATest(t *testing.T) {
+`
+
+func TestParseNewTests(t *testing.T) {
+	tests, err := parseNewTests(strings.NewReader(newTestsDiff), "example.com/mod")
+	require.NoError(t, err)
+	assert.Equal(t, newTests{
+		"example.com/mod/a": {"TestAdded": true, "FuzzAdded": true, "TestMoved": true},
+		"example.com/mod":   {"TestRoot": true},
+	}, tests)
+}
+
+func TestNewTestsSection(t *testing.T) {
+	keepFlags(t)
+	flags.summaryJSON = "-"
+
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	m.newTests = newTests{"a": {"TestAdded": true, "TestNotRun": true}}
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestOld"},
+		{Action: "pass", Package: "a", Test: "TestOld"},
+		{Action: "run", Package: "a", Test: "TestAdded"},
+		{Action: "run", Package: "a", Test: "TestAdded/sub"},
+	}...)
+	added, _ := m.root.children[0].findChild([]string{"TestAdded"})
+	require.NotNil(t, added)
+	assert.True(t, added.isNew)
+	assert.False(t, added.children[0].isNew, "only top level tests are tagged")
+	assert.Contains(t, m.View(), "TestAdded\t\tnew")
+
+	feed(m, []TestEvent{
+		{Action: "pass", Package: "a", Test: "TestAdded/sub"},
+		{Action: "pass", Package: "a", Test: "TestAdded", Elapsed: 1.5},
+		{Action: "pass", Package: "a"},
+	}...)
+	assert.Equal(t, "New tests:\n  "+iconPassed+" TestAdded\t1.5s\ta\n  "+iconSkipped+" TestNotRun\tdidn't run\ta\n", renderNewTests(m))
+	assert.True(t, m.summary().Packages[0].Tests[0].New)
+}
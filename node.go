@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
 	"slices"
 	"strings"
@@ -22,11 +23,121 @@ type node struct {
 	isTest     bool
 	lvl        int
 	msg        string
+	// pausedAt is when the test was paused, if it's currently paused, and waiting is
+	// the total time it has spent paused.  Paused time isn't included in elapsed.
+	pausedAt time.Time
+	waiting  time.Duration
+	// parallel is set on tests which called t.Parallel().  parallelDeclared counts
+	// the children which called t.Parallel(), and parallelMax is the most of those
+	// which actually ran at the same time.
+	parallel         bool
+	parallelDeclared int
+	parallelMax      int
+	// parallelCount is the number of tests which finished in this package, and called
+	// t.Parallel().  Only set on package nodes.  parallelTally counts the same for a
+	// top level test and its subtests, so they can be uncounted if the test is re-run.
+	parallelCount int
+	parallelTally int
+	// testCount is the number of tests which finished in this package.  Only
+	// tracked on package nodes.
+	testCount int
+	// setup is the estimated time it took to compile and start the package, i.e. the time between
+	// the start of the run and the package's first event.  Only set on package nodes.
+	setup time.Duration
+	// startOffset is when the node started, relative to the start of the run
+	startOffset time.Duration
+	// cached is set on package nodes whose results came from the test cache, and
+	// testElapsed is the sum of the package's top level test durations, used to
+	// estimate how much time the cache saved.
+	cached      bool
+	testElapsed time.Duration
+	// lastTs is the timestamp of the package's most recent event.  Only set on
+	// package nodes.
+	lastTs time.Time
+	// env is the environment the test reported in its first output line, and
+	// sawOutput is set once the first line has been checked.  See parseEnvLine.
+	env       []string
+	sawOutput bool
+	// testsStarted is set on package nodes once the first test event is seen.
+	// Until then, the package is still building or queued.
+	testsStarted bool
+	// dumping are the package's possibly hung tests when their goroutines were
+	// dumped, which the package's output is attached to, see dumpStuck
+	dumping []*node
+	// pinned packages are sorted to the top of the view, and elided last
+	pinned bool
+	// signature identifies a failure across runs, and annotation is the user's
+	// triage note for it.  Only set on failed tests.
+	signature  string
+	annotation string
+	// log is a copy of the node's output, and dropped holds the children removed
+	// by processChildren.  Both are only retained when writing reports, except the
+	// output of failed tests, which is always kept.
+	log     string
+	dropped []*node
+	// tally counts the results of a top level test and its subtests, so they can
+	// be uncounted if the test is re-run.
+	tally map[string]int
+	// coverage is the package's reported coverage percent, if hasCoverage.
+	// minCoverage is set when the package is below its -min-coverage threshold.
+	coverage    float64
+	hasCoverage bool
+	minCoverage float64
+	// dots are the most recent test results in the package, one character per
+	// test, for the dots view.  Only set on package nodes.
+	dots []string
+	// skipCause is the failed test which caused this test to skip, and causedSkips
+	// counts the skips a failed test caused.  lastFailed is the most recent failed
+	// test in a package.  See linkSkip.
+	skipCause   *node
+	causedSkips int
+	lastFailed  *node
+	// similar are the other failed tests with the same error, see linkSimilar
+	similar []*node
+	// collapsed nodes' children are hidden in the view
+	collapsed bool
+	// meta holds the extra fields of the node's events, see -extra-fields
+	meta map[string]string
+	// passedRuns and failedRuns count the results of a test which is run more than
+	// once in the same stream, e.g. with -count.
+	passedRuns int
+	failedRuns int
+	// preexisting is set on failed tests which don't run the changed lines, see
+	// analyzeImpact
+	preexisting bool
+	// buildFailure is set on packages which failed to build, or failed vet, e.g.
+	// "build failed"
+	buildFailure string
+	// crash is set when the node's output has a panic, a fatal error, or a data
+	// race report, e.g. "PANIC", see detectCrash
+	crash string
+	// bench is a benchmark's result, once it's reported
+	bench *benchResult
+	// baseline is the test's usual duration, from previous runs, see -timings
+	baseline time.Duration
+	// outputLines counts the lines in outputBuf, and overflow holds the output past
+	// -max-output-lines or -max-output-bytes, see limitOutput
+	outputLines int
+	overflow    *overflow
+	// isNew is set on top level tests which were added in the working tree, see
+	// -new-tests
+	isNew bool
+	// xpass is set on tests which passed, but were expected to fail, see -expect-fail
+	xpass bool
+	// rate counts the output for -max-output-rate
+	rate *rateWindow
+	// shuffle is the package's -shuffle seed
+	shuffle string
+	// fuzz is a fuzz target's latest status, see fuzzOutput
+	fuzz *fuzzStatus
+	// stream labels the input stream a package was read from, when -f is repeated
+	stream string
 }
 
 var packageSummaryPattern = regexp.MustCompile(`^(.{4})?\t\S+(\t[msh\d\.]*)?(\s(.*))?\n`)
 
 func (n *node) output(s string) {
+	n.detectCrash(s)
 	if n.lvl == 1 {
 		matches := packageSummaryPattern.FindStringSubmatch(s)
 
@@ -34,6 +145,10 @@ func (n *node) output(s string) {
 		case len(matches) == 5:
 			// set node message, then skip
 			n.msg = matches[4]
+			n.cached = strings.Contains(n.msg, "(cached)")
+		}
+		if c, ok := parseCoverage(s); ok {
+			n.coverage, n.hasCoverage = c, true
 		}
 
 		n.append(s)
@@ -50,11 +165,122 @@ func (n *node) output(s string) {
 		n.prepend(s)
 		return
 	}
+	if !n.sawOutput {
+		n.sawOutput = true
+		n.env = parseEnvLine(s)
+	}
 
 	n.append(s)
 }
 
+// envLinePattern matches the conventional first output line tests can use to report the
+// environment they ran with, e.g. "ENV: FOO=bar BAZ=qux".  The line may be prefixed with
+// the file and line number added by t.Log.
+var envLinePattern = regexp.MustCompile(`^\s*(?:\S+\.go:\d+: )?ENV:\s*(.*)$`)
+
+// parseEnvLine returns the KEY=value pairs in an ENV: line, or nil if it isn't
+// an ENV: line.
+func parseEnvLine(s string) []string {
+	matches := envLinePattern.FindStringSubmatch(strings.TrimRight(s, "\n"))
+	if matches == nil {
+		return nil
+	}
+	var env []string
+	for _, f := range strings.Fields(matches[1]) {
+		if strings.Contains(f, "=") {
+			env = append(env, f)
+		}
+	}
+	return env
+}
+
+// pkg returns the package node this node belongs to.
+func (n *node) pkg() *node {
+	for n.lvl > 1 && n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+// waitingTime returns the total time the test has spent paused, including the
+// current pause.
+func (n *node) waitingTime() time.Duration {
+	if n.pausedAt.IsZero() {
+		return n.waiting
+	}
+	return n.waiting + scaledTimeSince(n.pausedAt)
+}
+
+// runningParallel returns the number of parallel children which are currently
+// running, i.e. not waiting or finished.
+func (n *node) runningParallel() int {
+	var running int
+	for _, c := range n.children {
+		if c.parallel && !c.done && c.pausedAt.IsZero() {
+			running++
+		}
+	}
+	return running
+}
+
+// atMaxDepth returns true if this node is at the -max-depth limit, so its
+// children should not be displayed.  The package is level 0, top level tests
+// are level 1.
+func (n *node) atMaxDepth() bool {
+	return flags.maxDepth > 0 && n.lvl-1 >= flags.maxDepth
+}
+
+// descendantCounts returns the number of descendants, and how many of those failed.
+func (n *node) descendantCounts() (total, failed int) {
+	for _, c := range n.children {
+		t, f := c.descendantCounts()
+		total += t + 1
+		failed += f
+		if c.status == "fail" {
+			failed++
+		}
+	}
+	return total, failed
+}
+
+// runs returns the number of times the test has passed or failed.
+func (n *node) runs() int {
+	return n.passedRuns + n.failedRuns
+}
+
+// flaky is true if the test both passed and failed across multiple runs.
+func (n *node) flaky() bool {
+	return n.passedRuns > 0 && n.failedRuns > 0
+}
+
+// nextRun resets a finished test when it's run again, e.g. with -count, keeping
+// the results of its previous runs.
+func (n *node) nextRun() {
+	n.done = false
+	n.doneTs = time.Time{}
+	n.elapsed = 0
+	n.start = now()
+	n.pausedAt = time.Time{}
+	n.waiting = 0
+}
+
+// runsMsg describes the results of a test which ran more than once, e.g. "2/3 passed".
+func (n *node) runsMsg() string {
+	if n.runs() < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d passed", n.passedRuns, n.runs())
+}
+
 func (n *node) append(s string) {
+	if n.limitOutput(s) {
+		return
+	}
+	n.appendUnlimited(s)
+}
+
+// appendUnlimited appends s to the node's buffer, regardless of -max-output-lines.
+func (n *node) appendUnlimited(s string) {
 	if n.outputBuf == nil {
 		n.outputBuf = bytes.NewBufferString(s)
 	} else {
@@ -108,8 +334,13 @@ func (n *node) processChildren(final, recurse bool) {
 		// droppable nodes should have been sorted to the end.
 		for i := len(s) - 1; i >= 0; i-- {
 			if drop(s[i]) {
-				s[i].msg = "dropped" // debugging, should never be seen, if it is, something is wrong
-				s[i] = nil           // blank ref to ensure gc
+				if reporting() {
+					// kept for the reports, e.g. in snapshots, so it isn't marked
+					n.dropped = append(n.dropped, s[i])
+				} else {
+					s[i].msg = "dropped" // debugging, should never be seen, if it is, something is wrong
+				}
+				s[i] = nil // blank ref to ensure gc
 				s = s[:i]
 			}
 		}
@@ -39,3 +39,31 @@ ok  	github.com/golang-migrate/migrate/v4/dktesting	0.230s [no tests to run]
 		assert.Equal(t, out[i], n.msg, "incorrect output for line %v: %v", i, line)
 	}
 }
+
+func TestEnvLine(t *testing.T) {
+	tests := map[string][]string{
+		"ENV: FOO=bar BAZ=qux\n":                           {"FOO=bar", "BAZ=qux"},
+		"    db_test.go:12: ENV: DB=postgres VERSION=16\n": {"DB=postgres", "VERSION=16"},
+		"ENV:\n":                 {},
+		"hello ENV: FOO=bar\n":   nil,
+		"ENVIRONMENT: FOO=bar\n": nil,
+	}
+	for line, expected := range tests {
+		env := parseEnvLine(line)
+		if expected == nil {
+			assert.Nil(t, env, line)
+		} else {
+			assert.Equal(t, len(expected), len(env), line)
+			for i := range expected {
+				assert.Equal(t, expected[i], env[i], line)
+			}
+		}
+	}
+
+	// only the first output line is checked
+	n := &node{lvl: 2}
+	n.output("=== RUN   TestA\n")
+	n.output("ENV: FOO=bar\n")
+	n.output("ENV: FOO=baz\n")
+	assert.Equal(t, []string{"FOO=bar"}, n.env)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notification returns the title and body of the desktop notification sent by
+// -notify when the run finishes, e.g. "Tests FAILED" and "120 tests, 2 failed in 3m4s".
+func notification(s summary) (title, body string) {
+	title = "Tests " + strings.ToUpper(s.Result) + "ED"
+	if s.Aborted > 0 {
+		title = "Tests ABORTED"
+	}
+	body = fmt.Sprintf("%d tests", s.Tests)
+	if s.Passed > 0 {
+		body += fmt.Sprintf(", %d passed", s.Passed)
+	}
+	if s.Failed > 0 {
+		body += fmt.Sprintf(", %d failed", s.Failed)
+	}
+	if s.Skipped > 0 {
+		body += fmt.Sprintf(", %d skipped", s.Skipped)
+	}
+	body += fmt.Sprintf(" in %s", round(secondsDuration(s.Elapsed), 1))
+	return title, body
+}
+
+// notifier returns the command which shows a desktop notification, or nil if the
+// platform has no notifier installed.
+func notifier(title, body string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString("gotestpretty: "+title))
+		return exec.Command("osascript", "-e", script)
+	case "windows":
+		return nil
+	}
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		return exec.Command("notify-send", "--app-name", "gotestpretty", title, body)
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// notifyFinished sends the desktop notification for -notify.
+func notifyFinished(m *model) error {
+	cmd := notifier(notification(m.summary()))
+	if cmd == nil {
+		return fmt.Errorf("desktop notifications aren't supported on %s, or notify-send isn't installed", runtime.GOOS)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
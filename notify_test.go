@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotification(t *testing.T) {
+	title, body := notification(summary{Result: "fail", Tests: 12, Passed: 9, Failed: 2, Skipped: 1, Elapsed: 184})
+	assert.Equal(t, "Tests FAILED", title)
+	assert.Equal(t, "12 tests, 9 passed, 2 failed, 1 skipped in 3m4s", body)
+
+	title, body = notification(summary{Result: "pass", Tests: 3, Passed: 3, Elapsed: 0.5})
+	assert.Equal(t, "Tests PASSED", title)
+	assert.Equal(t, "3 tests, 3 passed in 500ms", body)
+
+	title, _ = notification(summary{Result: "fail", Aborted: 1})
+	assert.Equal(t, "Tests ABORTED", title)
+}
+
+func TestAppleScriptString(t *testing.T) {
+	assert.Equal(t, `"say \"hi\" C:\\dir"`, appleScriptString(`say "hi" C:\dir`))
+}
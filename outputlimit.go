@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// With -max-output-lines or -max-output-bytes, each node buffers at most that much
+// of its output.  The first half of the limit is kept, followed by a rolling tail
+// of the most recent output, since a failure's cause is usually near the start or
+// the end.  The output in between is omitted, replaced by a marker, or with
+// -spill-output, written to a temp file the marker points to.
+
+// overflow is the output of a node which reached the limit.
+type overflow struct {
+	// tail is the most recent output, and size is its length in bytes
+	tail []string
+	size int
+	// omitted counts the lines dropped from the tail, and spill is the temp file
+	// they're written to, with -spill-output
+	omitted int
+	spill   *os.File
+}
+
+// limitOutput returns true if s doesn't fit in the node's buffer, in which case
+// it's added to the tail instead.
+func (n *node) limitOutput(s string) bool {
+	if n.overflow == nil && !n.outputFull(s) {
+		n.outputLines++
+		return false
+	}
+	if n.overflow == nil {
+		n.overflow = &overflow{}
+	}
+	o := n.overflow
+	o.tail = append(o.tail, s)
+	o.size += len(s)
+	for len(o.tail) > 1 && o.overLimit() {
+		o.omit(n, o.tail[0])
+		o.size -= len(o.tail[0])
+		o.tail[0] = ""
+		o.tail = o.tail[1:]
+	}
+	return true
+}
+
+// outputFull returns true if adding s would take the buffered output past half
+// of the limits.
+func (n *node) outputFull(s string) bool {
+	if flags.maxOutputLines > 0 && n.outputLines >= flags.maxOutputLines/2 {
+		return true
+	}
+	if flags.maxOutputBytes > 0 && n.outputBuf != nil && n.outputBuf.Len()+len(s) > flags.maxOutputBytes/2 {
+		return true
+	}
+	return false
+}
+
+// overLimit returns true if the tail is longer than the rest of the limits.
+func (o *overflow) overLimit() bool {
+	if flags.maxOutputLines > 0 && len(o.tail) > flags.maxOutputLines-flags.maxOutputLines/2 {
+		return true
+	}
+	return flags.maxOutputBytes > 0 && o.size > flags.maxOutputBytes-flags.maxOutputBytes/2
+}
+
+// omit drops a line from the tail, spilling it to a temp file with -spill-output.
+func (o *overflow) omit(n *node, s string) {
+	o.omitted += max(strings.Count(s, "\n"), 1)
+	if !flags.spillOutput {
+		return
+	}
+	if o.spill == nil {
+		f, err := os.CreateTemp("", "gotestpretty-output-*.log")
+		if err != nil {
+			log.Println("spilling output of", n.name, "failed:", err)
+			flags.spillOutput = false
+			return
+		}
+		o.spill = f
+	}
+	if _, err := o.spill.WriteString(s); err != nil {
+		log.Println("spilling output of", n.name, "failed:", err)
+	}
+}
+
+// flushOutput appends the omitted marker and the tail to the node's buffer, once
+// the node has finished.
+func (n *node) flushOutput() {
+	o := n.overflow
+	if o == nil {
+		return
+	}
+	n.overflow = nil
+	marker := fmt.Sprintf("... %d lines omitted ...\n", o.omitted)
+	if o.spill != nil {
+		marker = fmt.Sprintf("... %d lines omitted, see %s ...\n", o.omitted, o.spill.Name())
+		_ = o.spill.Close()
+	}
+	if o.omitted > 0 {
+		n.appendUnlimited(marker)
+	}
+	for _, s := range o.tail {
+		n.appendUnlimited(s)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxOutputLines(t *testing.T) {
+	keepFlags(t)
+	flags.maxOutputLines = 4
+
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	for i := range 10 {
+		m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestA", Output: fmt.Sprintf("line %d\n", i)})
+	}
+	n := m.root.children[0].children[0]
+	assert.Equal(t, "line 0\nline 1\n", n.outputBuf.String(), "the first half of the limit is buffered")
+	assert.Equal(t, []string{"line 8\n", "line 9\n"}, n.overflow.tail)
+
+	m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestA", Output: "--- FAIL: TestA\n"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA"})
+	assert.Equal(t, "--- FAIL: TestA\nline 0\nline 1\n... 6 lines omitted ...\nline 8\nline 9\n", n.log)
+	assert.Nil(t, n.overflow)
+}
+
+func TestMaxOutputBytesSpill(t *testing.T) {
+	keepFlags(t)
+	flags.maxOutputBytes, flags.spillOutput = 30, true
+
+	n := &node{name: "TestA", lvl: 2, isTest: true}
+	for i := range 10 {
+		n.output(fmt.Sprintf("line %d\n", i))
+	}
+	n.flushOutput()
+
+	matches := regexp.MustCompile(`(?m)^\.\.\. 6 lines omitted, see (.*) \.\.\.$`).FindStringSubmatch(n.outputBuf.String())
+	require.NotNil(t, matches, n.outputBuf.String())
+	defer os.Remove(matches[1])
+	assert.Regexp(t, `^line 0\nline 1\n\.\.\..*\nline 8\nline 9\n$`, n.outputBuf.String())
+	b, err := os.ReadFile(matches[1])
+	require.NoError(t, err)
+	assert.Equal(t, "line 2\nline 3\nline 4\nline 5\nline 6\nline 7\n", string(b))
+}
+
+func TestOutputUnlimited(t *testing.T) {
+	n := &node{name: "TestA", lvl: 2, isTest: true}
+	for i := range 1000 {
+		n.output(fmt.Sprintf("line %d\n", i))
+	}
+	n.flushOutput()
+	assert.Nil(t, n.overflow)
+	assert.Equal(t, 1000, n.outputLines)
+}
+
+func TestByteCountDecimal(t *testing.T) {
+	for _, tt := range []struct {
+		bytes int
+		want  string
+	}{
+		{999, "999B"},
+		{1000, "1.0kB"},
+		{1500, "1.5kB"},
+		{1_400_000, "1.4MB"},
+		{2_300_000_000, "2.3GB"},
+	} {
+		assert.Equal(t, tt.want, byteCountDecimal(tt.bytes))
+	}
+}
+
+func TestBufferedOutputSize(t *testing.T) {
+	keepFlags(t)
+	start := time.Now()
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+	flags.slowThreshold = time.Second
+
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestChatty"})
+	for range 1000 {
+		m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestChatty", Output: strings.Repeat("x", 1399) + "\n"})
+	}
+	assert.NotContains(t, m.render(false), "MB output", "only shown once the test is slow")
+
+	now = func() time.Time { return start.Add(2 * time.Second) }
+	assert.Contains(t, m.render(false), "1.4MB output")
+
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestChatty"})
+	assert.NotContains(t, m.render(false), "MB output", "not shown once the test finishes")
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/viewport"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// pager shows the output of failed tests in a scrollable viewport, with search,
+// instead of the test tree.  Long output, like goroutine dumps, is hard to read once
+// it's been printed to the scrollback.
+type pager struct {
+	failures []*node
+	current  int
+	viewport viewport.Model
+	// lines are the failure's output, and styled are the same lines, with
+	// highlighted diffs and stack traces
+	lines  []string
+	styled []string
+	// searching is set while the query is being typed, and matches are the
+	// lines matching it
+	searching bool
+	query     string
+	matches   []int
+	match     int
+}
+
+// newPager returns a pager showing the failures, starting with the one at index current.
+func newPager(failures []*node, current, width, height int) *pager {
+	p := &pager{failures: failures, viewport: viewport.New(width, max(height-1, 1))}
+	p.show(current)
+	return p
+}
+
+// show switches to the failure at index i.
+func (p *pager) show(i int) {
+	p.current = i
+	n := p.failures[i]
+	output := strings.TrimRight(n.log, "\n")
+	p.lines = strings.Split(output, "\n")
+	p.styled = strings.Split(highlightStacks(highlightDiffs(explainSynctest(output)), n.pkg().name), "\n")
+	p.matches, p.match = nil, 0
+	p.render()
+	p.viewport.GotoTop()
+	p.search()
+}
+
+func (p *pager) resize(width, height int) {
+	p.viewport.Width, p.viewport.Height = width, max(height-1, 1)
+}
+
+// render sets the viewport's content, with the matches for the query highlighted.
+// While searching, the other highlighting is left off, so it can't be broken up.
+func (p *pager) render() {
+	lines := p.styled
+	if p.query != "" {
+		lines = make([]string, len(p.lines))
+		for i, l := range p.lines {
+			lines[i] = strings.ReplaceAll(l, p.query, diffHighlight.Render(p.query))
+		}
+	}
+	p.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// search finds the lines matching the query, and scrolls to the first one.
+func (p *pager) search() {
+	p.matches, p.match = nil, 0
+	if p.query == "" {
+		return
+	}
+	for i, l := range p.lines {
+		if strings.Contains(l, p.query) {
+			p.matches = append(p.matches, i)
+		}
+	}
+	p.render()
+	if len(p.matches) > 0 {
+		p.viewport.SetYOffset(p.matches[0])
+	}
+}
+
+// nextMatch scrolls to the next, or with delta -1, the previous match.
+func (p *pager) nextMatch(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.match = (p.match + delta + len(p.matches)) % len(p.matches)
+	p.viewport.SetYOffset(p.matches[p.match])
+}
+
+// update handles a key press.  Returns true when the pager is closed.
+func (p *pager) update(msg tea.KeyMsg) bool {
+	if p.searching {
+		switch key := msg.String(); key {
+		case "enter":
+			p.searching = false
+			p.search()
+		case "esc":
+			p.searching = false
+			p.query = ""
+			p.search()
+			p.render()
+		case "backspace":
+			if p.query != "" {
+				p.query = p.query[:len(p.query)-1]
+			}
+		case "space":
+			p.query += " "
+		default:
+			if len([]rune(key)) == 1 {
+				p.query += key
+			}
+		}
+		return false
+	}
+
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return true
+	case "/":
+		p.searching = true
+		p.query = ""
+	case "n":
+		p.nextMatch(1)
+	case "N":
+		p.nextMatch(-1)
+	case "]":
+		p.show((p.current + 1) % len(p.failures))
+	case "[":
+		p.show((p.current - 1 + len(p.failures)) % len(p.failures))
+	case "g", "home":
+		p.viewport.GotoTop()
+	case "G", "end":
+		p.viewport.GotoBottom()
+	default:
+		p.viewport, _ = p.viewport.Update(msg)
+	}
+	return false
+}
+
+func (p *pager) view() string {
+	n := p.failures[p.current]
+	header := fmt.Sprintf("%s %s %s  [%d/%d]", iconFailed, n.pkg().name, n.testName(), p.current+1, len(p.failures))
+	help := "q close  / search  n/N next/prev match  ]/[ next/prev failure"
+	switch {
+	case p.searching:
+		help = "/" + p.query
+	case p.query != "":
+		help = fmt.Sprintf("%q: %d matches  ", p.query, len(p.matches)) + help
+	}
+	return header + "  " + gray.Render(help) + "\n" + p.viewport.View()
+}
+
+// openPager opens the pager on the failed test n, or the first failure if n is nil.
+// Returns false if there are no failures to show.
+func (m *model) openPager(n *node) bool {
+	var failures []*node
+	for _, f := range m.failures() {
+		// tests which only failed because a subtest failed just repeat its output
+		if f.log != "" && !f.hasFailedChild() {
+			failures = append(failures, f)
+		}
+	}
+	if len(failures) == 0 {
+		return false
+	}
+	current := 0
+	if n != nil {
+		current = -1
+		for i, f := range failures {
+			if f == n {
+				current = i
+			}
+		}
+		if current < 0 {
+			return false
+		}
+	}
+	m.pager = newPager(failures, current, m.windowWidth, m.viewHeight())
+	return true
+}
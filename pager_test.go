@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPager(t *testing.T) {
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 6})
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	for _, name := range []string{"TestOne", "TestTwo"} {
+		m.processEvent(TestEvent{Action: "run", Package: "a", Test: name})
+		for i := range 20 {
+			m.processEvent(TestEvent{Action: "output", Package: "a", Test: name, Output: fmt.Sprintf("%s line %d\n", name, i)})
+		}
+		m.processEvent(TestEvent{Action: "fail", Package: "a", Test: name})
+	}
+
+	// enter on a failure opens the pager on it
+	m.Update(key("down"))
+	m.Update(key("down"))
+	require.Equal(t, "TestOne", m.cursor.name)
+	m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	require.NotNil(t, m.pager)
+	assert.Contains(t, m.View(), "TestOne  [1/2]")
+	assert.Contains(t, m.View(), "TestOne line 0")
+	assert.NotContains(t, m.View(), "TestOne line 19")
+
+	m.Update(key("G"))
+	assert.Contains(t, m.View(), "TestOne line 19")
+
+	// switch between failures
+	m.Update(key("]"))
+	assert.Contains(t, m.View(), "TestTwo  [2/2]")
+	assert.Contains(t, m.View(), "TestTwo line 0")
+	m.Update(key("]"))
+	assert.Contains(t, m.View(), "TestOne  [1/2]")
+
+	// search
+	m.Update(key("/"))
+	for _, r := range "line 1" {
+		m.Update(key(string(r)))
+	}
+	assert.Contains(t, m.View(), "/line 1")
+	m.Update(tea.KeyPressMsg{Code: tea.KeyBackspace})
+	m.Update(key("1"))
+	m.Update(key("2"))
+	m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	assert.Equal(t, []int{12}, m.pager.matches)
+	assert.Contains(t, m.View(), `"line 12": 1 matches`)
+	assert.True(t, strings.HasPrefix(strings.Split(m.View(), "\n")[1], "TestOne "), "scrolled to the match")
+
+	// closing returns to the tree
+	m.Update(key("q"))
+	assert.Nil(t, m.pager)
+	assert.False(t, m.done)
+	assert.Contains(t, m.View(), "TestTwo")
+}
+
+func TestOpenPagerSkipsParents(t *testing.T) {
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA/sub"})
+	m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestA/sub", Output: "boom\n"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA/sub"})
+	m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestA", Output: "--- FAIL: TestA\n"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA"})
+
+	require.True(t, m.openPager(nil))
+	assert.Len(t, m.pager.failures, 1)
+	assert.Equal(t, "sub", m.pager.failures[0].name)
+
+	m = newModel()
+	assert.False(t, m.openPager(nil), "no failures")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// parallelPct returns the percent of the tests which called t.Parallel().
+func parallelPct(parallel, tests int) float64 {
+	if tests == 0 {
+		return 0
+	}
+	return 100 * float64(parallel) / float64(tests)
+}
+
+// renderParallel reports how many of each package's tests called t.Parallel(),
+// as a measurable target for speeding up the suite.  Not in the default sections.
+func renderParallel(m *model) string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	var parallel, tests int
+	for _, pkg := range m.root.children {
+		if pkg.testCount == 0 {
+			continue
+		}
+		parallel += pkg.parallelCount
+		tests += pkg.testCount
+		fmt.Fprintf(tw, "  %s\t%d/%d\t%s\n", pkg.name, pkg.parallelCount, pkg.testCount,
+			gray.Render(fmt.Sprintf("%.0f%%", parallelPct(pkg.parallelCount, pkg.testCount))))
+	}
+	if tests == 0 {
+		return ""
+	}
+	_ = tw.Flush()
+	return fmt.Sprintf("%.0f%% of tests use t.Parallel:\n", parallelPct(parallel, tests)) + sb.String()
+}
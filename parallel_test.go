@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderParallel(t *testing.T) {
+	m := newModel()
+	assert.Empty(t, renderParallel(m))
+
+	for _, pkg := range []string{"a", "example.com/b"} {
+		m.processEvent(TestEvent{Action: "start", Package: pkg})
+	}
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "pause", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "cont", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA/sub"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA/sub"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestB"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestB"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestC"})
+	m.processEvent(TestEvent{Action: "pause", Package: "a", Test: "TestC"})
+	m.processEvent(TestEvent{Action: "cont", Package: "a", Test: "TestC"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestC"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "example.com/b", Test: "TestD"})
+	m.processEvent(TestEvent{Action: "pass", Package: "example.com/b", Test: "TestD"})
+	m.processEvent(TestEvent{Action: "pass", Package: "example.com/b"})
+
+	assert.Equal(t, "40% of tests use t.Parallel:\n"+
+		"  a              2/4  50%\n"+
+		"  example.com/b  0/1  0%\n", renderParallel(m))
+
+	// re-running a test uncounts its earlier run
+	m.rerunning = true
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestC"})
+	assert.Equal(t, 1, m.root.children[0].parallelCount)
+	m.processEvent(TestEvent{Action: "pause", Package: "a", Test: "TestC"})
+	m.processEvent(TestEvent{Action: "cont", Package: "a", Test: "TestC"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a", Test: "TestC"})
+	assert.Equal(t, 2, m.root.children[0].parallelCount)
+	assert.Equal(t, 4, m.root.children[0].testCount)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// absPathPattern matches absolute paths to go files, like those in panic stack traces
+// and testify's "Error Trace:" lines, e.g. "/home/ci/builds/1/pkg/foo/bar_test.go:12".
+// The path must start the line or follow a separator, which is the first group, so
+// the slashes inside relative paths aren't taken for the start of one.
+var absPathPattern = regexp.MustCompile(`(^|[\s"'(=])((?:[A-Za-z]:)?[/\\][^\s:"'()]+\.go\b)`)
+
+// pathNormalizer rewrites absolute file paths in test output to module-relative paths,
+// which are shorter, and the same across machines.  The absolute paths are retained,
+// so tools which link to the files can map them back.
+type pathNormalizer struct {
+	// root is the directory of the go.mod containing the working directory, and
+	// module is its module path
+	root, module string
+	// gopath directories; files under GOPATH/src and the module cache are
+	// rewritten relative to those
+	gopath []string
+	// abs maps the rewritten paths back to the original absolute paths
+	abs map[string]string
+}
+
+func newPathNormalizer() *pathNormalizer {
+	p := &pathNormalizer{abs: map[string]string{}}
+	if wd, err := os.Getwd(); err == nil {
+		p.root, p.module = findModule(wd)
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			gopath = filepath.Join(home, "go")
+		}
+	}
+	for _, dir := range filepath.SplitList(gopath) {
+		p.gopath = append(p.gopath, filepath.ToSlash(dir))
+	}
+	return p
+}
+
+// modulePattern matches the module directive in a go.mod file.
+var modulePattern = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+// findModule returns the directory of the go.mod file containing dir, and its
+// module path.
+func findModule(dir string) (root, module string) {
+	for {
+		if b, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			if matches := modulePattern.FindSubmatch(b); matches != nil {
+				module = string(matches[1])
+			}
+			return filepath.ToSlash(dir), module
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// normalize rewrites the absolute paths in a line of pkg's output.
+func (p *pathNormalizer) normalize(s, pkg string) string {
+	return absPathPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := absPathPattern.FindStringSubmatch(match)
+		prefix, path := groups[1], groups[2]
+		// the output may come from Windows, even when replayed elsewhere, so its
+		// separators are converted regardless of this OS's
+		slashed := strings.ReplaceAll(path, `\`, "/")
+		rel := p.relative(slashed, pkg)
+		if rel == slashed {
+			return match
+		}
+		p.abs[rel] = path
+		return prefix + rel
+	})
+}
+
+// relative returns the module-relative form of path, or path if it can't be
+// made relative.
+func (p *pathNormalizer) relative(path, pkg string) string {
+	if p.root != "" {
+		if rel, ok := strings.CutPrefix(path, p.root+"/"); ok {
+			return rel
+		}
+	}
+	for _, dir := range p.gopath {
+		if rel, ok := strings.CutPrefix(path, dir+"/pkg/mod/"); ok {
+			return rel
+		}
+		if rel, ok := strings.CutPrefix(path, dir+"/src/"); ok {
+			return p.trimModule(rel)
+		}
+	}
+	// the output may come from a different machine, e.g. a CI build directory.  If the
+	// file's directory ends with the package's import path, rewrite it to the import path.
+	dir, file := pathSplit(path)
+	dirs := strings.Split(dir, "/")
+	pkgs := strings.Split(pkg, "/")
+	n := 0
+	for n < len(dirs) && n < len(pkgs) && dirs[len(dirs)-1-n] == pkgs[len(pkgs)-1-n] {
+		n++
+	}
+	if n == 0 || n < min(2, len(pkgs)) {
+		return path
+	}
+	return p.trimModule(pkg + "/" + file)
+}
+
+// trimModule trims the module path from an import path, if it's in the main module.
+func (p *pathNormalizer) trimModule(path string) string {
+	if p.module != "" {
+		if rel, ok := strings.CutPrefix(path, p.module+"/"); ok {
+			return rel
+		}
+	}
+	return path
+}
+
+// pathSplit splits a slash separated path into its directory and file name.
+func pathSplit(path string) (dir, file string) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePaths(t *testing.T) {
+	p := &pathNormalizer{
+		root:   "/home/me/repo",
+		module: "github.com/org/repo",
+		gopath: []string{"/home/me/go"},
+		abs:    map[string]string{},
+	}
+
+	tests := []struct {
+		in, pkg, want string
+	}{
+		{"\t/home/me/repo/pkg/foo/bar_test.go:12 +0x1d", "github.com/org/repo/pkg/foo", "\tpkg/foo/bar_test.go:12 +0x1d"},
+		{"Error Trace:\t/home/ci/builds/1/repo/pkg/foo/bar_test.go:12", "github.com/org/repo/pkg/foo", "Error Trace:\tpkg/foo/bar_test.go:12"},
+		{"\t/home/ci/builds/1/other/foo/bar_test.go:12", "github.com/org/repo/pkg/foo", "\t/home/ci/builds/1/other/foo/bar_test.go:12"},
+		{"\t/home/me/go/pkg/mod/github.com/x/y@v1.0.0/y.go:5", "github.com/org/repo/pkg/foo", "\tgithub.com/x/y@v1.0.0/y.go:5"},
+		{"\t/home/me/go/src/github.com/org/repo/a.go:5", "github.com/org/repo", "\ta.go:5"},
+		{"bar_test.go:12: got 1", "github.com/org/repo/pkg/foo", "bar_test.go:12: got 1"},
+		{`Error Trace:	C:\Users\ci\repo\pkg\win\bar_test.go:12`, "github.com/org/repo/pkg/win", "Error Trace:\tpkg/win/bar_test.go:12"},
+		{`	C:\Users\ci\other\foo_test.go:12`, "github.com/org/repo/pkg/foo", `	C:\Users\ci\other\foo_test.go:12`},
+		{`	\foo_test.go:12`, "github.com/org/repo/pkg/foo", `	\foo_test.go:12`},
+		// relative paths are left alone, even when they contain the package's path
+		{"\trepo/internal/foo/bar.go:3", "github.com/org/repo/internal/foo", "\trepo/internal/foo/bar.go:3"},
+		{"see pkg/foo/bar_test.go:12", "github.com/org/repo/pkg/foo", "see pkg/foo/bar_test.go:12"},
+		{`file="/home/ci/repo/pkg/foo/baz_test.go"`, "github.com/org/repo/pkg/foo", `file="pkg/foo/baz_test.go"`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.normalize(tt.in, tt.pkg), tt.in)
+	}
+	assert.Equal(t, "/home/ci/builds/1/repo/pkg/foo/bar_test.go", p.abs["pkg/foo/bar_test.go"])
+	assert.Equal(t, `C:\Users\ci\repo\pkg\win\bar_test.go`, p.abs["pkg/win/bar_test.go"])
+}
+
+func TestFindModule(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.23\n"), 0o644))
+	sub := filepath.Join(dir, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	root, module := findModule(sub)
+	assert.Equal(t, filepath.ToSlash(dir), root)
+	assert.Equal(t, "example.com/m", module)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// e.g. "=== RUN   TestFoo", "=== CONT  TestFoo"
+	plainEventPattern = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME)\s+(\S+)`)
+	// e.g. "--- PASS: TestFoo (0.00s)", possibly indented for subtests
+	plainResultPattern = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \((\d+(?:\.\d+)?)s\)`)
+	// e.g. "ok  	pkg	0.123s", "FAIL	pkg	0.123s", "?   	pkg	[no test files]"
+	plainPackagePattern = regexp.MustCompile(`^(ok|FAIL|\?)\s*\t(\S+)(?:\t(\d+(?:\.\d+)?)s)?`)
+)
+
+// plainParser reconstructs approximate test events from plain (non-JSON) `go test`
+// output.  Plain output doesn't name the package until the package's result line,
+// so events are held until then.
+type plainParser struct {
+	pending []TestEvent
+	// current is the test which following output lines are attributed to
+	current string
+}
+
+// parse parses a single line of output, without the trailing newline.  Returns
+// false if the line doesn't look like test output.  Returns the events
+// which are ready to be processed, which will be empty until a package result line
+// is seen.
+func (p *plainParser) parse(line string) ([]TestEvent, bool) {
+	output := line + "\n"
+
+	if m := plainEventPattern.FindStringSubmatch(line); m != nil {
+		test := m[2]
+		switch m[1] {
+		case "RUN":
+			p.add("run", test, "")
+		case "PAUSE":
+			p.add("pause", test, "")
+		case "CONT":
+			p.add("cont", test, "")
+		}
+		p.current = test
+		p.add("output", test, output)
+		return nil, true
+	}
+
+	if m := plainResultPattern.FindStringSubmatch(line); m != nil {
+		test := m[2]
+		p.add("output", test, output)
+		p.add(strings.ToLower(m[1]), test, "")
+		p.pending[len(p.pending)-1].Elapsed, _ = strconv.ParseFloat(m[3], 64)
+		p.current = ""
+		return nil, true
+	}
+
+	if m := plainPackagePattern.FindStringSubmatch(line); m != nil {
+		var action string
+		switch m[1] {
+		case "ok":
+			action = "pass"
+		case "FAIL":
+			action = "fail"
+		default:
+			action = "skip"
+		}
+		p.add("output", "", output)
+		p.add(action, "", "")
+		p.pending[len(p.pending)-1].Elapsed, _ = strconv.ParseFloat(m[3], 64)
+		return p.flush(m[2]), true
+	}
+
+	if len(p.pending) == 0 {
+		// not inside a package's test output
+		return nil, false
+	}
+
+	p.add("output", p.current, output)
+	return nil, true
+}
+
+func (p *plainParser) add(action, test, output string) {
+	p.pending = append(p.pending, TestEvent{Action: action, Test: test, Output: output})
+}
+
+// flush returns the pending events, attributed to pkg, preceded by a start
+// event for the package.
+func (p *plainParser) flush(pkg string) []TestEvent {
+	events := make([]TestEvent, 0, len(p.pending)+1)
+	events = append(events, TestEvent{Action: "start", Package: pkg})
+	for _, ev := range p.pending {
+		ev.Package = pkg
+		events = append(events, ev)
+	}
+	p.pending = nil
+	p.current = ""
+	return events
+}
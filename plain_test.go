@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainParser(t *testing.T) {
+	in := `building...
+=== RUN   TestA
+    a_test.go:10: hello
+--- PASS: TestA (0.50s)
+=== RUN   TestB
+=== RUN   TestB/sub
+    b_test.go:20: boom
+--- FAIL: TestB (0.02s)
+    --- FAIL: TestB/sub (0.01s)
+FAIL
+FAIL	example.com/a	0.612s
+?   	example.com/b	[no test files]
+`
+	var p plainParser
+	var events []TestEvent
+	var passthrough []string
+	for _, line := range strings.Split(strings.TrimSuffix(in, "\n"), "\n") {
+		evs, ok := p.parse(line)
+		if !ok {
+			passthrough = append(passthrough, line)
+		}
+		events = append(events, evs...)
+	}
+
+	assert.Equal(t, []string{"building..."}, passthrough)
+
+	var actions []string
+	for _, ev := range events {
+		if ev.Action != "output" {
+			actions = append(actions, ev.Package+" "+ev.Test+" "+ev.Action)
+		}
+	}
+	assert.Equal(t, []string{
+		"example.com/a  start",
+		"example.com/a TestA run",
+		"example.com/a TestA pass",
+		"example.com/a TestB run",
+		"example.com/a TestB/sub run",
+		"example.com/a TestB fail",
+		"example.com/a TestB/sub fail",
+		"example.com/a  fail",
+		"example.com/b  start",
+		"example.com/b  skip",
+	}, actions)
+
+	assert.Contains(t, events, TestEvent{Action: "output", Package: "example.com/a", Test: "TestB/sub", Output: "    b_test.go:20: boom\n"})
+	assert.Contains(t, events, TestEvent{Action: "pass", Package: "example.com/a", Test: "TestA", Elapsed: 0.5})
+	assert.Contains(t, events, TestEvent{Action: "fail", Package: "example.com/a", Elapsed: 0.612})
+
+	m := newModel()
+	feed(m, events...)
+	assert.True(t, m.overallFail)
+	assert.Equal(t, 2, m.fails)
+	assert.Equal(t, 1, m.passes)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// prCommentMarker identifies gotestpretty's comment on a pull request, so later
+// runs update it instead of adding another one.
+const prCommentMarker = "<!-- gotestpretty -->"
+
+const (
+	// maxCommentSize is the longest comment GitHub accepts
+	maxCommentSize = 65536
+	// maxCommentOutput is how much of each failure's output the comment includes
+	maxCommentOutput = 4096
+)
+
+// pullRequest is the pull request the run's comment is posted on.
+type pullRequest struct {
+	api    string
+	repo   string
+	number int
+	token  string
+}
+
+var pullRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// currentPullRequest finds the pull request from the GitHub Actions environment:
+// $GITHUB_TOKEN, $GITHUB_REPOSITORY, and the PR number from the event payload or
+// $GITHUB_REF.  $GITHUB_PR_NUMBER overrides the PR number, for other CI systems.
+func currentPullRequest() (pullRequest, error) {
+	pr := pullRequest{
+		api:   os.Getenv("GITHUB_API_URL"),
+		repo:  os.Getenv("GITHUB_REPOSITORY"),
+		token: os.Getenv("GITHUB_TOKEN"),
+	}
+	if pr.api == "" {
+		pr.api = "https://api.github.com"
+	}
+	if pr.token == "" || pr.repo == "" {
+		return pr, errors.New("$GITHUB_TOKEN and $GITHUB_REPOSITORY must be set")
+	}
+
+	if n := os.Getenv("GITHUB_PR_NUMBER"); n != "" {
+		var err error
+		pr.number, err = strconv.Atoi(n)
+		return pr, err
+	}
+	if path := os.Getenv("GITHUB_EVENT_PATH"); path != "" {
+		var event struct {
+			PullRequest struct{ Number int } `json:"pull_request"`
+		}
+		if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &event) == nil && event.PullRequest.Number > 0 {
+			pr.number = event.PullRequest.Number
+			return pr, nil
+		}
+	}
+	if matches := pullRefPattern.FindStringSubmatch(os.Getenv("GITHUB_REF")); matches != nil {
+		pr.number, _ = strconv.Atoi(matches[1])
+		return pr, nil
+	}
+	return pr, errors.New("not running on a pull request")
+}
+
+type issueComment struct {
+	ID   int64       `json:"id,omitempty"`
+	Body string      `json:"body"`
+	User *githubUser `json:"user,omitempty"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+// do sends a request to the GitHub API, and decodes the response into v, if it's not nil.
+func (pr pullRequest) do(method, path string, body, v any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(pr.api, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+pr.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// login returns the login of the token's user, who the comment is posted as.
+// GitHub Actions' token can't look itself up, but always posts as its bot.
+func (pr pullRequest) login() (string, error) {
+	var user githubUser
+	err := pr.do(http.MethodGet, "/user", nil, &user)
+	if err != nil && os.Getenv("GITHUB_ACTIONS") == "true" {
+		return "github-actions[bot]", nil
+	}
+	return user.Login, err
+}
+
+// findComment returns the ID of gotestpretty's existing comment on the PR, or 0.
+// Only comments posted by the token's user count, so gotestpretty never edits
+// someone else's comment, even one which quotes the marker.
+func (pr pullRequest) findComment() (int64, error) {
+	login, err := pr.login()
+	if err != nil {
+		return 0, err
+	}
+	const perPage = 100
+	for page := 1; ; page++ {
+		var comments []issueComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", pr.repo, pr.number, perPage, page)
+		if err := pr.do(http.MethodGet, path, nil, &comments); err != nil {
+			return 0, err
+		}
+		for _, c := range comments {
+			if c.User != nil && c.User.Login == login && strings.Contains(c.Body, prCommentMarker) {
+				return c.ID, nil
+			}
+		}
+		if len(comments) < perPage {
+			return 0, nil
+		}
+	}
+}
+
+// postPRComment posts the Markdown report as a comment on the current pull
+// request, or updates the comment posted by an earlier run.
+func postPRComment(m *model) error {
+	pr, err := currentPullRequest()
+	if err != nil {
+		return err
+	}
+	comment := issueComment{Body: prCommentBody(m.summary())}
+
+	id, err := pr.findComment()
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		return pr.do(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", pr.repo, id), comment, nil)
+	}
+	return pr.do(http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", pr.repo, pr.number), comment, nil)
+}
+
+// prCommentBody renders the comment, keeping it within GitHub's limit: each
+// failure's output is truncated, and if it's still too long, so is the comment.
+func prCommentBody(s summary) string {
+	s.Packages = slices.Clone(s.Packages)
+	for i := range s.Packages {
+		p := &s.Packages[i]
+		p.Tests = slices.Clone(p.Tests)
+		for j := range p.Tests {
+			p.Tests[j].Output = truncateOutput(p.Tests[j].Output, maxCommentOutput)
+		}
+	}
+	body := prCommentMarker + "\n" + markdownReport(s)
+	if len(body) <= maxCommentSize {
+		return body
+	}
+	const note = "\n_The report was truncated to fit in a comment._\n"
+	body = body[:strings.LastIndexByte(body[:maxCommentSize-len(note)], '\n')+1]
+	if i := strings.LastIndex(body, "<details>"); i > strings.LastIndex(body, "</details>") {
+		// don't leave a failure's block open
+		body = body[:i]
+	}
+	return body + note
+}
+
+// truncateOutput cuts out to at most n bytes, at the end of a line, noting how
+// many lines were cut.
+func truncateOutput(out string, n int) string {
+	if len(out) <= n {
+		return out
+	}
+	cut := strings.LastIndexByte(out[:n], '\n') + 1
+	if cut == 0 {
+		// one long line
+		cut = n
+		for cut > 0 && !utf8.RuneStart(out[cut]) {
+			cut--
+		}
+	}
+	return fmt.Sprintf("%s... (%d more lines truncated)\n", out[:cut], strings.Count(strings.TrimSuffix(out[cut:], "\n"), "\n")+1)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrentPullRequest(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	_, err := currentPullRequest()
+	assert.Error(t, err)
+
+	t.Setenv("GITHUB_TOKEN", "token")
+	t.Setenv("GITHUB_REPOSITORY", "ansel1/gotestpretty")
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_PR_NUMBER", "")
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_REF", "refs/heads/main")
+	_, err = currentPullRequest()
+	assert.EqualError(t, err, "not running on a pull request")
+
+	t.Setenv("GITHUB_REF", "refs/pull/12/merge")
+	pr, err := currentPullRequest()
+	require.NoError(t, err)
+	assert.Equal(t, pullRequest{api: "https://api.github.com", repo: "ansel1/gotestpretty", number: 12, token: "token"}, pr)
+
+	path := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"pull_request": {"number": 34}}`), 0o644))
+	t.Setenv("GITHUB_EVENT_PATH", path)
+	pr, err = currentPullRequest()
+	require.NoError(t, err)
+	assert.Equal(t, 34, pr.number)
+}
+
+func TestPostPRComment(t *testing.T) {
+	var comments []issueComment
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/user":
+			_ = json.NewEncoder(w).Encode(githubUser{Login: "bot"})
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(comments)
+		case r.Method == http.MethodPost:
+			var c issueComment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+			c.ID = int64(len(comments) + 1)
+			c.User = &githubUser{Login: "bot"}
+			comments = append(comments, c)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPatch:
+			var c issueComment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+			comments[len(comments)-1].Body = c.Body
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_TOKEN", "token")
+	t.Setenv("GITHUB_REPOSITORY", "o/r")
+	t.Setenv("GITHUB_PR_NUMBER", "5")
+
+	comments = append(comments,
+		issueComment{ID: 100, Body: "LGTM", User: &githubUser{Login: "someone"}},
+		// someone else's comment quoting the marker isn't gotestpretty's
+		issueComment{ID: 101, Body: "> " + prCommentMarker, User: &githubUser{Login: "someone"}},
+	)
+
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "pass", Package: "a"})
+	require.NoError(t, postPRComment(m))
+	require.NoError(t, postPRComment(m))
+
+	assert.Equal(t, []string{
+		"GET /user",
+		"GET /repos/o/r/issues/5/comments",
+		"POST /repos/o/r/issues/5/comments",
+		"GET /user",
+		"GET /repos/o/r/issues/5/comments",
+		"PATCH /repos/o/r/issues/comments/3",
+	}, requests)
+	require.Len(t, comments, 3, "the comment is updated, not posted again")
+	assert.Equal(t, "> "+prCommentMarker, comments[1].Body)
+	assert.True(t, strings.HasPrefix(comments[2].Body, prCommentMarker+"\n### "))
+}
+
+func TestPRCommentBody(t *testing.T) {
+	out := strings.Repeat("some output\n", 1000)
+	s := summary{Result: "fail"}
+	for i := range 100 {
+		s.Packages = append(s.Packages, summaryPackage{
+			Name:   fmt.Sprintf("p%d", i),
+			Status: "fail",
+			Tests:  []summaryTest{{Name: "TestA", Status: "fail", Output: out}},
+		})
+	}
+
+	body := prCommentBody(s)
+	assert.LessOrEqual(t, len(body), maxCommentSize)
+	assert.Contains(t, body, "some output\n... (659 more lines truncated)\n")
+	assert.True(t, strings.HasSuffix(body, "</details>\n\n\n_The report was truncated to fit in a comment._\n"), body[len(body)-200:])
+	assert.Equal(t, out, s.Packages[0].Tests[0].Output, "the summary isn't changed")
+
+	assert.Equal(t, "ab... (1 more lines truncated)\n", truncateOutput("abcd", 2))
+	assert.Equal(t, "a\n... (2 more lines truncated)\n", truncateOutput("a\nbc\nd\n", 3))
+}
@@ -0,0 +1,87 @@
+package pretty
+
+import "sync"
+
+// Injector feeds test events from multiple concurrent sources, like several go
+// test processes, into a single consumer.  It's safe to use from any goroutine.
+// The rules:
+//
+//   - events from one source are delivered in the order they were sent
+//   - events from different sources are interleaved in the order they arrive
+//   - a package belongs to the first source to send an event for it.  If another
+//     source runs the same package, its events are renamed to "<package> [<source>]",
+//     so the runs don't corrupt each other's tree
+//   - done is called once, after every source is closed
+//
+// Deliveries are serialized, so send and done don't need to be safe for
+// concurrent use.
+type Injector struct {
+	mu      sync.Mutex
+	send    func(Event)
+	done    func()
+	sources map[*Source]bool
+	// owners maps each package to the source which owns it
+	owners map[string]*Source
+	closed bool
+}
+
+// Source is one of an Injector's sources.
+type Source struct {
+	name string
+	inj  *Injector
+}
+
+// NewInjector returns an Injector which delivers events to send, and calls done
+// when its sources are finished.
+func NewInjector(send func(Event), done func()) *Injector {
+	return &Injector{
+		send:    send,
+		done:    done,
+		sources: map[*Source]bool{},
+		owners:  map[string]*Source{},
+	}
+}
+
+// Source registers a new source.  All sources should be registered before any is
+// closed, or done may be called early.
+func (i *Injector) Source(name string) *Source {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	s := &Source{name: name, inj: i}
+	i.sources[s] = true
+	return s
+}
+
+// Send delivers an event.  Events sent after the source is closed are dropped.
+func (s *Source) Send(e Event) {
+	i := s.inj
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.sources[s] {
+		return
+	}
+	if e.Package != "" {
+		owner, ok := i.owners[e.Package]
+		if !ok {
+			i.owners[e.Package] = s
+		} else if owner != s {
+			e.Package += " [" + s.name + "]"
+		}
+	}
+	i.send(e)
+}
+
+// Close marks the source finished.  When the last source is closed, done is called.
+func (s *Source) Close() {
+	i := s.inj
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.sources, s)
+	if len(i.sources) == 0 && !i.closed {
+		i.closed = true
+		i.done()
+	}
+}
@@ -0,0 +1,57 @@
+package pretty
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector(t *testing.T) {
+	var events []Event
+	dones := 0
+	inj := NewInjector(func(e Event) {
+		events = append(events, e)
+	}, func() {
+		dones++
+	})
+
+	a, b := inj.Source("a"), inj.Source("b")
+
+	var wg sync.WaitGroup
+	for _, s := range []*Source{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.Close()
+			s.Send(Event{Action: "start", Package: "shared"})
+			for i := range 50 {
+				test := fmt.Sprintf("Test%d", i)
+				s.Send(Event{Action: "run", Package: "shared", Test: test})
+				s.Send(Event{Action: "pass", Package: "shared", Test: test})
+			}
+			s.Send(Event{Action: "pass", Package: "shared"})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, dones)
+	assert.Len(t, events, 2*102)
+	packages := map[string][]string{}
+	for _, e := range events {
+		if e.Test != "" {
+			packages[e.Package] = append(packages[e.Package], e.Action+" "+e.Test)
+		}
+	}
+	assert.Len(t, packages, 2, "the second source's package should be renamed")
+	for pkg, actions := range packages {
+		for i := range 50 {
+			assert.Equal(t, []string{"run Test" + fmt.Sprint(i), "pass Test" + fmt.Sprint(i)}, actions[2*i:2*i+2], "%s's events should be in order", pkg)
+		}
+	}
+
+	// events after close are dropped
+	a.Send(Event{Action: "start", Package: "late"})
+	assert.Len(t, events, 2*102)
+}
@@ -0,0 +1,67 @@
+// Package pretty holds the parts of gotestpretty which other tools can share to
+// consume `go test -json` output: the event type, its decoder, and an Injector,
+// which merges the events of several concurrent sources, like go test processes
+// run in parallel, into one ordered stream.
+//
+// The gotestpretty command is built on them:
+//
+//	s := bufio.NewScanner(os.Stdin)
+//	for s.Scan() {
+//		e, err := pretty.Decode(s.Bytes())
+//		if err != nil {
+//			// not a test event, e.g. build output
+//			continue
+//		}
+//		...
+//	}
+//
+// The tree of packages and tests, the live view, and the summary are still part
+// of the command.
+package pretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// Event is an event of `go test -json`, see `go doc test2json`.
+type Event struct {
+	Time    time.Time // encodes as an RFC3339-format string
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64 // seconds
+	Output  string
+	// OutputType is added by go 1.24 and later, to mark error output and the
+	// framing lines like "=== RUN".
+	OutputType string `json:",omitempty"`
+	// ImportPath is set on the build-output and build-fail events of go 1.24 and
+	// later, and FailedBuild on the fail event of a package which failed to build.
+	ImportPath  string `json:",omitempty"`
+	FailedBuild string `json:",omitempty"`
+	// Extra holds fields which aren't part of go test's output, added by wrappers.
+	// Decode rejects them, so they're only set by decoders which allow them.
+	Extra map[string]string `json:"-"`
+	// Stream labels the input stream the event was read from, when more than one
+	// is merged.
+	Stream string `json:"-"`
+}
+
+// Decode parses a single line of `go test -json` output.  Returns an error if the
+// line isn't a test event.
+func Decode(line []byte) (Event, error) {
+	var e Event
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&e); err != nil {
+		return e, err
+	}
+	// e.g. two events written to the same line by writers which raced
+	if _, err := decoder.Token(); err != io.EOF {
+		return e, errors.New("unexpected data after the event")
+	}
+	return e, nil
+}
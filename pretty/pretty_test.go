@@ -0,0 +1,30 @@
+package pretty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	e, err := Decode([]byte(`{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"example.com/a","Test":"TestA","Output":"boom\n"}`))
+	require.NoError(t, err)
+	assert.Equal(t, Event{
+		Time:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Action:  "output",
+		Package: "example.com/a",
+		Test:    "TestA",
+		Output:  "boom\n",
+	}, e)
+
+	for _, line := range []string{
+		"not an event",
+		`{"Action":"run","Package":"a","Extra":"field"}`,
+		`{"Action":"run","Package":"a"}{"Action":"run","Package":"b"}`,
+	} {
+		_, err := Decode([]byte(line))
+		assert.Error(t, err, line)
+	}
+}
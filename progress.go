@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// progressWidth is the width of the progress bar, in cells.
+const progressWidth = 20
+
+// resolvePackages resolves -packages to the package list: package patterns are
+// listed with go list, @file reads the packages from a file, one per line, like the
+// output of go list, and a number is only the count of packages.
+func resolvePackages(v string) (pkgs []string, count int, err error) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return nil, n, nil
+	}
+	if path, ok := strings.CutPrefix(v, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		pkgs = strings.Fields(string(b))
+		return pkgs, len(pkgs), nil
+	}
+	pkgs, err = listPackages(strings.Fields(v))
+	return pkgs, len(pkgs), err
+}
+
+// packageProgress returns how many of the run's packages are done, out of the
+// total, if the package list or count is known.
+func (m *model) packageProgress() (done, total int) {
+	for _, pkg := range m.packages {
+		if !m.filtered(pkg) {
+			total++
+		}
+	}
+	if len(m.packages) == 0 {
+		total = m.packageCount
+	}
+	for _, n := range m.root.children {
+		if n.done {
+			done++
+		}
+	}
+	return min(done, total), total
+}
+
+// eta estimates the time left in the run, from the history's package durations if
+// the package list is known, otherwise from the average time per package so far.
+// Like projectedElapsed, the remaining time is split across GOMAXPROCS packages.
+func (m *model) eta(done, total int) (time.Duration, bool) {
+	if len(m.packages) > 0 && len(m.expected) > 0 {
+		started := map[string]*node{}
+		for _, n := range m.root.children {
+			started[n.name] = n
+		}
+		var remaining time.Duration
+		for _, pkg := range m.packages {
+			d, ok := m.expected[pkg]
+			n := started[pkg]
+			switch {
+			case !ok || m.filtered(pkg):
+			case n == nil:
+				remaining += d
+			case !n.done:
+				remaining += max(d-scaledTimeSince(n.firstStart), 0)
+			}
+		}
+		return remaining / time.Duration(runtime.GOMAXPROCS(0)), true
+	}
+	if done == 0 {
+		return 0, false
+	}
+	return scaledTimeSince(m.start) / time.Duration(done) * time.Duration(total-done), true
+}
+
+// renderProgress renders a progress bar of the finished packages, with the ETA.
+func (m *model) renderProgress(sb *strings.Builder, done, total int) {
+	filled := progressWidth * done / total
+	bar := strings.Repeat("█", filled) + gray.Render(strings.Repeat("░", progressWidth-filled))
+	fmt.Fprintf(sb, "%s %d/%d packages", bar, done, total)
+	if eta, ok := m.eta(done, total); ok && done < total {
+		fmt.Fprintf(sb, "  %s", gray.Render("ETA "+round(eta, 0).String()))
+	}
+	sb.WriteString("\n")
+}
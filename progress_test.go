@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePackages(t *testing.T) {
+	pkgs, count, err := resolvePackages("12")
+	require.NoError(t, err)
+	assert.Nil(t, pkgs)
+	assert.Equal(t, 12, count)
+
+	path := filepath.Join(t.TempDir(), "pkgs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a\nexample.com/b\n\n"), 0o644))
+	pkgs, count, err = resolvePackages("@" + path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "example.com/b"}, pkgs)
+	assert.Equal(t, 2, count)
+
+	_, _, err = resolvePackages("@" + path + ".missing")
+	assert.Error(t, err)
+}
+
+func TestPackageProgress(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	start := time.Now()
+	now = func() time.Time { return start }
+
+	m := newModel()
+	m.packageCount = 4
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "start", Package: "b"})
+	assert.Equal(t, 2, m.queued())
+
+	out := m.render(false)
+	assert.Contains(t, out, strings.Repeat("░", 20)+" 0/4 packages\n", "no ETA until a package finishes")
+
+	now = func() time.Time { return start.Add(10 * time.Second) }
+	m.processEvent(TestEvent{Action: "pass", Package: "a"})
+	out = m.render(false)
+	assert.Contains(t, out, strings.Repeat("█", 5)+strings.Repeat("░", 15)+" 1/4 packages  ETA 30s\n")
+
+	m.processEvent(TestEvent{Action: "pass", Package: "b"})
+	m.done = true
+	assert.NotContains(t, m.render(false), "packages")
+}
+
+func TestPackageProgressHistory(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	start := time.Now()
+	now = func() time.Time { return start }
+
+	procs := time.Duration(runtime.GOMAXPROCS(0))
+	m := newModel()
+	m.packages = []string{"a", "b", "c"}
+	m.expected = map[string]time.Duration{"a": 4 * time.Second * procs, "b": 10 * time.Second * procs, "other": time.Hour}
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	now = func() time.Time { return start.Add(time.Second * procs) }
+
+	done, total := m.packageProgress()
+	assert.Equal(t, 0, done)
+	assert.Equal(t, 3, total)
+	eta, ok := m.eta(done, total)
+	require.True(t, ok)
+	// a has 3s left, b hasn't started, c and other aren't known
+	assert.Equal(t, 13*time.Second, eta)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// listPackages returns the import paths of the packages matching the go list
+// patterns.
+func listPackages(patterns []string) ([]string, error) {
+	args := append([]string{"list", "-e"}, patterns...)
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list %s: %w", strings.Join(patterns, " "), err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// queued returns how many of the run's known packages haven't started yet.  go test
+// only runs -p packages at once, so on large runs most packages are waiting.
+func (m *model) queued() int {
+	if len(m.packages) == 0 {
+		return max(m.packageCount-len(m.root.children), 0)
+	}
+	started := make(map[string]bool, len(m.root.children))
+	for _, n := range m.root.children {
+		started[n.name] = true
+	}
+	var queued int
+	for _, pkg := range m.packages {
+		if !started[pkg] && !m.filtered(pkg) {
+			queued++
+		}
+	}
+	return queued
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueuedPackages(t *testing.T) {
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.packages = []string{"a", "b", "c"}
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+
+	assert.Equal(t, 2, m.queued())
+	assert.Contains(t, m.View(), "+2 queued")
+
+	m.processEvent(TestEvent{Action: "start", Package: "b"})
+	m.processEvent(TestEvent{Action: "start", Package: "c"})
+	assert.NotContains(t, m.View(), "queued")
+}
+
+func TestListPackages(t *testing.T) {
+	pkgs, err := listPackages([]string{"."})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/ansel1/gotestpretty"}, pkgs)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// With -max-output-rate, a test which writes output faster than the limit, e.g. a
+// runaway loop logging as fast as it can, has the output past the limit dropped,
+// so it can't lock up the live view or use up memory.  The test is annotated
+// "output rate-limited", and its output notes how many lines were dropped.  The
+// rate is measured over one second windows of the events' timestamps, so output
+// read from a file is limited the same as it was when the tests ran.
+
+// outputRate is the value of -max-output-rate, in lines or bytes per second.
+type outputRate struct {
+	lines, bytes int
+}
+
+var rateUnits = []struct {
+	suffix string
+	size   int
+}{{"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+// outputRateFlag parses -max-output-rate, e.g. "10000" or "10000/s" lines per
+// second, or "5MB/s", "500KB/s", or "1000B/s".
+func outputRateFlag(s string) error {
+	v := strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	unit := 0
+	for _, u := range rateUnits {
+		if n, ok := strings.CutSuffix(v, u.suffix); ok {
+			v, unit = n, u.size
+			break
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid rate %q, should be lines per second, or bytes per second like 5MB/s", s)
+	}
+	if unit == 0 {
+		flags.maxOutputRate = outputRate{lines: n}
+	} else {
+		flags.maxOutputRate = outputRate{bytes: n * unit}
+	}
+	return nil
+}
+
+// rateWindow counts a node's output in the current one second window.
+type rateWindow struct {
+	start        time.Time
+	lines, bytes int
+	// dropped counts the lines dropped since the node started
+	dropped int
+}
+
+// rateLimited returns true if the output event is over -max-output-rate, in which
+// case it should be dropped.
+func (n *node) rateLimited(ev TestEvent) bool {
+	limit := flags.maxOutputRate
+	if limit.lines == 0 && limit.bytes == 0 {
+		return false
+	}
+	ts := ev.Time
+	if ts.IsZero() {
+		ts = now()
+	}
+	if n.rate == nil {
+		n.rate = &rateWindow{}
+	}
+	w := n.rate
+	if ts.Sub(w.start) >= time.Second || ts.Before(w.start) {
+		w.start, w.lines, w.bytes = ts, 0, 0
+	}
+	w.lines++
+	w.bytes += len(ev.Output)
+	if limit.lines > 0 && w.lines > limit.lines || limit.bytes > 0 && w.bytes > limit.bytes {
+		w.dropped++
+		return true
+	}
+	return false
+}
+
+// outputDropped returns the number of lines dropped by -max-output-rate.
+func (n *node) outputDropped() int {
+	if n.rate == nil {
+		return 0
+	}
+	return n.rate.dropped
+}
+
+// noteDroppedOutput adds a line to the node's output saying how much of it was
+// dropped, once it has finished.
+func (n *node) noteDroppedOutput() {
+	if dropped := n.outputDropped(); dropped > 0 {
+		n.appendUnlimited(fmt.Sprintf("... %d lines of output dropped by -max-output-rate ...\n", dropped))
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputRateFlag(t *testing.T) {
+	keepFlags(t)
+
+	for s, want := range map[string]outputRate{
+		"1000":    {lines: 1000},
+		"1000/s":  {lines: 1000},
+		"5MB/s":   {bytes: 5 << 20},
+		"500KB/s": {bytes: 500 << 10},
+		"100B/s":  {bytes: 100},
+	} {
+		assert.NoError(t, outputRateFlag(s), s)
+		assert.Equal(t, want, flags.maxOutputRate, s)
+	}
+	for _, s := range []string{"", "0", "fast", "5GB/s", "-1"} {
+		assert.Error(t, outputRateFlag(s), s)
+	}
+}
+
+func TestMaxOutputRate(t *testing.T) {
+	keepFlags(t)
+	flags.maxOutputRate = outputRate{lines: 3}
+
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.processEvent(TestEvent{Action: "start", Package: "a", Time: start})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestLoud", Time: start})
+	for i := range 10 {
+		m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestLoud", Time: start.Add(time.Duration(i) * time.Millisecond), Output: fmt.Sprintf("line %d\n", i)})
+	}
+	// the next second has a new allowance
+	m.processEvent(TestEvent{Action: "output", Package: "a", Test: "TestLoud", Time: start.Add(time.Second), Output: "later\n"})
+
+	n := m.root.children[0].children[0]
+	assert.Equal(t, 7, n.outputDropped())
+	assert.Contains(t, m.View(), "output rate-limited")
+
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestLoud", Time: start.Add(time.Second)})
+	assert.Equal(t, "line 0\nline 1\nline 2\nlater\n... 7 lines of output dropped by -max-output-rate ...\n", n.log)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxSimilarNames is the number of similar failures named next to a failure.
+const maxSimilarNames = 3
+
+// errorMessagePattern matches a failure message in a test's output, i.e. the
+// text after the file and line, like "foo_test.go:12: connection refused".
+var errorMessagePattern = regexp.MustCompile(`(?m)^\s*[\w./\\-]+\.go:\d+: (.+)$`)
+
+// errorSignature returns the test's first failure message, with volatile parts
+// like durations and addresses removed, or "" if the output has no message.
+func errorSignature(output string) string {
+	matches := errorMessagePattern.FindStringSubmatch(output)
+	if matches == nil {
+		return ""
+	}
+	return strings.TrimSpace(volatilePattern.ReplaceAllString(matches[1], ""))
+}
+
+// linkSimilar links a failed test to the other failures with the same error
+// signature, in any package, so failures with a common cause can be spotted.
+func (m *model) linkSimilar(n *node) {
+	if n.outputBuf == nil {
+		return
+	}
+	sig := errorSignature(n.outputBuf.String())
+	if sig == "" {
+		return
+	}
+	if m.errorSignatures == nil {
+		m.errorSignatures = map[string][]*node{}
+	}
+	if slices.Contains(m.errorSignatures[sig], n) {
+		// the same test failed again, e.g. with -count
+		return
+	}
+	for _, other := range m.errorSignatures[sig] {
+		if n.descendsFrom(other) || other.descendsFrom(n) {
+			// parents' output repeats their subtests' failures
+			continue
+		}
+		other.similar = append(other.similar, n)
+		n.similar = append(n.similar, other)
+	}
+	m.errorSignatures[sig] = append(m.errorSignatures[sig], n)
+}
+
+// similarLink returns the text naming the failures similar to this one.
+func (n *node) similarLink() string {
+	if len(n.similar) == 0 {
+		return ""
+	}
+	var names []string
+	for _, s := range n.similar[:min(len(n.similar), maxSimilarNames)] {
+		name := s.testName()
+		if s.pkg() != n.pkg() {
+			name = s.pkg().name + "." + name
+		}
+		names = append(names, name)
+	}
+	link := "similar errors in: " + strings.Join(names, ", ")
+	if more := len(n.similar) - maxSimilarNames; more > 0 {
+		link += fmt.Sprintf(" +%d more", more)
+	}
+	return link
+}
+
+// descendsFrom returns true if n is a descendant of ancestor.
+func (n *node) descendsFrom(ancestor *node) bool {
+	for p := n.parent; p != nil; p = p.parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
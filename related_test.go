@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorSignature(t *testing.T) {
+	assert.Equal(t, "dial tcp: connection refused after", errorSignature("=== RUN   TestA\n    a_test.go:12: dial tcp: connection refused after 1.5s\n"))
+	assert.Equal(t, "", errorSignature("=== RUN   TestA\n--- FAIL: TestA (0.00s)\n"))
+}
+
+func TestLinkSimilar(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "    a_test.go:3: connection refused after 1s\n"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "run", Package: "a", Test: "TestB/sub"},
+		{Action: "output", Package: "a", Test: "TestB/sub", Output: "    b_test.go:9: connection refused after 2s\n"},
+		{Action: "fail", Package: "a", Test: "TestB/sub"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "    b_test.go:9: connection refused after 2s\n"},
+		{Action: "fail", Package: "a", Test: "TestB"},
+		{Action: "run", Package: "a", Test: "TestC"},
+		{Action: "output", Package: "a", Test: "TestC", Output: "    c_test.go:4: boom\n"},
+		{Action: "fail", Package: "a", Test: "TestC"},
+		{Action: "start", Package: "b"},
+		{Action: "run", Package: "b", Test: "TestD"},
+		{Action: "output", Package: "b", Test: "TestD", Output: "    d_test.go:4: connection refused after 3s\n"},
+		{Action: "fail", Package: "b", Test: "TestD"},
+	}...)
+
+	testA, _ := m.root.children[0].findChild([]string{"TestA"})
+	testB, _ := m.root.children[0].findChild([]string{"TestB"})
+	testC, _ := m.root.children[0].findChild([]string{"TestC"})
+	sub, _ := testB.findChild([]string{"sub"})
+
+	assert.Len(t, testA.similar, 3)
+	assert.NotContains(t, sub.similar, testB, "parents repeat their subtests' failures")
+	assert.Empty(t, testC.similar)
+	assert.Equal(t, "similar errors in: TestB/sub, TestB, b.TestD", testA.similarLink())
+	assert.Contains(t, m.String(), "TestD\t0s\tsimilar errors in: a.TestA, a.TestB/sub, a.TestB")
+}
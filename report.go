@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// reporting returns true if any reports were requested, in which case nodes retain
+// a copy of their output.  GitHub Actions' annotations and step summary only need
+// the failures, whose output is always kept, so they don't count.
+func reporting() bool {
+	return flags.snapshot != "" || flags.html != "" || flags.markdown != "" || flags.prComment || flags.summaryJSON != "" || flags.junit != "" || flags.finalView == "starts"
+}
+
+var htmlFuncs = template.FuncMap{
+	"icon": func(status string) string {
+		switch status {
+		case "pass":
+			return "✓"
+		case "fail":
+			return "✖"
+		case "skip":
+			return "⍉"
+		case "aborted":
+			return "⊘"
+		}
+		return "…"
+	},
+	"name": escapeControl,
+	"elapsed": func(d time.Duration) string {
+		return formatElapsed(d, time.Millisecond, 3)
+	},
+	"timestamp": func(t time.Time) string {
+		return formatTime(t, time.RFC1123)
+	},
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .OverallFail}}FAILED{{else}}PASSED{{end}} - gotestpretty</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+ul { list-style: none; padding-left: 1.5em; }
+.pass .icon { color: green; }
+.fail .icon, .aborted .icon { color: red; }
+.skip .icon { color: darkgoldenrod; }
+.elapsed, .msg { color: gray; margin-left: 1em; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{if .Aborts}}ABORTED{{else if .OverallFail}}FAILED{{else}}PASSED{{end}}</h1>
+<p>{{.Total}} tests, {{.Passes}} passed, {{.Fails}} failed, {{.Skips}} skipped{{if .Aborts}}, {{.Aborts}} aborted{{end}}
+in {{elapsed (.End.Sub .Start)}}, started {{timestamp .Start}}</p>
+<ul>
+{{range .Packages}}{{template "node" .}}{{end}}
+</ul>
+</body>
+</html>
+{{define "node"}}<li class="{{.Status}}"><span class="icon">{{icon .Status}}</span> {{name .Name}}<span class="elapsed">{{elapsed .Elapsed}}</span><span class="msg">{{.Msg}}</span>
+{{- if .Output}}<details{{if eq .Status "fail"}} open{{end}}><summary>output</summary><pre>{{.Output}}</pre></details>{{end}}
+{{- if .Children}}<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}</li>
+{{end}}`))
+
+// writeHTMLReport writes the final test tree as a standalone HTML page.
+func writeHTMLReport(m *model, path string) error {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, m.snapshot()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(consoleText(buf.String())), 0o644)
+}
+
+// openBrowser opens the file in the default browser.
+func openBrowser(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReport(t *testing.T) {
+	keepFlags(t)
+	flags.html = "x"
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "output", Package: "a", Test: "TestA", Output: "<b>boom</b>\n"},
+		{Action: "fail", Package: "a", Test: "TestA", Elapsed: 1.5},
+		{Action: "fail", Package: "a", Elapsed: 2},
+	}...)
+	m.done = true
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, writeHTMLReport(m, path))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	html := string(b)
+	assert.Contains(t, html, "<h1>FAILED</h1>")
+	assert.Contains(t, html, `<li class="fail"><span class="icon">✖</span> TestA<span class="elapsed">1.5s</span>`)
+	assert.Contains(t, html, "&lt;b&gt;boom&lt;/b&gt;", "output should be escaped")
+}
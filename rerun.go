@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// canRerun returns true if failed tests can be re-run: gotestpretty needs to
+// know how to run go test, either because it ran the tests itself in wrapper mode,
+// or it was given the package list.
+func (m *model) canRerun() bool {
+	return m.input != nil && (goTest != nil || flags.packages != "")
+}
+
+// wantRerun returns true if tests should be re-run when the run ends: the failed
+// tests because of -rerun-fails, or because the r key was pressed, or the test
+// selected with the R key.
+func (m *model) wantRerun() bool {
+	if !m.canRerun() {
+		return false
+	}
+	if m.rerunTest != nil {
+		return true
+	}
+	if !m.rerunRequested && m.reruns >= flags.rerunFails {
+		return false
+	}
+	pkgs, _ := m.failedTests()
+	return len(pkgs) > 0
+}
+
+// failedTests returns the packages with failed tests, and the top level tests which
+// failed in them.
+func (m *model) failedTests() (pkgs, tests []string) {
+	for _, pkg := range m.root.children {
+		var failed bool
+		for _, n := range pkg.children {
+			if n.isTest && n.status == "fail" {
+				failed = true
+				if !slices.Contains(tests, n.name) {
+					tests = append(tests, n.name)
+				}
+			}
+		}
+		if failed {
+			pkgs = append(pkgs, pkg.name)
+		}
+	}
+	return pkgs, tests
+}
+
+// runFlagPattern matches go test's -run flag, with its value if it's in the same arg.
+var runFlagPattern = regexp.MustCompile(`^--?(test\.)?run(=.*)?$`)
+
+// withoutRunFlag removes any -run flags from go test flags.
+func withoutRunFlag(testFlags []string) []string {
+	var out []string
+	for i := 0; i < len(testFlags); i++ {
+		matches := runFlagPattern.FindStringSubmatch(testFlags[i])
+		switch {
+		case matches == nil:
+			out = append(out, testFlags[i])
+		case matches[2] == "":
+			// skip the value too
+			i++
+		}
+	}
+	return out
+}
+
+// startRerun re-runs the failed tests, or the test selected with the R key,
+// updating the same tree.  Failed packages without failed tests, like build
+// failures, aren't re-run.
+func (m *model) startRerun() tea.Cmd {
+	pkgs, tests := m.failedTests()
+	var names []string
+	for _, t := range tests {
+		names = append(names, regexp.QuoteMeta(t))
+	}
+	run := "^(" + strings.Join(names, "|") + ")$"
+	msg := fmt.Sprintf("Re-running %d failed tests in %d packages", len(tests), len(pkgs))
+	if flags.rerunFails > 0 {
+		msg += fmt.Sprintf(" (%d/%d)", m.reruns+1, max(flags.rerunFails, m.reruns+1))
+	}
+	// the other failed tests in the selected test's package aren't re-run
+	var keepFailing bool
+	if n := m.rerunTest; n != nil {
+		pkgs, run = []string{n.pkg().name}, runPattern(n)
+		msg = "Re-running " + n.pkg().name + " " + n.testName()
+		top := n
+		for top.parent != nil && top.parent.isTest {
+			top = top.parent
+		}
+		for _, c := range n.pkg().children {
+			if c != top && c.isTest && c.status == "fail" {
+				keepFailing = true
+			}
+		}
+	}
+	m.reruns++
+	m.rerunRequested = false
+	m.rerunTest = nil
+	m.rerunning = true
+
+	// packages which aren't re-run keep failing the run
+	m.overallFail = len(m.coverageViolations) > 0 || len(m.notStarted) > 0 || keepFailing
+	for _, n := range m.root.children {
+		if n.status == "fail" && !slices.Contains(pkgs, n.name) {
+			m.overallFail = true
+		}
+	}
+
+	var testFlags []string
+	if goTest != nil {
+		testFlags = goTest.testFlags
+	}
+	testFlags = append(withoutRunFlag(testFlags), "-run", run)
+
+	input := m.input
+	print := m.printOutput(gray.Render(msg), false)
+	return func() tea.Msg {
+		print()
+		run, err := startGoTest(pkgs, testFlags)
+		if err != nil {
+			return err
+		}
+		goTest = run
+		process(input)
+		return nil
+	}
+}
+
+// resetNode clears a test's results when it's re-run, so it isn't counted twice.
+// Packages only reset their own state, since only some of their tests are re-run.
+func (m *model) resetNode(n *node) {
+	if n.isTest {
+		pkg := n.pkg()
+		for status, count := range n.tally {
+			switch status {
+			case "pass":
+				m.passes -= count
+			case "fail":
+				m.fails -= count
+			case "skip":
+				m.skips -= count
+			case "xfail":
+				m.xfails -= count
+			}
+			m.total -= count
+			pkg.testCount -= count
+		}
+		// the tally includes the test itself
+		m.subtests -= max(0, sumValues(n.tally)-1)
+		pkg.testElapsed -= n.elapsed
+		pkg.parallelCount -= n.parallelTally
+		n.tally = nil
+		n.parallelTally = 0
+		n.children = nil
+		n.dropped = nil
+		n.passedRuns, n.failedRuns = 0, 0
+		m.flaky = slices.DeleteFunc(m.flaky, func(f *node) bool { return f == n || f.descendsFrom(n) })
+	}
+	n.done = false
+	n.doneTs = time.Time{}
+	n.elapsed = 0
+	n.start = now()
+	n.outputBuf = nil
+	n.outputLines, n.overflow, n.rate = 0, nil, nil
+	if n.fuzz != nil {
+		n.fuzz = nil
+		m.fuzzTargets = slices.DeleteFunc(m.fuzzTargets, func(f *node) bool { return f == n })
+	}
+	n.log = ""
+	n.dumping = nil
+}
+
+func sumValues(m map[string]int) int {
+	var sum int
+	for _, v := range m {
+		sum += v
+	}
+	return sum
+}
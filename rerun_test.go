@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithoutRunFlag(t *testing.T) {
+	assert.Equal(t, []string{"-v", "-count=1"}, withoutRunFlag([]string{"-v", "-run", "TestA", "-count=1"}))
+	assert.Equal(t, []string{"-v"}, withoutRunFlag([]string{"-run=TestA", "-v", "--test.run", "TestB"}))
+	assert.Nil(t, withoutRunFlag(nil))
+}
+
+func TestFailedTests(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/sub"},
+		{Action: "fail", Package: "a", Test: "TestA/sub"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "pass", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a"},
+		{Action: "start", Package: "b"},
+		{Action: "pass", Package: "b"},
+	}...)
+
+	pkgs, tests := m.failedTests()
+	assert.Equal(t, []string{"a"}, pkgs)
+	assert.Equal(t, []string{"TestA"}, tests)
+}
+
+func TestRerunResetsNodes(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/sub"},
+		{Action: "fail", Package: "a", Test: "TestA/sub"},
+		{Action: "run", Package: "a", Test: "TestA/ok"},
+		{Action: "pass", Package: "a", Test: "TestA/ok"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "pass", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a"},
+	}...)
+	assert.Equal(t, 2, m.fails)
+	assert.Equal(t, 4, m.total)
+	assert.Equal(t, 2, m.subtests)
+	testA, _ := m.root.children[0].findChild([]string{"TestA"})
+
+	m.rerunning = true
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a"},
+	}...)
+
+	pkg := m.root.children[0]
+	assert.Equal(t, "pass", pkg.status)
+	assert.Equal(t, "pass", testA.status)
+	assert.Empty(t, testA.children)
+	assert.Equal(t, 0, m.fails)
+	assert.Equal(t, 2, m.passes)
+	assert.Equal(t, 2, m.total)
+	assert.Equal(t, 0, m.subtests)
+}
+
+func TestCanRerun(t *testing.T) {
+	keepFlags(t)
+	m := newModel()
+	assert.False(t, m.canRerun())
+
+	m.input = newStartupBuffer()
+	assert.False(t, m.canRerun())
+
+	flags.packages = "./..."
+	assert.True(t, m.canRerun())
+	// nothing failed
+	assert.False(t, m.wantRerun())
+}
+
+func TestRerunSelected(t *testing.T) {
+	keepFlags(t)
+	flags.packages = "./..."
+
+	m := newModel()
+	m.input = newStartupBuffer()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/sub"},
+		{Action: "fail", Package: "a", Test: "TestA/sub"},
+		{Action: "fail", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a", Test: "TestB"},
+	}...)
+	m.Update(key("up"))
+	m.Update(key("end"))
+	require.Equal(t, "TestB", m.cursor.name)
+
+	m.Update(key("R"))
+	require.Same(t, m.cursor, m.rerunTest)
+	assert.True(t, m.wantRerun())
+
+	m.startRerun()
+	assert.Nil(t, m.rerunTest)
+	assert.Equal(t, 1, m.reruns)
+	// TestA isn't re-run, so it still fails the run
+	assert.True(t, m.overallFail)
+}
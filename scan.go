@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineSize is the longest line read as one line.  Longer lines, like a test
+// printing a huge blob without newlines, are split into chunks, rather than
+// stopping the input like bufio.Scanner does.
+var maxLineSize = 64 * 1024 * 1024
+
+// newLineScanner returns a scanner which splits r into lines, see scanLines.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxLineSize)
+	s.Split(scanLines)
+	return s
+}
+
+// scanLines is bufio.ScanLines, except lines longer than maxLineSize are split
+// into chunks, which never split a UTF-8 encoded rune.  Lines are only returned
+// once their newline is read, so input read from a FIFO or socket in partial
+// writes, which split a line, or a rune, is put back together.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, dropCR(data[:i]), nil
+	}
+	if len(data) >= maxLineSize {
+		cut := maxLineSize
+		// back up to the start of a rune cut off at the end
+		for p := cut - 1; p >= 0 && p > cut-utf8.UTFMax; p-- {
+			if utf8.RuneStart(data[p]) {
+				if p > 0 && !utf8.FullRune(data[p:cut]) {
+					cut = p
+				}
+				break
+			}
+		}
+		return cut, data[:cut], nil
+	}
+	if atEOF && len(data) > 0 {
+		// the last line has no newline
+		return len(data), dropCR(data), nil
+	}
+	return 0, nil, nil
+}
+
+// dropCR drops a trailing \r, of a line ending in \r\n.
+func dropCR(data []byte) []byte {
+	if len(data) > 0 && data[len(data)-1] == '\r' {
+		return data[:len(data)-1]
+	}
+	return data
+}
+
+// validUTF8 replaces the invalid UTF-8 in a line which isn't a test event, e.g.
+// binary output, so it can't garble the terminal.  The output of test events is
+// already made valid when it's decoded.
+func validUTF8(s string) string {
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanAll returns the lines scanned from r.
+func scanAll(t testing.TB, r *bytes.Reader, oneByte bool) []string {
+	s := newLineScanner(r)
+	if oneByte {
+		s = newLineScanner(iotest.OneByteReader(r))
+	}
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	require.NoError(t, s.Err())
+	return lines
+}
+
+func TestScanLines(t *testing.T) {
+	defer func(size int) { maxLineSize = size }(maxLineSize)
+	maxLineSize = 8
+
+	for _, tt := range []struct {
+		input string
+		want  []string
+	}{
+		{"a\nb\r\nc", []string{"a", "b", "c"}},
+		{"0123456789abc\nd\n", []string{"01234567", "89abc", "d"}},
+		// é is 2 bytes, and would be split at 8 bytes
+		{"0123456é\n", []string{"0123456", "é"}},
+		{"01234€€\n", []string{"01234€", "€"}},
+		{"\xff\xfe\xfd\xfc\xfb\xfa\xf9\xf8\xf7\n", []string{"\xff\xfe\xfd\xfc\xfb\xfa\xf9\xf8", "\xf7"}},
+	} {
+		assert.Equal(t, tt.want, scanAll(t, bytes.NewReader([]byte(tt.input)), false), "%q", tt.input)
+		assert.Equal(t, tt.want, scanAll(t, bytes.NewReader([]byte(tt.input)), true), "%q, read a byte at a time", tt.input)
+	}
+}
+
+func TestDecodeEventTrailingData(t *testing.T) {
+	_, err := decodeEvent([]byte(`{"Action":"run","Test":"TestA"}  `))
+	assert.NoError(t, err)
+	_, err = decodeEvent([]byte(`{"Action":"run","Test":"TestA"}{"Action":"pass","Test":"TestA"}`))
+	assert.Error(t, err, "two events on one line")
+	_, err = decodeEvent([]byte(`{"Action":"run"} garbage`))
+	assert.Error(t, err)
+}
+
+func TestReadEventsInvalidUTF8(t *testing.T) {
+	buf := newStartupBuffer()
+	readEvents(strings.NewReader("bad \xff bytes\n{\"Action\":\"output\",\"Package\":\"a\",\"Output\":\"x \xfe\\n\"}\n"), buf, "")
+	require.Len(t, buf.msgs, 2)
+	assert.Equal(t, Unattributed("bad \uFFFD bytes"), buf.msgs[0])
+	assert.Equal(t, "x \uFFFD\n", buf.msgs[1].(TestEvent).Output)
+}
+
+func FuzzScanLines(f *testing.F) {
+	f.Add([]byte("a\nb\r\nc"), uint8(8))
+	f.Add([]byte("0123456é\n€€€\n"), uint8(4))
+	f.Add([]byte("{\"Action\":\"output\",\"Output\":\"\xff\"}\n"), uint8(16))
+	f.Fuzz(func(t *testing.T, input []byte, size uint8) {
+		defer func(size int) { maxLineSize = size }(maxLineSize)
+		maxLineSize = int(size%64) + utf8.UTFMax
+
+		lines := scanAll(t, bytes.NewReader(input), false)
+		assert.Equal(t, lines, scanAll(t, bytes.NewReader(input), true), "partial reads are put back together")
+		for _, line := range lines {
+			assert.LessOrEqual(t, len(line), maxLineSize)
+			if utf8.Valid(input) {
+				assert.True(t, utf8.ValidString(line), "%q split a rune", line)
+			}
+		}
+		if !bytes.Contains(input, []byte("\r")) {
+			assert.Equal(t, string(bytes.ReplaceAll(input, []byte("\n"), nil)), strings.Join(lines, ""))
+		}
+	})
+}
+
+func FuzzDecodeEvent(f *testing.F) {
+	f.Add([]byte(`{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"a","Test":"TestA","Output":"ok\n"}`))
+	f.Add([]byte(`{"Action":"pass","Package":"a","Elapsed":1.5}`))
+	f.Add([]byte(`{"Action":"output","Output":"` + "\xe2\x82" + `"}`))
+	f.Add([]byte(`{"Action":"run"}{"Action":"run"}`))
+	f.Fuzz(func(t *testing.T, line []byte) {
+		e, err := decodeEvent(line)
+		if err != nil {
+			return
+		}
+		assert.True(t, utf8.ValidString(e.Output))
+		encoded, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		again, err := decodeEvent(encoded)
+		require.NoError(t, err, "%s", encoded)
+		assert.True(t, e.Time.Equal(again.Time))
+		e.Time = again.Time
+		assert.Equal(t, e, again)
+	})
+}
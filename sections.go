@@ -0,0 +1,295 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// summarySection is an optional section of the final summary, printed between the
+// test tree and the totals line.  Sections are enabled and ordered with -sections.
+type summarySection interface {
+	Name() string
+	// Render returns the section's text, or "" if there is nothing to show.
+	Render(m *model) string
+}
+
+// sectionFunc adapts a function to a summarySection.
+type sectionFunc struct {
+	name   string
+	render func(m *model) string
+}
+
+func (s sectionFunc) Name() string           { return s.name }
+func (s sectionFunc) Render(m *model) string { return s.render(m) }
+
+// summarySections are all the available sections.  New sections should be
+// registered here.
+var summarySections = []summarySection{
+	sectionFunc{"empty", renderEmptyPackages},
+	sectionFunc{"cache", renderCacheSummary},
+	sectionFunc{"diagnostics", renderDiagnostics},
+	sectionFunc{"coverage", renderCoverage},
+	sectionFunc{"unattributed", renderUnattributed},
+	sectionFunc{"budget", renderBudget},
+	sectionFunc{"slowest", renderSlowest},
+	sectionFunc{"flaky", renderFlaky},
+	sectionFunc{"regressions", renderRegressions},
+	sectionFunc{"benchmarks", renderBenchmarks},
+	sectionFunc{"impact", renderImpact},
+	sectionFunc{"parallel", renderParallel},
+	sectionFunc{"new", renderNewTests},
+	sectionFunc{"expected", renderExpectedFailures},
+	sectionFunc{"reproduce", renderReproduce},
+	sectionFunc{"fuzz", renderFuzz},
+}
+
+// defaultSections is the default value of -sections.
+const defaultSections = "empty,cache,coverage,budget,flaky,expected,regressions,impact,new,slowest,benchmarks,fuzz,reproduce,unattributed,diagnostics"
+
+// findSection returns the registered section with the given name, or nil.
+func findSection(name string) summarySection {
+	for _, s := range summarySections {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func sectionNames() string {
+	var names []string
+	for _, s := range summarySections {
+		names = append(names, s.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseSections parses the value of -sections, a comma separated list of
+// section names.
+func parseSections(v string) ([]summarySection, error) {
+	var sections []summarySection
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		s := findSection(name)
+		if s == nil {
+			return nil, fmt.Errorf("unknown section %q, must be one of: %s", name, sectionNames())
+		}
+		sections = append(sections, s)
+	}
+	return sections, nil
+}
+
+func (m *model) renderSections(sb *strings.Builder) {
+	for _, s := range flags.sections {
+		out := s.Render(m)
+		if out == "" {
+			continue
+		}
+		sb.WriteString("\n")
+		sb.WriteString(strings.TrimRight(out, "\n"))
+		sb.WriteString("\n")
+	}
+}
+
+// emptyPackages returns the packages which compiled and passed, but didn't run
+// any tests.  This usually means a -run pattern or build tags filtered out
+// every test, which is easy to miss in CI.
+// Packages with no test files at all are not included.
+func (m *model) emptyPackages() []*node {
+	var empty []*node
+	for _, n := range m.root.children {
+		if n.status == "pass" && n.testCount == 0 {
+			empty = append(empty, n)
+		}
+	}
+	return empty
+}
+
+// cacheSummary returns how many of the packages with tests were cached,
+// and an estimate of the time saved: the sum of the cached packages' top
+// level test durations, as recorded in the cached output.
+func (m *model) cacheSummary() (cached, total int, saved time.Duration) {
+	for _, n := range m.root.children {
+		if !n.done || n.status == "skip" {
+			// packages with no test files
+			continue
+		}
+		total++
+		if n.cached {
+			cached++
+			saved += n.testElapsed
+		}
+	}
+	return cached, total, saved
+}
+
+func renderEmptyPackages(m *model) string {
+	empty := m.emptyPackages()
+	if len(empty) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Packages with no tests run:\n")
+	for _, n := range empty {
+		fmt.Fprintf(&sb, "  %s\t%s\n", displayName(n.name), gray.Render(n.msg))
+	}
+	return sb.String()
+}
+
+func renderCacheSummary(m *model) string {
+	cached, total, saved := m.cacheSummary()
+	if total == 0 {
+		return ""
+	}
+	return gray.Render(fmt.Sprintf("%d/%d package results cached, saved ~%s", cached, total, round(saved, 0)))
+}
+
+// checkTimestamp counts events whose timestamps are earlier than the previous event
+// in the same package.  Packages' events are interleaved, but each package's events
+// should be in order, otherwise the clock skewed or the stream was spliced together.
+func (m *model) checkTimestamp(pkg *node, ev TestEvent) {
+	if ev.Time.IsZero() {
+		return
+	}
+	if ev.Time.Before(pkg.lastTs) {
+		m.skewed++
+		return
+	}
+	pkg.lastTs = ev.Time
+}
+
+// unfinished returns the tests and packages which never received a terminal event.
+func (m *model) unfinished() []*node {
+	var nodes []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			if !c.done {
+				nodes = append(nodes, c)
+			}
+			walk(c)
+		}
+	}
+	walk(&m.root)
+	return nodes
+}
+
+// maxDiagnosticNames limits how many unfinished tests are named in the diagnostics.
+const maxDiagnosticNames = 5
+
+func renderDiagnostics(m *model) string {
+	var lines []string
+	if m.skewed > 0 {
+		lines = append(lines, fmt.Sprintf("%d events had timestamps earlier than the previous event in the same package, replay timing may be inaccurate", m.skewed))
+	}
+	if unfinished := m.unfinished(); len(unfinished) > 0 {
+		lines = append(lines, fmt.Sprintf("%d tests or packages never finished, the stream may be truncated:", len(unfinished)))
+		for i, n := range unfinished {
+			if i == maxDiagnosticNames {
+				lines = append(lines, fmt.Sprintf("  ...and %d more", len(unfinished)-i))
+				break
+			}
+			name := n.name
+			if n.isTest {
+				name = n.pkg().name + " " + name
+			}
+			lines = append(lines, "  "+displayName(name))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Diagnostics:\n  " + strings.Join(lines, "\n  ")
+}
+
+// maxUnattributedLines limits how many distinct unattributed lines are collected,
+// and maxUnattributedShown how many are shown in the summary.
+const (
+	maxUnattributedLines = 1000
+	maxUnattributedShown = 10
+)
+
+// unattributedOutput counts the distinct lines of input which weren't test
+// events, in the order they were first seen.
+type unattributedOutput struct {
+	total  int
+	lines  []string
+	counts map[string]int
+}
+
+func (u *unattributedOutput) add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	u.total++
+	if u.counts == nil {
+		u.counts = map[string]int{}
+	}
+	if _, ok := u.counts[line]; !ok {
+		if len(u.lines) == maxUnattributedLines {
+			return
+		}
+		u.lines = append(u.lines, line)
+	}
+	u.counts[line]++
+}
+
+func renderUnattributed(m *model) string {
+	u := &m.unattributed
+	if u.total == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Unattributed output (%d lines):\n", u.total)
+	for i, line := range u.lines {
+		if i == maxUnattributedShown {
+			fmt.Fprintf(&sb, "  ...and %d more distinct lines\n", len(u.lines)-i)
+			break
+		}
+		if c := u.counts[line]; c > 1 {
+			line += gray.Render(fmt.Sprintf(" (x%d)", c))
+		}
+		fmt.Fprintf(&sb, "  %s\n", line)
+	}
+	return sb.String()
+}
+
+// recordSlow keeps the -top-slow longest running tests, as they finish, so
+// the passed tests don't need to be kept in the tree.
+func (m *model) recordSlow(n *node) {
+	if flags.topSlow <= 0 {
+		return
+	}
+	// re-run tests are recorded again
+	m.slowest = slices.DeleteFunc(m.slowest, func(s *node) bool { return s == n })
+	i, _ := slices.BinarySearchFunc(m.slowest, n, func(a, b *node) int {
+		// longest first
+		return cmp.Compare(b.elapsed, a.elapsed)
+	})
+	if i >= flags.topSlow {
+		return
+	}
+	m.slowest = slices.Insert(m.slowest, i, n)
+	if len(m.slowest) > flags.topSlow {
+		m.slowest = m.slowest[:flags.topSlow]
+	}
+}
+
+func renderSlowest(m *model) string {
+	if len(m.slowest) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Slowest tests:\n")
+	for i, n := range m.slowest {
+		fmt.Fprintf(&sb, "%3d. %s\t%s %s\t%s\n", i+1, durationStyle(n.elapsed).Render(round(n.elapsed, 3).String()), m.icon(n), displayName(n.testName()), gray.Render(n.pkg().name))
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSections(t *testing.T) {
+	sections, err := parseSections("cache, empty")
+	require.NoError(t, err)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "cache", sections[0].Name())
+	assert.Equal(t, "empty", sections[1].Name())
+
+	sections, err = parseSections("")
+	require.NoError(t, err)
+	assert.Empty(t, sections)
+
+	_, err = parseSections("empty,bogus")
+	assert.ErrorContains(t, err, `unknown section "bogus"`)
+}
+
+func TestRenderSections(t *testing.T) {
+	keepFlags(t)
+	flags.sections = []summarySection{
+		sectionFunc{"a", func(*model) string { return "section a\n" }},
+		sectionFunc{"b", func(*model) string { return "" }},
+		sectionFunc{"c", func(*model) string { return "section c" }},
+	}
+
+	var sb strings.Builder
+	newModel().renderSections(&sb)
+	assert.Equal(t, "\nsection a\n\nsection c\n", sb.String())
+}
+
+func TestDiagnostics(t *testing.T) {
+	start := time.Now()
+	m := newModel()
+	feed(m, []TestEvent{
+		{Time: start, Action: "start", Package: "a"},
+		{Time: start.Add(2 * time.Second), Action: "start", Package: "b"},
+		{Time: start.Add(time.Second), Action: "run", Package: "a", Test: "TestA"},
+		{Time: start.Add(3 * time.Second), Action: "run", Package: "b", Test: "TestB"},
+		{Time: start.Add(2 * time.Second), Action: "pass", Package: "b", Test: "TestB"},
+	}...)
+
+	assert.Equal(t, 1, m.skewed, "only backwards within a package should count")
+	out := renderDiagnostics(m)
+	assert.Contains(t, out, "1 events had timestamps earlier")
+	assert.Contains(t, out, "3 tests or packages never finished")
+	assert.Contains(t, out, "  a TestA")
+
+	assert.Equal(t, "", renderDiagnostics(newModel()))
+}
+
+func TestUnattributedOutput(t *testing.T) {
+	m := newModel()
+	m.unattributed.add("# warning: foo\n")
+	m.unattributed.add("go: downloading x")
+	m.unattributed.add("# warning: foo")
+	m.unattributed.add("  ")
+
+	assert.Equal(t, 3, m.unattributed.total)
+	out := renderUnattributed(m)
+	assert.Contains(t, out, "Unattributed output (3 lines):\n  # warning: foo (x2)\n  go: downloading x\n")
+}
+
+func TestSlowest(t *testing.T) {
+	keepFlags(t)
+	flags.topSlow = 2
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 1},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a", Test: "TestB", Elapsed: 3},
+		{Action: "run", Package: "a", Test: "TestC"},
+		{Action: "run", Package: "a", Test: "TestC/sub"},
+		{Action: "pass", Package: "a", Test: "TestC/sub", Elapsed: 2},
+		{Action: "pass", Package: "a", Test: "TestC", Elapsed: 0.5},
+		{Action: "fail", Package: "a"},
+	}...)
+
+	out := renderSlowest(m)
+	assert.Equal(t, "Slowest tests:\n  1. 3s\t✖ TestB\ta\n  2. 2s\t✓ TestC/sub\ta\n", out)
+}
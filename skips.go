@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultSkipCause matches skip messages which blame a failure, like
+// "skipping: setup failed".
+const defaultSkipCause = `(?i)\bskip(?:ping|ped)?\b.*\bfailed\b`
+
+// linkSkip links a skipped test to the failure which caused it, if its output
+// matches -skip-cause.  The failure is named by the pattern's first capture group,
+// or is the package's most recent failure.  One root cause then reads as a
+// single failure which caused N skips, rather than N unrelated skips.
+func (m *model) linkSkip(n *node) {
+	if flags.skipCause == nil || n.outputBuf == nil {
+		return
+	}
+	matches := flags.skipCause.FindStringSubmatch(n.outputBuf.String())
+	if matches == nil {
+		return
+	}
+	pkg := n.pkg()
+	cause := pkg.lastFailed
+	if len(matches) > 1 && matches[1] != "" {
+		cause = pkg.findFailed(matches[1])
+	}
+	if cause == nil {
+		return
+	}
+	n.skipCause = cause
+	cause.causedSkips++
+}
+
+// testName returns the node's full test name, e.g. "TestA/sub".
+func (n *node) testName() string {
+	if !n.isTest || n.parent == nil || !n.parent.isTest {
+		return n.name
+	}
+	return n.parent.testName() + "/" + n.name
+}
+
+// findFailed returns the failed test in the package with the given full name,
+// or if there is none, the first failed test with the given name at any level.
+func (n *node) findFailed(name string) *node {
+	var byName *node
+	var walk func(n *node) *node
+	walk = func(n *node) *node {
+		for _, c := range n.children {
+			if c.status == "fail" {
+				if c.testName() == name {
+					return c
+				}
+				if byName == nil && c.name == name {
+					byName = c
+				}
+			}
+			if found := walk(c); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	if found := walk(n); found != nil {
+		return found
+	}
+	return byName
+}
+
+// skipLink returns the text linking a skip to its cause, or a failure to the
+// skips it caused.
+func (n *node) skipLink() string {
+	var links []string
+	if n.skipCause != nil {
+		links = append(links, "← "+n.skipCause.testName()+" failed")
+	}
+	switch n.causedSkips {
+	case 0:
+	case 1:
+		links = append(links, "caused 1 skip")
+	default:
+		links = append(links, fmt.Sprintf("caused %d skips", n.causedSkips))
+	}
+	return strings.Join(links, "  ")
+}
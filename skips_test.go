@@ -0,0 +1,57 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkSkips(t *testing.T) {
+	keepFlags(t)
+	flags.skipCause = regexp.MustCompile(defaultSkipCause)
+	flags.includeSkipped = true
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestSetup"},
+		{Action: "fail", Package: "a", Test: "TestSetup"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "    b_test.go:3: skipping: setup failed\n"},
+		{Action: "skip", Package: "a", Test: "TestB"},
+		{Action: "run", Package: "a", Test: "TestC"},
+		{Action: "output", Package: "a", Test: "TestC", Output: "    c_test.go:3: skipping: setup failed\n"},
+		{Action: "skip", Package: "a", Test: "TestC"},
+		{Action: "run", Package: "a", Test: "TestD"},
+		{Action: "output", Package: "a", Test: "TestD", Output: "    d_test.go:3: not on windows\n"},
+		{Action: "skip", Package: "a", Test: "TestD"},
+	}...)
+
+	pkg := m.root.children[0]
+	setup, _ := pkg.findChild([]string{"TestSetup"})
+	testB, _ := pkg.findChild([]string{"TestB"})
+	testD, _ := pkg.findChild([]string{"TestD"})
+	assert.Equal(t, 2, setup.causedSkips)
+	assert.Equal(t, setup, testB.skipCause)
+	assert.Nil(t, testD.skipCause)
+
+	out := m.String()
+	assert.Contains(t, out, "caused 2 skips")
+	assert.Contains(t, out, "← TestSetup failed")
+}
+
+func TestFindFailed(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestA/setup"},
+		{Action: "fail", Package: "a", Test: "TestA/setup"},
+	}...)
+
+	pkg := m.root.children[0]
+	assert.Equal(t, "TestA/setup", pkg.findFailed("TestA/setup").testName())
+	assert.Equal(t, "TestA/setup", pkg.findFailed("setup").testName())
+	assert.Nil(t, pkg.findFailed("TestB"))
+}
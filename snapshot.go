@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// snapshotVersion should be bumped when the snapshot format changes incompatibly.
+const snapshotVersion = 1
+
+// snapshot is the serialized form of the final test tree.
+type snapshot struct {
+	Version     int
+	Start       time.Time
+	End         time.Time
+	Passes      int
+	Fails       int
+	Skips       int
+	Aborts      int `json:",omitempty"`
+	Total       int
+	OverallFail bool
+	Packages    []*snapshotNode
+	// Paths maps the module-relative paths in the output back to the original
+	// absolute paths
+	Paths map[string]string `json:",omitempty"`
+}
+
+type snapshotNode struct {
+	Name             string
+	Status           string
+	IsTest           bool              `json:",omitempty"`
+	Elapsed          time.Duration     `json:",omitempty"`
+	FirstStart       time.Time         `json:",omitempty"`
+	StartOffset      time.Duration     `json:",omitempty"`
+	DoneTs           time.Time         `json:",omitempty"`
+	Msg              string            `json:",omitempty"`
+	Output           string            `json:",omitempty"`
+	TestCount        int               `json:",omitempty"`
+	ParallelCount    int               `json:",omitempty"`
+	Setup            time.Duration     `json:",omitempty"`
+	Waiting          time.Duration     `json:",omitempty"`
+	Env              []string          `json:",omitempty"`
+	ParallelDeclared int               `json:",omitempty"`
+	ParallelMax      int               `json:",omitempty"`
+	Meta             map[string]string `json:",omitempty"`
+	Children         []*snapshotNode   `json:",omitempty"`
+}
+
+// reportVerbosities are the valid values of -report-verbosity, which controls
+// which tests' output is included in reports, independent of the console.
+var reportVerbosities = []string{"failed", "normal", "all"}
+
+// includeOutput returns true if the node's output should be included in reports.
+// dropped is true if the node was hidden from the console.
+func includeOutput(n *node, dropped bool) bool {
+	switch flags.reportVerbosity {
+	case "failed":
+		return n.status == "fail"
+	case "all":
+		return true
+	default:
+		return !dropped
+	}
+}
+
+func toSnapshotNode(n *node, dropped bool) *snapshotNode {
+	sn := &snapshotNode{
+		Name:             n.name,
+		Status:           n.status,
+		IsTest:           n.isTest,
+		Elapsed:          n.elapsed,
+		FirstStart:       n.firstStart,
+		StartOffset:      n.startOffset,
+		DoneTs:           n.doneTs,
+		Msg:              n.msg,
+		TestCount:        n.testCount,
+		ParallelCount:    n.parallelCount,
+		Setup:            n.setup,
+		Waiting:          n.waiting,
+		Env:              n.env,
+		ParallelDeclared: n.parallelDeclared,
+		ParallelMax:      n.parallelMax,
+		Meta:             n.meta,
+	}
+	if includeOutput(n, dropped) {
+		sn.Output = n.log
+	}
+	// include the dropped children, so the snapshot has the complete tree.  The viewer
+	// applies its own filters.
+	for _, c := range n.children {
+		sn.Children = append(sn.Children, toSnapshotNode(c, dropped))
+	}
+	for _, c := range n.dropped {
+		sn.Children = append(sn.Children, toSnapshotNode(c, true))
+	}
+	return sn
+}
+
+func fromSnapshotNode(sn *snapshotNode, parent *node) *node {
+	n := &node{
+		name:             sn.Name,
+		status:           sn.Status,
+		isTest:           sn.IsTest,
+		elapsed:          sn.Elapsed,
+		firstStart:       sn.FirstStart,
+		startOffset:      sn.StartOffset,
+		doneTs:           sn.DoneTs,
+		msg:              sn.Msg,
+		log:              sn.Output,
+		testCount:        sn.TestCount,
+		parallelCount:    sn.ParallelCount,
+		setup:            sn.Setup,
+		waiting:          sn.Waiting,
+		env:              sn.Env,
+		parallelDeclared: sn.ParallelDeclared,
+		parallelMax:      sn.ParallelMax,
+		meta:             sn.Meta,
+		parent:           parent,
+		lvl:              parent.lvl + 1,
+	}
+	switch n.status {
+	case "pass", "fail", "skip", "aborted":
+		n.done = true
+	}
+	for _, c := range sn.Children {
+		n.children = append(n.children, fromSnapshotNode(c, n))
+	}
+	if n.lvl == 1 {
+		n.cached = strings.Contains(n.msg, "(cached)")
+		for _, c := range n.children {
+			n.testElapsed += c.elapsed
+		}
+	}
+	return n
+}
+
+func (m *model) snapshot() *snapshot {
+	s := &snapshot{
+		Version:     snapshotVersion,
+		Start:       m.start,
+		End:         m.end,
+		Passes:      m.passes,
+		Fails:       m.fails,
+		Skips:       m.skips,
+		Aborts:      m.aborts,
+		Total:       m.total,
+		OverallFail: m.overallFail,
+	}
+	if s.End.IsZero() {
+		s.End = time.Now()
+	}
+	if m.paths != nil && len(m.paths.abs) > 0 {
+		s.Paths = m.paths.abs
+	}
+	for _, n := range m.root.children {
+		s.Packages = append(s.Packages, toSnapshotNode(n, false))
+	}
+	return s
+}
+
+func writeSnapshot(m *model, path string) error {
+	b, err := json.MarshalIndent(m.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// readSnapshot loads a snapshot file into a finished model.
+func readSnapshot(path string) (*model, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s", s.Version, path)
+	}
+
+	m := newModel()
+	m.start, m.end = s.Start, s.End
+	m.passes, m.fails, m.skips, m.total = s.Passes, s.Fails, s.Skips, s.Total
+	m.aborts = s.Aborts
+	m.overallFail = s.OverallFail
+	m.done = true
+	for _, sn := range s.Packages {
+		m.root.children = append(m.root.children, fromSnapshotNode(sn, &m.root))
+	}
+	return m, nil
+}
+
+// viewMain implements the view subcommand, which opens a saved snapshot in the
+// interactive browser, then prints its output and summary like the end of a run.
+// When stdout isn't a terminal, or with -notty, it just prints them.  Accepts the
+// same display flags as the main command.
+func viewMain(args []string) int {
+	parseFlags(args)
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return 2
+	}
+
+	if err := setupTheme(flags.theme); err != nil {
+		fmt.Println("fatal:", err)
+		return 1
+	}
+
+	m, err := readSnapshot(flag.Arg(0))
+	if err != nil {
+		fmt.Println("fatal:", err)
+		return 1
+	}
+
+	if !flags.noTTY && isTerminal(os.Stdout) {
+		if err := browse(m); err != nil {
+			fmt.Println("fatal:", err)
+			return 1
+		}
+	}
+
+	for _, n := range m.root.children {
+		if n.log != "" {
+			fmt.Println(consoleText(highlightDiffs(strings.TrimRight(n.log, "\n"))))
+		}
+	}
+
+	m.root.processChildren(true, true)
+	fmt.Println(consoleText(m.String()))
+
+	if m.overallFail {
+		return 1
+	}
+	return 0
+}
+
+// browse runs the live view on a loaded snapshot, so its tree can be navigated, and
+// failures opened in the pager or acted on, until it's quit.
+func browse(m *model) error {
+	// the live view isn't shown once the run is done
+	m.done = false
+	m.browsing = true
+	p := tea.NewProgram(m)
+	m.prog = p
+	_, err := p.Run()
+	return err
+}
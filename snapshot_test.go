@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	keepFlags(t)
+	flags.snapshot = "x"
+
+	m := newModel()
+	events := []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 1},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestB", Elapsed: 2},
+		{Action: "fail", Package: "a", Elapsed: 3},
+	}
+	feed(m, events...)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, writeSnapshot(m, path))
+
+	loaded, err := readSnapshot(path)
+	require.NoError(t, err)
+
+	assert.True(t, loaded.overallFail)
+	assert.Equal(t, 1, loaded.fails)
+	assert.Equal(t, 2, loaded.total)
+	require.Len(t, loaded.root.children, 1)
+
+	pkg := loaded.root.children[0]
+	assert.Equal(t, "a", pkg.name)
+	assert.Contains(t, pkg.log, "boom")
+	// the passed test was dropped from the live tree, but is kept in the snapshot
+	assert.Len(t, pkg.children, 2)
+
+	loaded.root.processChildren(true, true)
+	assert.Len(t, pkg.children, 1)
+}
+
+func TestBrowseSnapshot(t *testing.T) {
+	keepFlags(t)
+	flags.snapshot = "x"
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "output", Package: "a", Test: "TestB", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a"},
+	}...)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, writeSnapshot(m, path))
+	loaded, err := readSnapshot(path)
+	require.NoError(t, err)
+
+	// what browse shows, without a terminal to run the program in
+	loaded.done = false
+	loaded.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	assert.Contains(t, loaded.View(), "TestA")
+	assert.NotContains(t, loaded.View(), "dropped")
+
+	loaded.Update(key("down"))
+	loaded.Update(key("down"))
+	require.NotNil(t, loaded.cursor)
+	assert.Equal(t, "TestB", loaded.cursor.name)
+	assert.Same(t, loaded.cursor, loaded.selected())
+
+	loaded.Update(key("q"))
+	assert.True(t, loaded.done)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// soundEvents are the events which can have a sound, see -sound.
+var soundEvents = []string{"fail", "done-pass", "done-fail"}
+
+// soundFlag parses values of -sound, like "done-fail=bell*3" or
+// "done-pass=~/sounds/tada.wav".
+func soundFlag(s string) error {
+	event, sound, ok := strings.Cut(s, "=")
+	if !ok || sound == "" {
+		return fmt.Errorf("must be <event>=<sound>, got %q", s)
+	}
+	if !slices.Contains(soundEvents, event) {
+		return fmt.Errorf("unknown event %q, must be one of %v", event, soundEvents)
+	}
+	if _, err := bellCount(sound); err != nil {
+		return err
+	}
+	if flags.sounds == nil {
+		flags.sounds = map[string]string{}
+	}
+	flags.sounds[event] = sound
+	return nil
+}
+
+// bellCount returns the number of times a sound rings the terminal bell, e.g. 3
+// for "bell*3", or 0 if the sound is a file.
+func bellCount(sound string) (int, error) {
+	rest, ok := strings.CutPrefix(sound, "bell")
+	switch {
+	case !ok:
+		return 0, nil
+	case rest == "":
+		return 1, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(rest, "*"))
+	if err != nil || !strings.HasPrefix(rest, "*") || n < 1 {
+		return 0, fmt.Errorf("invalid bell pattern %q, should be bell or bell*<n>", sound)
+	}
+	return n, nil
+}
+
+// bellInterval is the pause between bells, so repeated bells are heard separately.
+const bellInterval = 150 * time.Millisecond
+
+// playSound plays the sound for the event, if any.  Bells are written to the
+// terminal, and files are played in the background with the platform's player.
+func playSound(event string) {
+	sound, ok := flags.sounds[event]
+	if !ok {
+		return
+	}
+	if n, _ := bellCount(sound); n > 0 {
+		for i := range n {
+			if i > 0 {
+				time.Sleep(bellInterval)
+			}
+			_, _ = os.Stdout.WriteString("\a")
+		}
+		return
+	}
+	if strings.HasPrefix(sound, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			sound = home + sound[1:]
+		}
+	}
+	if cmd := soundPlayer(sound); cmd != nil {
+		_ = cmd.Start()
+	}
+}
+
+// soundCmd plays the sound for the event from the program.
+func soundCmd(event string) tea.Cmd {
+	if _, ok := flags.sounds[event]; !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		playSound(event)
+		return nil
+	}
+}
+
+// soundPlayer returns the command which plays a sound file, or nil if there's no
+// player installed.
+func soundPlayer(file string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("afplay", file)
+	case "windows":
+		return exec.Command("powershell", "-c", fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", strings.ReplaceAll(file, "'", "''")))
+	}
+	for _, player := range []string{"paplay", "aplay", "play"} {
+		if _, err := exec.LookPath(player); err == nil {
+			return exec.Command(player, file)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoundFlag(t *testing.T) {
+	keepFlags(t)
+
+	assert.NoError(t, soundFlag("fail=bell"))
+	assert.NoError(t, soundFlag("done-fail=bell*3"))
+	assert.NoError(t, soundFlag("done-pass=~/tada.wav"))
+	assert.Equal(t, map[string]string{"fail": "bell", "done-fail": "bell*3", "done-pass": "~/tada.wav"}, flags.sounds)
+
+	assert.Error(t, soundFlag("fail"))
+	assert.Error(t, soundFlag("start=bell"))
+	assert.Error(t, soundFlag("fail=bell3"))
+	assert.Error(t, soundFlag("fail=bell*0"))
+}
+
+func TestBellCount(t *testing.T) {
+	for sound, want := range map[string]int{"bell": 1, "bell*2": 2, "tada.wav": 0} {
+		n, err := bellCount(sound)
+		assert.NoError(t, err)
+		assert.Equal(t, want, n, sound)
+	}
+}
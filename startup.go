@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// startupBuffer holds the input read before the live view starts.  If the whole
+// run finishes within -tui-delay, the live view is skipped, avoiding the flicker of
+// starting and immediately exiting the TUI.  Otherwise, the buffered input is
+// forwarded to the program, followed by the rest of the input.
+type startupBuffer struct {
+	mu   sync.Mutex
+	prog *tea.Program
+	msgs []tea.Msg
+	// done is closed when the end of the input is buffered
+	done      chan struct{}
+	closeDone sync.Once
+}
+
+func newStartupBuffer() *startupBuffer {
+	return &startupBuffer{done: make(chan struct{})}
+}
+
+func (b *startupBuffer) Send(msg tea.Msg) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.prog != nil {
+		b.prog.Send(msg)
+		return
+	}
+	b.msgs = append(b.msgs, msg)
+	switch msg.(type) {
+	case Done, error:
+		// re-runs send Done again
+		b.closeDone.Do(func() { close(b.done) })
+	}
+}
+
+// forward sends the buffered input to p, and any input after that.  Since
+// sending blocks until the program is running, this should be called in a
+// goroutine.
+func (b *startupBuffer) forward(p *tea.Program) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, msg := range b.msgs {
+		p.Send(msg)
+	}
+	b.msgs = nil
+	b.prog = p
+}
+
+// finish runs the buffered input through the model without the live view,
+// printing output directly.
+func (b *startupBuffer) finish(m *model) {
+	// running the commands may send more input, e.g. re-runs of failed tests
+	for {
+		b.mu.Lock()
+		msgs := b.msgs
+		b.msgs = nil
+		b.mu.Unlock()
+		if len(msgs) == 0 {
+			return
+		}
+
+		for _, msg := range msgs {
+			_, cmd := m.Update(msg)
+			runCmd(cmd)
+		}
+	}
+}
+
+// runCmd runs a command synchronously, for when there is no program to run it.
+// Only printing and side effects matter here, so messages returned by the command
+// are dropped.
+func runCmd(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			runCmd(c)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartupBufferFinish(t *testing.T) {
+	b := newStartupBuffer()
+	b.Send(TestEvent{Action: "start", Package: "a"})
+	b.Send(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	b.Send(Unattributed("not json"))
+	b.Send(TestEvent{Action: "pass", Package: "a", Test: "TestA"})
+
+	select {
+	case <-b.done:
+		t.Fatal("done before the end of the input")
+	default:
+	}
+
+	b.Send(Done{})
+	<-b.done
+
+	m := newModel()
+	b.finish(m)
+	assert.True(t, m.done)
+	assert.Equal(t, 1, m.passes)
+	assert.Equal(t, 1, m.unattributed.total)
+	assert.Empty(t, b.msgs)
+}
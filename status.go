@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statusFileInterval throttles how often the -status-file is rewritten.
+const statusFileInterval = 500 * time.Millisecond
+
+// maxStatusFailures limits the number of failures listed in the status menu.
+const maxStatusFailures = 20
+
+// statusText renders the run's status in the plugin format shared by xbar, SwiftBar,
+// and Argos: the first line is shown in the menu bar, the lines after "---" in its
+// menu.  A plugin script which just prints the file is enough to show it, e.g.
+//
+//	#!/bin/sh
+//	cat /tmp/gotestpretty.status
+func (m *model) statusText() string {
+	var sb strings.Builder
+
+	icon, color := "…", ""
+	switch {
+	case m.fails > 0 || m.overallFail:
+		icon, color = "✖", "red"
+	case m.done:
+		icon, color = "✓", "green"
+	}
+	fmt.Fprintf(&sb, "%s %d passed", icon, m.passes)
+	if m.fails > 0 {
+		fmt.Fprintf(&sb, ", %d failed", m.fails)
+	}
+	if color != "" {
+		fmt.Fprintf(&sb, " | color=%s", color)
+	}
+	sb.WriteString("\n---\n")
+
+	status := "running"
+	if m.done {
+		status = "finished"
+	}
+	fmt.Fprintf(&sb, "%s: %d tests, %d skipped in %s\n", status, m.total, m.skips, round(scaledTimeSince(m.start), 0))
+
+	failures := m.failures()
+	for i, n := range failures {
+		if i == maxStatusFailures {
+			fmt.Fprintf(&sb, "...and %d more\n", len(failures)-i)
+			break
+		}
+		// "|" separates the text from the options in the plugin format
+		name := strings.ReplaceAll(n.pkg().name+" "+n.name, "|", "¦")
+		fmt.Fprintf(&sb, "%s | color=red%s\n", name, m.failureAction(n))
+	}
+	return sb.String()
+}
+
+// failureAction returns the plugin options which jump to the test's failing line
+// when its menu item is clicked: $EDITOR opens it in a terminal, or if $EDITOR isn't
+// set, the file is opened in its default app.  Returns "" if the file isn't found.
+func (m *model) failureAction(n *node) string {
+	file, line := failingLine(n.log)
+	if file == "" {
+		return ""
+	}
+	if file = m.sourceFile(file, n.pkg().name); file == "" {
+		return ""
+	}
+	// the menu bar app doesn't have the shell's PATH
+	if editor, err := exec.LookPath(os.Getenv("EDITOR")); err == nil {
+		return fmt.Sprintf(" bash=%s param1=+%s param2=%s terminal=true", pluginParam(editor), line, pluginParam(file))
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(file)}
+	return " href=" + pluginParam(u.String())
+}
+
+// currentModule is the directory and module path of the go.mod containing the
+// working directory.
+var currentModule = sync.OnceValues(func() (root, module string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", ""
+	}
+	return findModule(wd)
+})
+
+// sourceFile returns the absolute path of a file named in pkg's test output, or ""
+// if it isn't found.  Test output names files relative to the package directory,
+// unless they were rewritten relative to the module, see pathNormalizer.
+func (m *model) sourceFile(file, pkg string) string {
+	if m.paths != nil {
+		if abs, ok := m.paths.abs[file]; ok {
+			file = abs
+		}
+	}
+	if !filepath.IsAbs(file) {
+		root, module := currentModule()
+		rel, ok := strings.CutPrefix(pkg, module)
+		if module == "" || !ok || (rel != "" && !strings.HasPrefix(rel, "/")) {
+			return ""
+		}
+		file = filepath.Join(filepath.FromSlash(root+rel), file)
+	}
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+	return file
+}
+
+// pluginParam quotes a plugin option's value if it has spaces.
+func pluginParam(s string) string {
+	if strings.ContainsAny(s, " \t\"") {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return s
+}
+
+// failures returns the failed tests in the tree.
+func (m *model) failures() []*node {
+	var failed []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			if c.isTest && c.status == "fail" {
+				failed = append(failed, c)
+			}
+			walk(c)
+		}
+	}
+	walk(&m.root)
+	return failed
+}
+
+// updateStatusFile rewrites the -status-file, if enabled.  Unless force is set,
+// writes are throttled.
+func (m *model) updateStatusFile(force bool) {
+	if flags.statusFile == "" || (!force && since(m.statusWritten) < statusFileInterval) {
+		return
+	}
+	m.statusWritten = now()
+
+	// write to a temp file and rename, so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(flags.statusFile), ".gotestpretty-status-*")
+	if err == nil {
+		_, err = tmp.WriteString(m.statusText())
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), flags.statusFile)
+		}
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		log.Println("error writing status file:", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusFile(t *testing.T) {
+	keepFlags(t)
+	flags.statusFile = filepath.Join(t.TempDir(), "status")
+
+	m := newModel()
+	for _, ev := range []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "Test|B"},
+		{Action: "fail", Package: "a", Test: "Test|B"},
+	} {
+		m.Update(ev)
+	}
+	m.updateStatusFile(true)
+
+	b, err := os.ReadFile(flags.statusFile)
+	require.NoError(t, err)
+	s := string(b)
+	assert.Contains(t, s, "✖ 1 passed, 1 failed | color=red\n---\nrunning: 2 tests")
+	assert.Contains(t, s, "a Test¦B | color=red\n")
+}
+
+func TestStatusFailureAction(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "github.com/ansel1/gotestpretty"},
+		{Action: "run", Package: "github.com/ansel1/gotestpretty", Test: "TestA"},
+		{Action: "output", Package: "github.com/ansel1/gotestpretty", Test: "TestA", Output: "    status_test.go:12: boom\n"},
+		{Action: "fail", Package: "github.com/ansel1/gotestpretty", Test: "TestA"},
+		{Action: "run", Package: "github.com/ansel1/gotestpretty", Test: "TestB"},
+		{Action: "output", Package: "github.com/ansel1/gotestpretty", Test: "TestB", Output: "    missing_test.go:3: boom\n"},
+		{Action: "fail", Package: "github.com/ansel1/gotestpretty", Test: "TestB"},
+	}...)
+	file, err := filepath.Abs("status_test.go")
+	require.NoError(t, err)
+	failures := m.failures()
+	require.Len(t, failures, 2)
+
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, " href=file://"+filepath.ToSlash(file), m.failureAction(failures[0]))
+
+	t.Setenv("EDITOR", "sh")
+	sh, err := exec.LookPath("sh")
+	require.NoError(t, err)
+	assert.Equal(t, " bash="+sh+" param1=+12 param2="+file+" terminal=true", m.failureAction(failures[0]))
+
+	// the file isn't found, so there's nothing to open
+	assert.Empty(t, m.failureAction(failures[1]))
+}
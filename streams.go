@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ansel1/gotestpretty/pretty"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// -f can be repeated to read several go test -json streams at once, e.g. the
+// output of test shards run in parallel, or several FIFOs.  Their events are
+// merged into one run, keyed by package, and each package is labeled with the
+// stream it came from.  A package run by more than one stream is shown once per
+// stream, see pretty.Injector.
+
+// inputFlag parses -f.  The first file is also kept in flags.infile, which is
+// checked wherever it matters that the input is a file rather than stdin.
+func inputFlag(path string) error {
+	flags.infiles = append(flags.infiles, path)
+	if flags.infile == "" {
+		flags.infile = path
+	}
+	return nil
+}
+
+// streamLabels returns the labels of the input files: their names, without the
+// extension, or their paths if that's ambiguous.
+func streamLabels(paths []string) []string {
+	labels := make([]string, len(paths))
+	counts := map[string]int{}
+	for i, path := range paths {
+		base := filepath.Base(path)
+		labels[i] = strings.TrimSuffix(base, filepath.Ext(base))
+		counts[labels[i]]++
+	}
+	for i, label := range labels {
+		if counts[label] > 1 {
+			labels[i] = paths[i]
+		}
+	}
+	return labels
+}
+
+// processStreams reads the input files concurrently, labeling their events, until
+// all of them reach EOF.  They're merged by a pretty.Injector, so Done is sent once,
+// after the last stream ends.
+func processStreams(p sender, paths []string) {
+	var files []*os.File
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			p.Send(err)
+			return
+		}
+		files = append(files, f)
+	}
+
+	inj := pretty.NewInjector(func(e TestEvent) { p.Send(e) }, func() { p.Send(Done{}) })
+	labels := streamLabels(paths)
+	// every source is registered before any of them can be closed
+	sources := make([]*pretty.Source, len(files))
+	for i := range files {
+		sources[i] = inj.Source(labels[i])
+	}
+
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer src.Close()
+			defer files[i].Close()
+			readEvents(bufio.NewReader(files[i]), sourceSender{src: src, p: p}, labels[i])
+		}()
+	}
+	wg.Wait()
+}
+
+// sourceSender sends a stream's events through its injector source, and other
+// messages, like unattributed lines, straight to p.
+type sourceSender struct {
+	src *pretty.Source
+	p   sender
+}
+
+func (s sourceSender) Send(msg tea.Msg) {
+	if e, ok := msg.(TestEvent); ok {
+		s.src.Send(e)
+		return
+	}
+	s.p.Send(msg)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLabels(t *testing.T) {
+	assert.Equal(t, []string{"shard1", "shard2"}, streamLabels([]string{"out/shard1.json", "shard2.json"}))
+	assert.Equal(t, []string{"a/run.json", "b/run.json", "c"}, streamLabels([]string{"a/run.json", "b/run.json", "c"}))
+}
+
+func TestProcessStreams(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+		return path
+	}
+	a := write("shard1.json",
+		`{"Action":"start","Package":"a"}`,
+		`{"Action":"run","Package":"a","Test":"TestA"}`,
+		`{"Action":"pass","Package":"a","Test":"TestA"}`,
+		`{"Action":"pass","Package":"a"}`,
+	)
+	b := write("shard2.json",
+		`{"Action":"start","Package":"b"}`,
+		`{"Action":"run","Package":"b","Test":"TestB"}`,
+		`{"Action":"fail","Package":"b","Test":"TestB"}`,
+		`{"Action":"fail","Package":"b"}`,
+	)
+
+	buf := newStartupBuffer()
+	processStreams(buf, []string{a, b})
+	<-buf.done
+
+	var dones int
+	for _, msg := range buf.msgs {
+		if _, ok := msg.(Done); ok {
+			dones++
+		}
+	}
+	assert.Equal(t, 1, dones, "done is sent once, after every stream ends")
+	assert.IsType(t, Done{}, buf.msgs[len(buf.msgs)-1])
+
+	m := newModel()
+	buf.finish(m)
+	assert.Equal(t, 2, m.total)
+	assert.Equal(t, 1, m.fails)
+	require.Len(t, m.root.children, 2)
+	for _, pkg := range m.root.children {
+		var sb strings.Builder
+		m.println(pkg, &sb)
+		switch pkg.name {
+		case "a":
+			assert.Equal(t, "shard1", pkg.stream)
+			assert.Contains(t, sb.String(), "[shard1]")
+		case "b":
+			assert.Equal(t, "shard2", pkg.stream)
+			assert.Contains(t, sb.String(), "[shard2]")
+		}
+	}
+}
+
+func TestProcessStreamsMissingFile(t *testing.T) {
+	buf := newStartupBuffer()
+	processStreams(buf, []string{filepath.Join(t.TempDir(), "missing.json")})
+	<-buf.done
+	require.Len(t, buf.msgs, 1)
+	assert.Error(t, buf.msgs[0].(error))
+}
+
+func TestProcessSendsDoneOnce(t *testing.T) {
+	keepFlags(t)
+	path := filepath.Join(t.TempDir(), "test.out")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Action":"start","Package":"a"}`+"\n"+`{"Action":"pass","Package":"a"}`+"\n"), 0o644))
+	flags.infile, flags.infiles = path, []string{path}
+
+	buf := newStartupBuffer()
+	process(buf)
+	<-buf.done
+
+	var dones int
+	for _, msg := range buf.msgs {
+		if _, ok := msg.(Done); ok {
+			dones++
+		}
+	}
+	assert.Equal(t, 1, dones, "a second Done would start -rerun-fails' re-run, or end it early")
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// testStats aggregates the results of a single test across multiple runs.
+type testStats struct {
+	pkg, name            string
+	passes, fails, skips int
+	durations            []time.Duration
+}
+
+func (t *testStats) runs() int {
+	return t.passes + t.fails
+}
+
+// flaky is true if the test both passed and failed.
+func (t *testStats) flaky() bool {
+	return t.passes > 0 && t.fails > 0
+}
+
+func (t *testStats) failRate() float64 {
+	if t.runs() == 0 {
+		return 0
+	}
+	return float64(t.fails) / float64(t.runs())
+}
+
+// percentile returns the p-th percentile (0-100) of the recorded durations.
+func (t *testStats) percentile(p int) time.Duration {
+	if len(t.durations) == 0 {
+		return 0
+	}
+	d := slices.Clone(t.durations)
+	slices.Sort(d)
+	i := (len(d) - 1) * p / 100
+	return d[i]
+}
+
+// stressStats aggregates test results across multiple `go test` runs.
+type stressStats struct {
+	runs  int
+	tests map[string]*testStats
+	// pkgFails counts the runs in which a package failed without a failing test,
+	// e.g. because TestMain exited non-zero, and buildFails the runs in which a
+	// package failed to build
+	pkgFails   map[string]int
+	buildFails map[string]int
+	// failures counts all the failures recorded, and testFailed is set for a
+	// package when one of its tests failed, until the package's result
+	failures   int
+	testFailed map[string]bool
+}
+
+func newStressStats() *stressStats {
+	return &stressStats{
+		tests:      map[string]*testStats{},
+		pkgFails:   map[string]int{},
+		buildFails: map[string]int{},
+		testFailed: map[string]bool{},
+	}
+}
+
+// record adds the result of a test event.  Only terminal events are recorded:
+// the results of tests, and the failures of packages and builds which aren't
+// explained by a failed test.
+func (s *stressStats) record(ev TestEvent) {
+	if ev.Action == "build-fail" {
+		s.buildFails[ev.ImportPath]++
+		s.failures++
+		return
+	}
+	if ev.Test == "" {
+		s.recordPackage(ev)
+		return
+	}
+	switch ev.Action {
+	case "pass", "fail", "skip":
+	default:
+		return
+	}
+
+	key := ev.Package + " " + ev.Test
+	t := s.tests[key]
+	if t == nil {
+		t = &testStats{pkg: ev.Package, name: ev.Test}
+		s.tests[key] = t
+	}
+
+	switch ev.Action {
+	case "pass":
+		t.passes++
+	case "fail":
+		t.fails++
+		s.failures++
+		s.testFailed[ev.Package] = true
+	case "skip":
+		t.skips++
+		return
+	}
+	t.durations = append(t.durations, time.Duration(ev.Elapsed*float64(time.Second)))
+}
+
+func (s *stressStats) recordPackage(ev TestEvent) {
+	switch ev.Action {
+	case "pass", "skip":
+		delete(s.testFailed, ev.Package)
+	case "fail":
+		switch {
+		case s.testFailed[ev.Package]:
+			delete(s.testFailed, ev.Package)
+		case ev.FailedBuild != "":
+			// already recorded by its build-fail event
+		default:
+			s.pkgFails[ev.Package]++
+			s.failures++
+		}
+	}
+}
+
+// sorted returns the tests sorted by fail rate descending, then by name.
+func (s *stressStats) sorted() []*testStats {
+	tests := make([]*testStats, 0, len(s.tests))
+	for _, t := range s.tests {
+		tests = append(tests, t)
+	}
+	slices.SortFunc(tests, func(a, b *testStats) int {
+		if a.failRate() != b.failRate() {
+			if a.failRate() > b.failRate() {
+				return -1
+			}
+			return 1
+		}
+		if i := strings.Compare(a.pkg, b.pkg); i != 0 {
+			return i
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return tests
+}
+
+func (s *stressStats) failed() bool {
+	if len(s.pkgFails) > 0 || len(s.buildFails) > 0 {
+		return true
+	}
+	for _, t := range s.tests {
+		if t.fails > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// report writes a table of per test statistics to w.
+func (s *stressStats) report(w io.Writer) {
+	tests := s.sorted()
+
+	var flaky, failing int
+	for _, t := range tests {
+		switch {
+		case t.flaky():
+			flaky++
+		case t.fails > 0:
+			failing++
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEST\tRUNS\tPASS\tFAIL%\tMIN\tP50\tP90\tMAX\t")
+	for _, t := range tests {
+		if t.runs() == 0 {
+			continue
+		}
+		mark := ""
+		if t.flaky() {
+			mark = " (flaky)"
+		}
+		fmt.Fprintf(tw, "%s %s%s\t%d\t%d\t%.1f%%\t%s\t%s\t%s\t%s\t\n",
+			t.pkg, t.name, mark, t.runs(), t.passes, t.failRate()*100,
+			round(t.percentile(0), 1), round(t.percentile(50), 1), round(t.percentile(90), 1), round(t.percentile(100), 1))
+	}
+	_ = tw.Flush()
+
+	if len(s.pkgFails) > 0 || len(s.buildFails) > 0 {
+		fmt.Fprintln(w, "\nFailed outside of a test:")
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, pkg := range slices.Sorted(maps.Keys(s.buildFails)) {
+			fmt.Fprintf(tw, "  %s\tbuild failed in %d runs\n", pkg, s.buildFails[pkg])
+		}
+		for _, pkg := range slices.Sorted(maps.Keys(s.pkgFails)) {
+			fmt.Fprintf(tw, "  %s\tfailed in %d runs\n", pkg, s.pkgFails[pkg])
+		}
+		_ = tw.Flush()
+	}
+
+	fmt.Fprintf(w, "\n%d runs, %d tests, %d flaky, %d always failed\n", s.runs, len(tests), flaky, failing)
+}
+
+// stressMain implements the stress subcommand, which runs `go test` repeatedly
+// and reports pass rates and duration distributions per test.
+func stressMain(args []string) int {
+	fs := flag.NewFlagSet("stress", flag.ExitOnError)
+	count := fs.Int("n", 0, "Number of times to run the tests\nDefaults to 10 if -duration is not set")
+	duration := fs.Duration("duration", 0, "Keep running the tests until this much time has elapsed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n\t%s stress [flags] [packages] [go test flags]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Runs 'go test -json' repeatedly, and reports pass rates, flakiness, and durations per test.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if *count == 0 && *duration == 0 {
+		*count = 10
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats := newStressStats()
+	start := time.Now()
+
+	for {
+		if *count > 0 && stats.runs >= *count {
+			break
+		}
+		if *duration > 0 && time.Since(start) >= *duration {
+			break
+		}
+
+		fmt.Fprintf(os.Stderr, "run %d...", stats.runs+1)
+		err := stressRun(ctx, fs.Args(), stats)
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, " interrupted")
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, " error:", err)
+			return 1
+		}
+		stats.runs++
+		fmt.Fprintln(os.Stderr, " done")
+	}
+
+	stats.report(os.Stdout)
+
+	if stats.failed() {
+		return 1
+	}
+	return 0
+}
+
+// stressRun runs `go test -json` once and records the results.  Failures are not
+// returned as errors, only go test failing without any failure being recorded,
+// e.g. because of bad flags, or failing to run at all.
+func stressRun(ctx context.Context, args []string, stats *stressStats) error {
+	cmd := exec.CommandContext(ctx, "go", append([]string{"test", "-json", "-count=1"}, args...)...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	failures := stats.failures
+	s := bufio.NewScanner(out)
+	for s.Scan() {
+		if ev, err := decodeEvent(s.Bytes()); err == nil {
+			stats.record(ev)
+		}
+	}
+
+	// a non-zero exit code just means something failed, if it was recorded
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); err != nil && (!errors.As(err, &exitErr) || stats.failures == failures) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStressStats(t *testing.T) {
+	s := newStressStats()
+
+	for i, action := range []string{"pass", "fail", "pass", "pass"} {
+		s.record(TestEvent{Action: "run", Package: "a", Test: "TestFlaky"})
+		s.record(TestEvent{Action: action, Package: "a", Test: "TestFlaky", Elapsed: float64(i + 1)})
+		s.record(TestEvent{Action: "pass", Package: "a", Test: "TestStable", Elapsed: 0.5})
+		s.record(TestEvent{Action: "skip", Package: "a", Test: "TestSkipped"})
+		s.record(TestEvent{Action: action, Package: "a"})
+		s.runs++
+	}
+
+	flaky := s.tests["a TestFlaky"]
+	assert.Equal(t, 4, flaky.runs())
+	assert.Equal(t, 1, flaky.fails)
+	assert.True(t, flaky.flaky())
+	assert.Equal(t, 0.25, flaky.failRate())
+	assert.Equal(t, time.Second, flaky.percentile(0))
+	assert.Equal(t, 2*time.Second, flaky.percentile(50))
+	assert.Equal(t, 4*time.Second, flaky.percentile(100))
+
+	stable := s.tests["a TestStable"]
+	assert.False(t, stable.flaky())
+	assert.Equal(t, 4, stable.passes)
+
+	assert.Equal(t, 4, s.tests["a TestSkipped"].skips)
+	assert.Len(t, s.tests, 3, "package level events should not be recorded")
+
+	sorted := s.sorted()
+	assert.Equal(t, "TestFlaky", sorted[0].name)
+	assert.True(t, s.failed())
+
+	var sb strings.Builder
+	s.report(&sb)
+	assert.Contains(t, sb.String(), "a TestFlaky (flaky)")
+	assert.NotContains(t, sb.String(), "TestSkipped")
+	assert.Contains(t, sb.String(), "4 runs, 3 tests, 1 flaky, 0 always failed")
+	assert.NotContains(t, sb.String(), "Failed outside of a test")
+}
+
+func TestStressStatsPackageFailures(t *testing.T) {
+	s := newStressStats()
+
+	// b's tests pass, but the package fails, e.g. because TestMain exits non-zero
+	s.record(TestEvent{Action: "pass", Package: "b", Test: "TestB"})
+	s.record(TestEvent{Action: "fail", Package: "b"})
+	s.record(TestEvent{Action: "build-output", ImportPath: "c [c.test]", Output: "c.go:1: syntax error\n"})
+	s.record(TestEvent{Action: "build-fail", ImportPath: "c [c.test]"})
+	s.record(TestEvent{Action: "fail", Package: "c", FailedBuild: "c [c.test]"})
+	s.runs++
+
+	assert.Equal(t, map[string]int{"b": 1}, s.pkgFails)
+	assert.Equal(t, map[string]int{"c [c.test]": 1}, s.buildFails)
+	assert.True(t, s.failed())
+
+	var sb strings.Builder
+	s.report(&sb)
+	assert.Contains(t, sb.String(), "Failed outside of a test:\n  c [c.test]  build failed in 1 runs\n  b           failed in 1 runs\n")
+}
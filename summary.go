@@ -0,0 +1,148 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+)
+
+// summarySlowest is the number of tests listed in the summary's Slowest.
+const summarySlowest = 10
+
+// summary is the machine readable summary of a run, written by -summary-json, for
+// scripts which would rather not parse the go test -json stream themselves.
+// Elapsed times are in seconds, like go test's.
+type summary struct {
+	Result   string
+	Start    time.Time
+	End      time.Time
+	Elapsed  float64
+	Tests    int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Aborted  int `json:",omitempty"`
+	XFailed  int `json:",omitempty"`
+	Packages []summaryPackage
+	Slowest  []summaryTest
+}
+
+type summaryPackage struct {
+	Name     string
+	Status   string
+	Elapsed  float64
+	Coverage *float64 `json:",omitempty"`
+	Shuffle  string   `json:",omitempty"`
+	Tests    []summaryTest
+	// testCount is the number of tests which finished.  Tests may list fewer, when
+	// the passed tests were dropped, because no reports were requested.
+	testCount int
+}
+
+type summaryTest struct {
+	// Package is only set in Slowest
+	Package string `json:",omitempty"`
+	Name    string
+	Status  string
+	Elapsed float64
+	// Runs and Passes are only set for tests which ran more than once, e.g. with -count
+	Runs   int  `json:",omitempty"`
+	Passes int  `json:",omitempty"`
+	Flaky  bool `json:",omitempty"`
+	// Parallel is set for tests which called t.Parallel()
+	Parallel bool `json:",omitempty"`
+	// New is set for tests added in the working tree, see -new-tests
+	New bool `json:",omitempty"`
+	// Output is only included for failed tests
+	Output string `json:",omitempty"`
+}
+
+func (m *model) summary() summary {
+	end := m.end
+	if end.IsZero() {
+		end = now()
+	}
+	s := summary{
+		Result:   "pass",
+		Start:    m.start,
+		End:      end,
+		Elapsed:  end.Sub(m.start).Seconds(),
+		Tests:    m.total,
+		Passed:   m.passes,
+		Failed:   m.fails,
+		Skipped:  m.skips,
+		Aborted:  m.aborts,
+		XFailed:  m.xfails,
+		Packages: []summaryPackage{},
+		Slowest:  []summaryTest{},
+	}
+	if m.overallFail {
+		s.Result = "fail"
+	}
+
+	var all []summaryTest
+	for _, pkg := range m.root.children {
+		sp := summaryPackage{
+			Name:    pkg.name,
+			Status:  pkg.status,
+			Elapsed: pkg.elapsed.Seconds(),
+			Tests:   []summaryTest{},
+			Shuffle: pkg.shuffle,
+
+			testCount: pkg.testCount,
+		}
+		if pkg.hasCoverage {
+			sp.Coverage = &pkg.coverage
+		}
+		var walk func(n *node)
+		walk = func(n *node) {
+			for _, c := range append(n.children, n.dropped...) {
+				t := summaryTest{
+					Name:     c.testName(),
+					Status:   c.status,
+					Elapsed:  c.elapsed.Seconds(),
+					Flaky:    c.flaky(),
+					Parallel: c.parallel,
+					New:      c.isNew,
+				}
+				if c.runs() > 1 {
+					t.Runs, t.Passes = c.runs(), c.passedRuns
+				}
+				if c.status == "fail" {
+					t.Output = c.log
+				}
+				sp.Tests = append(sp.Tests, t)
+				t.Package, t.Output = pkg.name, ""
+				all = append(all, t)
+				walk(c)
+			}
+		}
+		walk(pkg)
+		slices.SortStableFunc(sp.Tests, func(a, b summaryTest) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		s.Packages = append(s.Packages, sp)
+	}
+
+	slices.SortStableFunc(all, func(a, b summaryTest) int {
+		return cmp.Compare(b.Elapsed, a.Elapsed)
+	})
+	s.Slowest = append(s.Slowest, all[:min(len(all), summarySlowest)]...)
+	return s
+}
+
+// writeSummary writes the summary to path, or stdout if path is "-".
+func writeSummary(m *model, path string) error {
+	b, err := json.MarshalIndent(m.summary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if path == "-" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	keepFlags(t)
+	flags.summaryJSON = filepath.Join(t.TempDir(), "summary.json")
+
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 1},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "run", Package: "a", Test: "TestB/sub"},
+		{Action: "output", Package: "a", Test: "TestB/sub", Output: "boom\n"},
+		{Action: "fail", Package: "a", Test: "TestB/sub", Elapsed: 2},
+		{Action: "fail", Package: "a", Test: "TestB", Elapsed: 2},
+		{Action: "output", Package: "a", Output: "coverage: 50.0% of statements\n"},
+		{Action: "fail", Package: "a", Elapsed: 3},
+	}...)
+	m.root.processChildren(true, true)
+
+	require.NoError(t, writeSummary(m, flags.summaryJSON))
+	b, err := os.ReadFile(flags.summaryJSON)
+	require.NoError(t, err)
+	var s summary
+	require.NoError(t, json.Unmarshal(b, &s))
+
+	assert.Equal(t, "fail", s.Result)
+	assert.Equal(t, 3, s.Tests)
+	assert.Equal(t, 2, s.Failed)
+	require.Len(t, s.Packages, 1)
+	pkg := s.Packages[0]
+	assert.Equal(t, "fail", pkg.Status)
+	assert.Equal(t, 3.0, pkg.Elapsed)
+	if assert.NotNil(t, pkg.Coverage) {
+		assert.Equal(t, 50.0, *pkg.Coverage)
+	}
+	require.Len(t, pkg.Tests, 3)
+	assert.Equal(t, summaryTest{Name: "TestA", Status: "pass", Elapsed: 1}, pkg.Tests[0])
+	assert.Equal(t, "TestB/sub", pkg.Tests[2].Name)
+	assert.Contains(t, pkg.Tests[2].Output, "boom")
+
+	require.Len(t, s.Slowest, 3)
+	assert.Equal(t, "a", s.Slowest[0].Package)
+	assert.Equal(t, 2.0, s.Slowest[0].Elapsed)
+	assert.Empty(t, s.Slowest[0].Output)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// summaryStyles are the valid values of -summary-style.
+var summaryStyles = []string{"line", "table"}
+
+// summaryRow is a row of the -summary-style table.
+type summaryRow struct {
+	label string
+	value string
+	style lipgloss.Style
+}
+
+// renderSummaryTable writes the final totals as an aligned table, one count per
+// row, instead of the comma separated totals line.
+func (m *model) renderSummaryTable(sb *strings.Builder) {
+	switch {
+	case m.aborted || m.aborts > 0:
+		sb.WriteString(failedText.Bold(true).Render("ABORTED") + "\n")
+	case m.overallFail:
+		sb.WriteString(failedText.Bold(true).Render("FAILED") + "\n")
+	default:
+		sb.WriteString(lipgloss.NewStyle().Foreground(colorPassed).Bold(true).Render("PASSED") + "\n")
+	}
+
+	plain := lipgloss.NewStyle()
+	passed := lipgloss.NewStyle().Foreground(colorPassed)
+	skipped := lipgloss.NewStyle().Foreground(colorSkipped)
+	count := func(n int) string { return fmt.Sprint(n) }
+
+	tests := count(m.total)
+	if flags.splitSubtests {
+		tests = fmt.Sprintf("%d (%d subtests)", m.total-m.subtests, m.subtests)
+	}
+	rows := []summaryRow{
+		{"Tests", tests, plain},
+		{"Passed", count(m.passes), passed},
+		{"Failed", count(m.fails), failedText},
+		{"Skipped", count(m.skips), skipped},
+	}
+	// the rarer counts are only shown when they happened
+	if m.aborts > 0 {
+		rows = append(rows, summaryRow{"Aborted", count(m.aborts), failedText})
+	}
+	if m.xfails > 0 {
+		rows = append(rows, summaryRow{"Expected failures", count(m.xfails), skipped})
+	}
+	if m.buildFails > 0 {
+		rows = append(rows, summaryRow{"Build failures", count(m.buildFails), failedText})
+	}
+	if len(m.flaky) > 0 {
+		rows = append(rows, summaryRow{"Flaky", count(len(m.flaky)), failedText})
+	}
+	if m.slow > 0 {
+		rows = append(rows, summaryRow{fmt.Sprintf("Slow (>%s)", flags.slowThreshold), count(m.slow), lipgloss.NewStyle().Foreground(colorOrange)})
+	}
+
+	elapsed := m.end.Sub(m.start)
+	if m.end.IsZero() {
+		elapsed = scaledTimeSince(m.start)
+	}
+	duration := round(elapsed, 1).String()
+	if build, test, ok := m.phases(); ok {
+		duration += fmt.Sprintf(" (build %s, test %s)", round(build, 1), round(test, 1))
+	}
+	rows = append(rows, summaryRow{"Duration", duration, durationStyle(elapsed)})
+	if flags.timeFormat != "" && !m.start.IsZero() {
+		rows = append(rows, summaryRow{"Started", formatTime(m.start, ""), plain})
+	}
+	if t, ok := m.totalCoverage(); ok {
+		rows = append(rows, summaryRow{"Coverage", t.String(), plain})
+	}
+
+	writeSummaryRows(sb, rows)
+}
+
+// writeSummaryRows writes the rows with the labels padded to the same width, and
+// the counts aligned right.  The padding is added before the styles, since their
+// escape sequences would throw off the widths.
+func writeSummaryRows(sb *strings.Builder, rows []summaryRow) {
+	var labelWidth, valueWidth int
+	for _, r := range rows {
+		labelWidth = max(labelWidth, len(r.label))
+		if isCount(r.value) {
+			valueWidth = max(valueWidth, len(r.value))
+		}
+	}
+	for i, r := range rows {
+		value := r.value
+		if isCount(value) {
+			value = fmt.Sprintf("%*s", valueWidth, value)
+		}
+		fmt.Fprintf(sb, "  %-*s  %s", labelWidth, r.label, r.style.Render(value))
+		if i < len(rows)-1 {
+			sb.WriteString("\n")
+		}
+	}
+}
+
+// isCount returns true if s is a plain number.
+func isCount(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// countSlow counts the finished tests which took longer than -slow-threshold.
+func (m *model) countSlow(n *node) {
+	if flags.slowThreshold > 0 && n.elapsed > flags.slowThreshold {
+		m.slow++
+	}
+}
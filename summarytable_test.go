@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryTable(t *testing.T) {
+	keepFlags(t)
+	flags.summaryStyle, flags.slowThreshold = "table", time.Second
+
+	m := newModel()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a", Time: start},
+		{Action: "run", Package: "a", Test: "TestA", Time: start},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 0.1, Time: start},
+		{Action: "run", Package: "a", Test: "TestB", Time: start},
+		{Action: "pass", Package: "a", Test: "TestB", Elapsed: 2, Time: start},
+		{Action: "run", Package: "a", Test: "TestC", Time: start},
+		{Action: "fail", Package: "a", Test: "TestC", Elapsed: 0.1, Time: start},
+		{Action: "run", Package: "a", Test: "TestD", Time: start},
+		{Action: "skip", Package: "a", Test: "TestD", Time: start},
+		{Action: "fail", Package: "a", Elapsed: 3, Time: start},
+	}...)
+	m.done = true
+	m.overallFail = true
+	m.end = m.start.Add(3 * time.Second)
+
+	out := m.render(false)
+	assert.Contains(t, out, "FAILED\n"+
+		"  Tests       4\n"+
+		"  Passed      2\n"+
+		"  Failed      1\n"+
+		"  Skipped     1\n"+
+		"  Slow (>1s)  1\n"+
+		"  Duration    3s")
+	assert.Equal(t, 1, m.slow)
+
+	flags.summaryStyle = "line"
+	assert.Contains(t, m.render(false), "FAILED 4 tests, 1 skipped, 1 failed in 3s")
+}
+
+func TestWriteSummaryRows(t *testing.T) {
+	var sb strings.Builder
+	writeSummaryRows(&sb, []summaryRow{
+		{label: "Tests", value: "120"},
+		{label: "Failed", value: "2"},
+		{label: "Duration", value: "1m2s"},
+	})
+	assert.Equal(t, "  Tests     120\n  Failed      2\n  Duration  1m2s", sb.String())
+}
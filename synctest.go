@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tests using testing/synctest run in a bubble with a fake clock, which only
+// advances when every goroutine in the bubble is durably blocked.  When none of
+// them can be woken, synctest panics with a deadlock, followed by the stacks of
+// the blocked goroutines.  The panic is explained in the output, listing where
+// each goroutine is blocked instead of the raw stacks, and when go test didn't
+// attribute it to a test, it's attributed to the test in the stacks.
+
+// deadlockPrefix starts the panic of a synctest bubble which deadlocked, e.g.
+// "panic: deadlock: all goroutines in bubble are blocked" or
+// "panic: deadlock: main bubble goroutine has exited but blocked goroutines remain".
+const deadlockPrefix = "panic: deadlock: "
+
+// goroutineHeaderPattern matches the header of a goroutine's stack, e.g.
+// "goroutine 7 [chan receive (durable), synctest bubble 1]:"
+var goroutineHeaderPattern = regexp.MustCompile(`^goroutine (\d+) \[(.*)\]:$`)
+
+// blockedGoroutine is a goroutine blocked in a synctest bubble.
+type blockedGoroutine struct {
+	id string
+	// reason is what it's waiting on, e.g. "chan receive"
+	reason string
+	// fn and file are its first frame outside the standard library
+	fn, file string
+}
+
+// inBubble returns true if the goroutine header's state says it's in a synctest
+// bubble.  Go 1.24's GOEXPERIMENT=synctest marks them "(synctest)", later versions
+// mark durably blocked goroutines "(durable)", and name the bubble.
+func inBubble(state string) bool {
+	return strings.Contains(state, "(synctest)") || strings.Contains(state, "(durable)") || strings.Contains(state, "synctest bubble")
+}
+
+// waitReason returns what the goroutine is waiting on, from its header's state.
+func waitReason(state string) string {
+	reason, _, _ := strings.Cut(state, ",")
+	reason = strings.TrimSuffix(reason, " (synctest)")
+	return strings.TrimSuffix(reason, " (durable)")
+}
+
+// stdlibFunc returns true if the stack frame's function is in the standard
+// library, whose packages don't have a dot in their first path element.
+func stdlibFunc(fn string) bool {
+	first, _, _ := strings.Cut(fn, "/")
+	return !strings.Contains(first, ".") || !strings.Contains(fn, "/")
+}
+
+// parseGoroutine parses a goroutine's stack frames, the lines after its header.
+// Returns the number of lines they span.
+func parseGoroutine(g *blockedGoroutine, lines []string) int {
+	i := 0
+	for ; i+1 < len(lines); i += 2 {
+		matches := stackFuncPattern.FindStringSubmatch(lines[i])
+		if matches == nil || !stackFilePattern.MatchString(lines[i+1]) {
+			break
+		}
+		if g.fn == "" || stdlibFunc(g.fn) && !stdlibFunc(matches[1]) {
+			g.fn = matches[1]
+			g.file, _, _ = strings.Cut(strings.TrimSpace(lines[i+1]), " +0x")
+		}
+	}
+	return i
+}
+
+// testFromFunc returns the top level test a function belongs to, e.g. "TestFoo"
+// for "example.com/m/pkg.TestFoo.func1", or "".  The testing package's own
+// functions, like synctest.Test, don't belong to a test.
+func testFromFunc(fn string) string {
+	if strings.HasPrefix(fn, "testing") || strings.HasPrefix(fn, "runtime") {
+		return ""
+	}
+	if i := strings.LastIndex(fn, "/"); i >= 0 {
+		fn = fn[i+1:]
+	}
+	for _, part := range strings.Split(fn, ".") {
+		if strings.HasPrefix(part, "Test") {
+			return part
+		}
+	}
+	return ""
+}
+
+// deadlockedTest returns the test which owns the deadlocked bubble in the output,
+// or "" if there's no deadlock, or it can't be told.
+func deadlockedTest(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), deadlockPrefix) {
+			continue
+		}
+		for _, l := range lines[i+1:] {
+			if matches := stackFuncPattern.FindStringSubmatch(l); matches != nil {
+				if test := testFromFunc(matches[1]); test != "" {
+					return test
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// explainSynctest replaces the stacks of the goroutines blocked in a deadlocked
+// synctest bubble with a line for each, saying where it's blocked.  Other
+// goroutines' stacks are kept.  Test output is indented when it's rolled up into
+// its package's, so the lines are matched without their indentation.
+func explainSynctest(output string) string {
+	if !strings.Contains(output, deadlockPrefix) {
+		return output
+	}
+	lines := strings.Split(output, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		text := strings.TrimLeft(lines[i], " ")
+		if !strings.HasPrefix(text, deadlockPrefix) {
+			continue
+		}
+		indent := lines[i][:len(lines[i])-len(text)]
+		var blocked []blockedGoroutine
+		var rest []string
+		j := i + 1
+		for ; j < len(lines); j++ {
+			text := strings.TrimLeft(lines[j], " ")
+			matches := goroutineHeaderPattern.FindStringSubmatch(text)
+			if matches == nil {
+				if text == "" {
+					continue
+				}
+				if strings.HasPrefix(text, "\t") || stackFuncPattern.MatchString(text) {
+					rest = append(rest, lines[j])
+					continue
+				}
+				break
+			}
+			g := blockedGoroutine{id: matches[1], reason: waitReason(matches[2])}
+			n := parseGoroutine(&g, lines[j+1:])
+			if inBubble(matches[2]) {
+				blocked = append(blocked, g)
+			} else {
+				rest = append(rest, lines[j:j+1+n]...)
+				rest = append(rest, "")
+			}
+			j += n
+		}
+		for _, line := range deadlockExplanation(blocked, deadlockedTest(strings.Join(lines[i:j], "\n"))) {
+			out = append(out, strings.TrimRight(indent+line, " "))
+		}
+		out = append(out, rest...)
+		i = j - 1
+	}
+	return strings.Join(out, "\n")
+}
+
+// deadlockExplanation returns the lines explaining a bubble's deadlock.
+func deadlockExplanation(blocked []blockedGoroutine, test string) []string {
+	in := ""
+	if test != "" {
+		in = " in " + test
+	}
+	if len(blocked) == 0 {
+		return []string{fmt.Sprintf("synctest bubble deadlocked%s: every goroutine in the bubble is blocked, so its fake clock can't advance to wake them", in)}
+	}
+	lines := []string{fmt.Sprintf("synctest bubble deadlocked%s: every goroutine in the bubble is blocked, so its fake clock can't advance to wake them:", in)}
+	for _, g := range blocked {
+		line := fmt.Sprintf("  goroutine %s blocked on %s", g.id, g.reason)
+		if g.fn != "" {
+			line += fmt.Sprintf(" in %s (%s)", g.fn, g.file)
+		}
+		lines = append(lines, line)
+	}
+	return append(lines, "")
+}
+
+// attributeDeadlock marks the test which owns a deadlocked bubble, when go test
+// attributed the panic to the package rather than the test.
+func attributeDeadlock(pkg *node) {
+	if pkg.outputBuf == nil || !strings.Contains(pkg.outputBuf.String(), deadlockPrefix) {
+		return
+	}
+	name := deadlockedTest(pkg.outputBuf.String())
+	if name == "" {
+		return
+	}
+	if n, _ := pkg.findChild([]string{name}); n != nil && n.crash == "" {
+		n.crash = "DEADLOCK"
+	}
+}
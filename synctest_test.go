@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const synctestDeadlock = `panic: deadlock: main bubble goroutine has exited but blocked goroutines remain [recovered, repanicked]
+
+goroutine 7 [running]:
+testing.tRunner.func1.2({0x5a1f40, 0x6d6a80})
+	/usr/local/go/src/testing/testing.go:1872 +0x237
+testing/synctest.Test(0xc0000a6820, 0x6b1c08)
+	/usr/local/go/src/testing/synctest/synctest.go:290 +0x5e
+example.com/m/pkg.TestCache(0xc0000a6820)
+	/src/pkg/cache_test.go:12 +0x25
+
+goroutine 9 [chan receive (durable), synctest bubble 8]:
+example.com/m/pkg.(*Cache).wait(...)
+	/src/pkg/cache.go:40
+example.com/m/pkg.TestCache.func1()
+	/src/pkg/cache_test.go:15 +0x45
+created by example.com/m/pkg.TestCache in goroutine 8
+	/src/pkg/cache_test.go:14 +0x30
+
+goroutine 10 [sleep (durable), synctest bubble 8]:
+time.Sleep(0x3b9aca00)
+	/usr/local/go/src/runtime/time.go:338 +0x165
+example.com/m/pkg.TestCache.func2()
+	/src/pkg/cache_test.go:20 +0x25
+FAIL	example.com/m/pkg	0.012s`
+
+func TestExplainSynctest(t *testing.T) {
+	out := explainSynctest(synctestDeadlock)
+	assert.Contains(t, out, "panic: deadlock: main bubble goroutine has exited but blocked goroutines remain [recovered, repanicked]\n"+
+		"synctest bubble deadlocked in TestCache: every goroutine in the bubble is blocked, so its fake clock can't advance to wake them:\n"+
+		"  goroutine 9 blocked on chan receive in example.com/m/pkg.(*Cache).wait (/src/pkg/cache.go:40)\n"+
+		"  goroutine 10 blocked on sleep in example.com/m/pkg.TestCache.func2 (/src/pkg/cache_test.go:20)\n")
+	assert.Contains(t, out, "goroutine 7 [running]:\ntesting.tRunner.func1.2", "other goroutines are kept")
+	assert.NotContains(t, out, "goroutine 9 [chan receive")
+	assert.True(t, strings.HasSuffix(out, "FAIL\texample.com/m/pkg\t0.012s"))
+
+	// rolled up test output is indented
+	indented := "    " + strings.ReplaceAll(synctestDeadlock, "\n", "\n    ")
+	assert.Contains(t, explainSynctest(indented), "\n    synctest bubble deadlocked in TestCache")
+
+	assert.Equal(t, "panic: boom\n", explainSynctest("panic: boom\n"))
+}
+
+func TestSynctestGoroutineStates(t *testing.T) {
+	// go 1.24's GOEXPERIMENT=synctest
+	assert.True(t, inBubble("chan receive (synctest)"))
+	assert.Equal(t, "chan receive", waitReason("chan receive (synctest)"))
+	assert.True(t, inBubble("select (durable), synctest bubble 3"))
+	assert.Equal(t, "select", waitReason("select (durable), synctest bubble 3"))
+	assert.False(t, inBubble("chan receive, 2 minutes"))
+}
+
+func TestAttributeDeadlock(t *testing.T) {
+	m := newModel()
+	feed(m, []TestEvent{
+		{Action: "start", Package: "example.com/m/pkg"},
+		{Action: "run", Package: "example.com/m/pkg", Test: "TestCache"},
+		{Action: "run", Package: "example.com/m/pkg", Test: "TestOther"},
+	}...)
+	for _, line := range strings.SplitAfter(synctestDeadlock, "\n") {
+		m.processEvent(TestEvent{Action: "output", Package: "example.com/m/pkg", Output: line})
+	}
+	m.processEvent(TestEvent{Action: "fail", Package: "example.com/m/pkg", Test: "TestCache"})
+	m.processEvent(TestEvent{Action: "fail", Package: "example.com/m/pkg", Test: "TestOther"})
+
+	pkg := m.root.children[0]
+	tests := map[string]*node{}
+	for _, c := range pkg.children {
+		tests[c.name] = c
+	}
+	m.processEvent(TestEvent{Action: "fail", Package: "example.com/m/pkg"})
+
+	assert.Equal(t, "DEADLOCK", pkg.crash)
+	assert.Equal(t, "DEADLOCK", tests["TestCache"].crash)
+	assert.Empty(t, tests["TestOther"].crash)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// teeFile is the -tee file.  It's created by the first call to teeInput, and the
+// input of re-runs is appended to it.
+var teeFile *os.File
+
+// teeInput returns a reader which copies everything read from r to the -tee file,
+// unmodified, so the raw go test -json stream is kept along with the live view.
+// Non-JSON lines, like build errors, are kept in the order they were read.
+func teeInput(r io.Reader) (io.Reader, error) {
+	if flags.tee == "" {
+		return r, nil
+	}
+	if teeFile == nil {
+		if sameFile(flags.tee, flags.infile) {
+			return nil, errors.New("-tee would overwrite the -f input file")
+		}
+		f, err := os.Create(flags.tee)
+		if err != nil {
+			return nil, err
+		}
+		teeFile = f
+	}
+	return io.TeeReader(r, teeFile), nil
+}
+
+// sameFile returns true if the paths name the same existing file.
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+// closeTee closes the -tee file, once the run has finished.
+func closeTee() error {
+	if teeFile == nil {
+		return nil
+	}
+	err := teeFile.Close()
+	teeFile = nil
+	return err
+}
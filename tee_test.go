@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeeInput(t *testing.T) {
+	keepFlags(t)
+	path := filepath.Join(t.TempDir(), "raw.json")
+	flags.tee = path
+	defer closeTee()
+
+	input := "{\"Action\":\"start\",\"Package\":\"a\"}\n# a [build failed]\npartial"
+	r, err := teeInput(strings.NewReader(input))
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, input, string(b))
+
+	// re-runs are appended
+	r, err = teeInput(strings.NewReader(" line\n"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, closeTee())
+
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, input+" line\n", string(b))
+}
+
+func TestTeeInputOverwritesInput(t *testing.T) {
+	keepFlags(t)
+	path := filepath.Join(t.TempDir(), "in.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o644))
+	flags.tee, flags.infile = path, path
+
+	_, err := teeInput(strings.NewReader(""))
+	assert.Error(t, err)
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "{}\n", string(b))
+}
@@ -0,0 +1,40 @@
+// Package basic is the source of the basic golden fixture.  Its tests fail on
+// purpose, and only run when recording the fixture, see golden_test.go.
+package basic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPass(t *testing.T) {
+	time.Sleep(300 * time.Millisecond)
+}
+
+func TestFail(t *testing.T) {
+	t.Log("some output")
+	t.Error("boom")
+}
+
+func TestSkip(t *testing.T) {
+	t.Skip("not today")
+}
+
+func TestSubtests(t *testing.T) {
+	for _, name := range []string{"one", "two", "three"} {
+		t.Run(name, func(t *testing.T) {
+			if name == "two" {
+				t.Fatal("two failed")
+			}
+		})
+	}
+}
+
+func TestParallel(t *testing.T) {
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			time.Sleep(200 * time.Millisecond)
+		})
+	}
+}
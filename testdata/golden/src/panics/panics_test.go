@@ -0,0 +1,12 @@
+// Package panics is the source of the panics golden fixture.  Its tests fail on
+// purpose, and only run when recording the fixture, see golden_test.go.
+package panics
+
+import "testing"
+
+func TestOK(t *testing.T) {}
+
+func TestPanic(t *testing.T) {
+	var m map[string]int
+	m["boom"]++
+}
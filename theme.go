@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Adaptive colors pick a variant based on whether the terminal has a dark
+// or light background.  The plain ANSI colors look fine on dark backgrounds,
+// but yellow and gray are illegible on light ones.
+var (
+	colorPassed  = lipgloss.AdaptiveColor{Light: "28", Dark: "2"}
+	colorSkipped = lipgloss.AdaptiveColor{Light: "130", Dark: "3"}
+	colorFailed  = lipgloss.AdaptiveColor{Light: "124", Dark: "1"}
+	colorGray    = lipgloss.AdaptiveColor{Light: "243", Dark: "8"}
+	colorOrange  = lipgloss.AdaptiveColor{Light: "166", Dark: "208"}
+)
+
+var (
+	iconPassed  = "✓"
+	iconSkipped = "⍉"
+	iconFailed  = "✖"
+	iconQueued  = "◌"
+	iconAborted = "⊘"
+	iconPanic   = "☠"
+	iconBuild   = "⚒"
+	iconRace    = "⇄"
+	iconStuck   = "⚠"
+	iconXFail   = "✗"
+	iconCursor  = "›"
+	gray        = lipgloss.NewStyle()
+	failedText  = lipgloss.NewStyle()
+	// newText tags the tests added in the working tree, see -new-tests
+	newText = lipgloss.NewStyle()
+	// diffHighlight marks the differing words in got/want pairs
+	diffHighlight = lipgloss.NewStyle()
+	// diffRemoved and diffAdded color the expected and actual sides of a failed
+	// assertion, see -diff-color
+	diffRemoved = lipgloss.NewStyle()
+	diffAdded   = lipgloss.NewStyle()
+	// stackOwnFrame marks the stack frames from the module under test
+	stackOwnFrame = lipgloss.NewStyle()
+	// durationStyles color durations from fast to slow, see durationStyle
+	durationStyles = []lipgloss.Style{lipgloss.NewStyle(), lipgloss.NewStyle(), lipgloss.NewStyle(), lipgloss.NewStyle()}
+)
+
+// glyphs are the characters of the icons, which the config file's [icons] table can
+// replace, e.g. for fonts without them.
+var glyphs = map[string]string{
+	"passed":  "✓",
+	"skipped": "⍉",
+	"failed":  "✖",
+	"aborted": "⊘",
+	"build":   "⚒",
+	"panic":   "☠",
+	"race":    "⇄",
+	"stuck":   "⚠",
+	"xfail":   "✗",
+	"queued":  "◌",
+	"cursor":  "›",
+}
+
+var themes = []string{"auto", "dark", "light"}
+
+// setupTheme renders the icons and styles for the given theme.  "auto"
+// queries the terminal's background color (OSC 11, falling back to $COLORFGBG),
+// so it should be called before the program takes over the terminal.
+func setupTheme(theme string) error {
+	switch theme {
+	case "auto":
+		lipgloss.SetHasDarkBackground(lipgloss.HasDarkBackground())
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	default:
+		return fmt.Errorf("invalid theme %q, must be one of %v", theme, themes)
+	}
+
+	iconPassed = lipgloss.NewStyle().Foreground(colorPassed).Bold(true).Render(glyphs["passed"])
+	iconSkipped = lipgloss.NewStyle().Foreground(colorSkipped).Bold(true).Render(glyphs["skipped"])
+	iconFailed = lipgloss.NewStyle().Foreground(colorFailed).Bold(true).Render(glyphs["failed"])
+	iconAborted = lipgloss.NewStyle().Foreground(colorFailed).Bold(true).Render(glyphs["aborted"])
+	iconBuild = lipgloss.NewStyle().Foreground(colorFailed).Bold(true).Render(glyphs["build"])
+	iconPanic = lipgloss.NewStyle().Foreground(colorFailed).Bold(true).Render(glyphs["panic"])
+	iconRace = lipgloss.NewStyle().Foreground(colorFailed).Bold(true).Render(glyphs["race"])
+	iconStuck = lipgloss.NewStyle().Foreground(colorOrange).Bold(true).Render(glyphs["stuck"])
+	iconXFail = lipgloss.NewStyle().Foreground(colorSkipped).Bold(true).Render(glyphs["xfail"])
+	gray = lipgloss.NewStyle().Foreground(colorGray)
+	failedText = lipgloss.NewStyle().Foreground(colorFailed)
+	newText = lipgloss.NewStyle().Foreground(colorPassed).Bold(true)
+	iconQueued = gray.Render(glyphs["queued"])
+	iconCursor = lipgloss.NewStyle().Bold(true).Render(glyphs["cursor"])
+	diffHighlight = lipgloss.NewStyle().Reverse(true)
+	diffRemoved = lipgloss.NewStyle().Foreground(colorFailed).TabWidth(lipgloss.NoTabConversion)
+	diffAdded = lipgloss.NewStyle().Foreground(colorPassed).TabWidth(lipgloss.NoTabConversion)
+	stackOwnFrame = lipgloss.NewStyle().Bold(true)
+	durationStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(colorPassed),
+		lipgloss.NewStyle().Foreground(colorSkipped),
+		lipgloss.NewStyle().Foreground(colorOrange),
+		lipgloss.NewStyle().Foreground(colorFailed),
+	}
+
+	return nil
+}
+
+// durationStyle returns the style for a duration, from green for fast to red for
+// slow, by the -duration-colors thresholds.
+func durationStyle(d time.Duration) lipgloss.Style {
+	for i, threshold := range flags.durationColors {
+		if d < threshold {
+			return durationStyles[i]
+		}
+	}
+	return durationStyles[len(durationStyles)-1]
+}
+
+// durationColorsFlag parses -duration-colors, the three ascending thresholds
+// between the duration colors.
+func durationColorsFlag(s string) error {
+	var thresholds []time.Duration
+	for _, v := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return err
+		}
+		if len(thresholds) > 0 && d <= thresholds[len(thresholds)-1] {
+			return fmt.Errorf("thresholds must be ascending: %s", s)
+		}
+		thresholds = append(thresholds, d)
+	}
+	if len(thresholds) != len(durationStyles)-1 {
+		return fmt.Errorf("must be %d thresholds, got %q", len(durationStyles)-1, s)
+	}
+	flags.durationColors = thresholds
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationColors(t *testing.T) {
+	keepFlags(t)
+
+	require.NoError(t, durationColorsFlag("50ms, 500ms,5s"))
+	assert.Equal(t, []time.Duration{50 * time.Millisecond, 500 * time.Millisecond, 5 * time.Second}, flags.durationColors)
+
+	assert.Error(t, durationColorsFlag("1s,500ms,5s"))
+	assert.Error(t, durationColorsFlag("1s,5s"))
+	assert.Error(t, durationColorsFlag("fast,slow,slower"))
+}
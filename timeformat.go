@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// timeFormats are the valid values of -time-format.
+var timeFormats = []string{"rfc3339", "24h", "unix"}
+
+// formatTime formats a timestamp per -time-format, or with layout, if it isn't set.
+// 24h is the local time of day, with the date if it isn't today.
+func formatTime(t time.Time, layout string) string {
+	switch flags.timeFormat {
+	case "rfc3339":
+		return t.Format(time.RFC3339)
+	case "24h":
+		t = t.Local()
+		if y, m, d := now().Local().Date(); t.Year() != y || t.Month() != m || t.Day() != d {
+			return t.Format("2006-01-02 15:04:05")
+		}
+		return t.Format("15:04:05")
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.Format(layout)
+}
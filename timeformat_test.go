@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTime(t *testing.T) {
+	keepFlags(t)
+	defer func(f func() time.Time) { now = f }(now)
+
+	ts := time.Date(2024, 8, 31, 18, 12, 41, 500, time.FixedZone("EDT", -4*60*60))
+	now = func() time.Time { return ts.Add(time.Hour) }
+
+	tests := []struct {
+		format, want string
+	}{
+		{"", ts.Format(time.RFC1123)},
+		{"rfc3339", "2024-08-31T18:12:41-04:00"},
+		{"24h", ts.Local().Format("15:04:05")},
+		{"unix", "1725142361"},
+	}
+	for _, tt := range tests {
+		flags.timeFormat = tt.format
+		assert.Equal(t, tt.want, formatTime(ts, time.RFC1123), tt.format)
+	}
+
+	// 24h includes the date when it isn't today
+	flags.timeFormat = "24h"
+	now = func() time.Time { return ts.Add(72 * time.Hour) }
+	assert.Equal(t, ts.Local().Format("2006-01-02 15:04:05"), formatTime(ts, ""))
+}
+
+func TestTimeFormatTotals(t *testing.T) {
+	keepFlags(t)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Unix(1725142361, 0) }
+
+	m := newModel()
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	assert.NotContains(t, m.render(false), "started")
+
+	flags.timeFormat = "unix"
+	lines := strings.Split(m.render(false), "\n")
+	assert.Contains(t, lines[len(lines)-1], ", started 1725142361")
+
+	flags.timeFormat = "rfc3339"
+	assert.Contains(t, stepSummary(m.summary()), ", started "+time.Unix(1725142361, 0).Format(time.RFC3339))
+}
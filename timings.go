@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// timingsKept is the number of recent durations kept per test.
+const timingsKept = 5
+
+// minRegression is the smallest slowdown highlighted, so tests which only take a
+// few milliseconds aren't flagged for being jittery.
+const minRegression = 100 * time.Millisecond
+
+// timings are the recent durations of passed tests, oldest first, keyed by package
+// and test name, so tests which got slower can be spotted in later runs.
+type timings map[string][]time.Duration
+
+// timingsPath returns the location of the timings file.  Can be overridden with
+// $GOTESTPRETTY_TIMINGS.
+func timingsPath() (string, error) {
+	return cachePath("GOTESTPRETTY_TIMINGS", "timings.json")
+}
+
+func loadTimings() (timings, error) {
+	path, err := timingsPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return timings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := timings{}
+	return t, json.Unmarshal(b, &t)
+}
+
+func (t timings) save() error {
+	path, err := timingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func timingKey(pkg, test string) string {
+	return pkg + " " + test
+}
+
+// baseline returns the median of the test's recent durations.
+func (t timings) baseline(key string) (time.Duration, bool) {
+	d := slices.Clone(t[key])
+	if len(d) == 0 {
+		return 0, false
+	}
+	slices.Sort(d)
+	return d[len(d)/2], true
+}
+
+func (t timings) record(key string, d time.Duration) {
+	recent := append(t[key], d)
+	if len(recent) > timingsKept {
+		recent = recent[len(recent)-timingsKept:]
+	}
+	t[key] = recent
+}
+
+// checkTiming compares a passed test's duration to its recent runs, and records it.
+func (m *model) checkTiming(n *node, ev TestEvent) {
+	if m.timings == nil || ev.Action != "pass" {
+		return
+	}
+	key := timingKey(ev.Package, ev.Test)
+	if b, ok := m.timings.baseline(key); ok {
+		n.baseline = b
+		if n.slower() {
+			m.regressions = append(m.regressions, n)
+		}
+	}
+	m.timings.record(key, n.elapsed)
+}
+
+// slower is true if the test took significantly longer than usual, see
+// -regression-threshold.
+func (n *node) slower() bool {
+	if n.baseline == 0 {
+		return false
+	}
+	delta := n.elapsed - n.baseline
+	return delta >= minRegression && float64(delta) > float64(n.baseline)*float64(flags.regressionPct)/100
+}
+
+// timingDelta describes the difference between the test's duration and its
+// usual duration, e.g. "+1.2s".
+func (n *node) timingDelta() string {
+	if n.baseline == 0 {
+		return ""
+	}
+	delta := n.elapsed - n.baseline
+	if delta < 0 {
+		return "-" + round(-delta, 1).String()
+	}
+	return "+" + round(delta, 1).String()
+}
+
+func renderRegressions(m *model) string {
+	if len(m.regressions) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Slower than usual:\n")
+	for _, n := range m.regressions {
+		fmt.Fprintf(&sb, "  %s\t%s (usually %s)\t%s\n", n.testName(), failedText.Render(round(n.elapsed, 1).String()), round(n.baseline, 1), gray.Render(n.pkg().name))
+	}
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings(t *testing.T) {
+	keepFlags(t)
+	t.Setenv("GOTESTPRETTY_TIMINGS", filepath.Join(t.TempDir(), "timings.json"))
+	flags.regressionPct = 50
+
+	tm, err := loadTimings()
+	require.NoError(t, err)
+	assert.Empty(t, tm)
+
+	for _, d := range []time.Duration{time.Second, 3 * time.Second, 2 * time.Second} {
+		tm.record(timingKey("a", "TestA"), d)
+	}
+	tm.record(timingKey("a", "TestB"), 10*time.Millisecond)
+	require.NoError(t, tm.save())
+
+	tm, err = loadTimings()
+	require.NoError(t, err)
+	b, ok := tm.baseline(timingKey("a", "TestA"))
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, b)
+
+	m := newModel()
+	m.timings = tm
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA", Elapsed: 3.5},
+		{Action: "run", Package: "a", Test: "TestB"},
+		// much slower, but only by a few milliseconds
+		{Action: "pass", Package: "a", Test: "TestB", Elapsed: 0.05},
+	}...)
+
+	require.Len(t, m.regressions, 1)
+	testA := m.regressions[0]
+	assert.Equal(t, "TestA", testA.name)
+	assert.Equal(t, "+1.5s", testA.timingDelta())
+	assert.Contains(t, m.String(), "3.5s (+1.5s)")
+	assert.Equal(t, "Slower than usual:\n  TestA\t3.5s (usually 2s)\ta\n", renderRegressions(m))
+	assert.Len(t, m.timings[timingKey("a", "TestA")], 4)
+
+	for range timingsKept {
+		m.timings.record(timingKey("a", "TestA"), time.Second)
+	}
+	assert.Len(t, m.timings[timingKey("a", "TestA")], timingsKept)
+}
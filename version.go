@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// modulePath is gotestpretty's module path, used to find its latest release.
+const modulePath = "github.com/ansel1/gotestpretty"
+
+// moduleProxy is where -check-update looks up the latest release, unless $GOPROXY
+// names another proxy.
+const moduleProxy = "https://proxy.golang.org"
+
+// versionInfo returns the version, commit, and go version the binary was built
+// with, as lines to print.
+func versionInfo(info *debug.BuildInfo) []string {
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	lines := []string{"gotestpretty " + version}
+
+	settings := map[string]string{}
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	if rev := settings["vcs.revision"]; rev != "" {
+		commit := "commit: " + rev
+		if t := settings["vcs.time"]; t != "" {
+			commit += " (" + t + ")"
+		}
+		if settings["vcs.modified"] == "true" {
+			commit += " modified"
+		}
+		lines = append(lines, commit)
+	}
+	lines = append(lines, "go: "+info.GoVersion)
+	return lines
+}
+
+// latestVersion looks up the latest release of gotestpretty in the module proxy.
+func latestVersion() (string, error) {
+	proxy := moduleProxy
+	// use the first proxy in $GOPROXY, if it's a URL, rather than direct or off
+	for _, p := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+		if strings.HasPrefix(p, "http") {
+			proxy = p
+		}
+		break
+	}
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(proxy, "/") + "/" + modulePath + "/@latest")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	var latest struct{ Version string }
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil {
+		return "", err
+	}
+	return latest.Version, nil
+}
+
+// versionMain implements the version subcommand, which prints the build info, to
+// include in bug reports.
+func versionMain(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	checkUpdate := fs.Bool("check-update", false, "Check for a newer release")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n\t%s version [-check-update]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Prints the version, commit, and go version gotestpretty was built with.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		fmt.Println("gotestpretty: no build info")
+		return 1
+	}
+	for _, line := range versionInfo(info) {
+		fmt.Println(line)
+	}
+
+	if *checkUpdate {
+		latest, err := latestVersion()
+		switch {
+		case err != nil:
+			fmt.Println("error checking for updates:", err)
+			return 1
+		case latest == info.Main.Version:
+			fmt.Println("up to date")
+		default:
+			fmt.Printf("latest release: %s, update with:\n\tgo install %s@latest\n", latest, modulePath)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.23.1",
+		Main:      debug.Module{Path: modulePath, Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2024-09-01T00:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	assert.Equal(t, []string{
+		"gotestpretty v1.2.3",
+		"commit: abc123 (2024-09-01T00:00:00Z) modified",
+		"go: go1.23.1",
+	}, versionInfo(info))
+
+	assert.Equal(t, []string{"gotestpretty (devel)", "go: go1.23.1"}, versionInfo(&debug.BuildInfo{GoVersion: "go1.23.1"}))
+}
+
+func TestLatestVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+modulePath+"/@latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Version":"v1.3.0","Time":"2024-09-01T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GOPROXY", srv.URL+",direct")
+
+	latest, err := latestVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", latest)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"container/list"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// viewMode is the format of the live view.  The final summary is rendered as a
+// tree, or as the starts view with -view starts.
+type viewMode int
+
+const (
+	// viewTree shows every running and failed test, under its package
+	viewTree viewMode = iota
+	// viewCompact shows only packages
+	viewCompact
+	// viewDots shows packages, with a character for each finished test
+	viewDots
+)
+
+// next returns the mode after v, cycling back to viewTree.
+func (v viewMode) next() viewMode {
+	return (v + 1) % (viewDots + 1)
+}
+
+// maxDots limits how many test results are shown per package in the dots view.
+// Only the most recent are kept.
+const maxDots = 60
+
+// addDot records a finished test's status for the dots view.
+func (n *node) addDot(status string) {
+	var dot string
+	switch status {
+	case "pass":
+		dot = gray.Render("·")
+	case "fail":
+		dot = iconFailed
+	case "skip":
+		dot = iconSkipped
+	case "xfail":
+		dot = iconXFail
+	default:
+		return
+	}
+	if len(n.dots) == maxDots {
+		n.dots = n.dots[1:]
+	}
+	n.dots = append(n.dots, dot)
+}
+
+// packagesOnly returns the package nodes in l.
+func packagesOnly(l *list.List) *list.List {
+	pkgs := list.New()
+	for _, n := range listSeq(l) {
+		if !n.isTest {
+			pkgs.PushBack(n)
+		}
+	}
+	return pkgs
+}
+
+func (m *model) printDots(n *node, writer io.Writer) {
+	fmt.Fprintf(writer, "%s %s\t%s\n", m.icon(n), displayName(n.name), strings.Join(n.dots, ""))
+}
+
+// finalViews are the valid values of -view, the format of the final summary.
+var finalViews = []string{"tree", "starts"}
+
+// printStarts prints every package and test in the order they started, with
+// their start times relative to the start of the run, for the starts view.  This
+// shows how go test scheduled the packages, and which tests ran alongside a
+// failure.
+func (m *model) printStarts(w io.Writer) {
+	var nodes []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range append(n.children, n.dropped...) {
+			nodes = append(nodes, c)
+			walk(c)
+		}
+	}
+	walk(&m.root)
+	slices.SortStableFunc(nodes, func(a, b *node) int {
+		if c := cmp.Compare(a.startOffset, b.startOffset); c != 0 {
+			return c
+		}
+		return a.firstStart.Compare(b.firstStart)
+	})
+
+	for _, n := range nodes {
+		name := n.name
+		if n.isTest {
+			name = n.pkg().name + " " + n.testName()
+		}
+		fmt.Fprintf(w, "+%s\t%s %s\t%s\n", round(n.startOffset, 3), m.icon(n), displayName(name), formatElapsed(n.elapsed, 0, 3))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewModes(t *testing.T) {
+	m := newModel()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	feed(m, []TestEvent{
+		{Action: "start", Package: "a"},
+		{Action: "run", Package: "a", Test: "TestA"},
+		{Action: "pass", Package: "a", Test: "TestA"},
+		{Action: "run", Package: "a", Test: "TestB"},
+		{Action: "fail", Package: "a", Test: "TestB"},
+		{Action: "run", Package: "a", Test: "TestC"},
+	}...)
+
+	assert.Contains(t, m.View(), "TestC")
+
+	m.viewMode = m.viewMode.next()
+	assert.Equal(t, viewCompact, m.viewMode)
+	assert.NotContains(t, m.View(), "TestC")
+	assert.Contains(t, m.View(), "a")
+
+	m.viewMode = m.viewMode.next()
+	assert.Equal(t, viewDots, m.viewMode)
+	assert.Contains(t, m.View(), "a\t·✖")
+
+	// the final summary is always a tree
+	assert.Contains(t, m.String(), "TestB")
+
+	assert.Equal(t, viewTree, m.viewMode.next())
+}
+
+func TestStartsView(t *testing.T) {
+	keepFlags(t)
+	flags.finalView = "starts"
+
+	start := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	m := newModel()
+	m.start = start
+	m.runStart = start
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	feed(m, []TestEvent{
+		{Time: at(0), Action: "start", Package: "a"},
+		{Time: at(100 * time.Millisecond), Action: "run", Package: "a", Test: "TestA"},
+		{Time: at(200 * time.Millisecond), Action: "start", Package: "b"},
+		{Time: at(300 * time.Millisecond), Action: "run", Package: "b", Test: "TestB"},
+		{Time: at(400 * time.Millisecond), Action: "pass", Package: "b", Test: "TestB", Elapsed: 0.1},
+		{Time: at(500 * time.Millisecond), Action: "pass", Package: "a", Test: "TestA", Elapsed: 0.4},
+		{Time: at(600 * time.Millisecond), Action: "pass", Package: "b", Elapsed: 0.4},
+		{Time: at(700 * time.Millisecond), Action: "pass", Package: "a", Elapsed: 0.7},
+	}...)
+	m.done = true
+	m.root.processChildren(true, true)
+
+	out := m.String()
+	assert.Contains(t, out, "+0s\t✓ a\t700ms\n"+
+		"+100ms\t✓ a TestA\t400ms\n"+
+		"+200ms\t✓ b\t400ms\n"+
+		"+300ms\t✓ b TestB\t100ms\n")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// webInterval throttles how often the -web page is updated.
+const webInterval = 250 * time.Millisecond
+
+// ansiPattern matches terminal escape sequences: CSI sequences, like colors, and
+// OSC sequences, like hyperlinks.
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(\x07|\x1b\\\\)")
+
+// webServer mirrors the test tree and summary to browsers, see -web.  Each page
+// holds an event stream, which is sent the whole tree as plain text on every update.
+type webServer struct {
+	mu      sync.Mutex
+	frame   string
+	clients map[chan string]bool
+}
+
+func newWebServer() *webServer {
+	return &webServer{clients: map[chan string]bool{}}
+}
+
+// startWeb starts serving the page on addr, and returns the URL it's served at.
+func startWeb(addr string) (*webServer, string, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", err
+	}
+	w := newWebServer()
+	go func() {
+		_ = http.Serve(l, w.handler())
+	}()
+	return w, "http://" + l.Addr().String(), nil
+}
+
+func (w *webServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = rw.Write([]byte(webPage))
+	})
+	mux.HandleFunc("/events", w.serveEvents)
+	return mux
+}
+
+// serveEvents streams the frames as server-sent events, starting with the current one.
+func (w *webServer) serveEvents(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+
+	frames := make(chan string, 1)
+	w.mu.Lock()
+	w.clients[frames] = true
+	if w.frame != "" {
+		frames <- w.frame
+	}
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.clients, frames)
+		w.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case frame := <-frames:
+			data, _ := json.Marshal(frame)
+			if _, err := fmt.Fprintf(rw, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// publish sends the frame to the pages.  Slow pages skip to the latest frame.
+func (w *webServer) publish(frame string) {
+	frame = ansiPattern.ReplaceAllString(frame, "")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if frame == w.frame {
+		return
+	}
+	w.frame = frame
+	for c := range w.clients {
+		select {
+		case <-c:
+		default:
+		}
+		c <- frame
+	}
+}
+
+// updateWeb sends the tree to the -web page, if enabled.  Unless force is set,
+// updates are throttled.
+func (m *model) updateWeb(force bool) {
+	if m.web == nil || (!force && since(m.webSent) < webInterval) {
+		return
+	}
+	m.webSent = now()
+	m.web.publish(m.renderSafely(false))
+}
+
+const webPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gotestpretty</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+pre { font-size: 14px; line-height: 1.3; }
+.pass { color: green; }
+.fail { color: red; }
+.skip { color: darkgoldenrod; }
+#status { color: gray; }
+</style>
+</head>
+<body>
+<div id="status">connecting…</div>
+<pre id="tree"></pre>
+<script>
+const tree = document.getElementById("tree");
+const status = document.getElementById("status");
+const classes = [["✖", "fail"], ["⊘", "fail"], ["☠", "fail"], ["⚒", "fail"], ["⇄", "fail"], ["FAILED", "fail"], ["✓", "pass"], ["PASSED", "pass"], ["⍉", "skip"]];
+const events = new EventSource("/events");
+events.onopen = () => { status.textContent = "live"; };
+events.onerror = () => { status.textContent = "disconnected, the run may have finished"; };
+events.onmessage = (e) => {
+  tree.replaceChildren(...JSON.parse(e.data).split("\n").map((line) => {
+    const span = document.createElement("span");
+    span.textContent = line + "\n";
+    const c = classes.find(([icon]) => line.trimStart().startsWith(icon));
+    if (c) span.className = c[1];
+    return span;
+  }));
+};
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextFrame reads the next server-sent event, and decodes its frame.
+func nextFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+	require.True(t, ok, line)
+	_, err = r.ReadString('\n')
+	require.NoError(t, err)
+	var frame string
+	require.NoError(t, json.Unmarshal([]byte(data), &frame))
+	return frame
+}
+
+func TestWebServer(t *testing.T) {
+	w := newWebServer()
+	srv := httptest.NewServer(w.handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, string(page), `new EventSource("/events")`)
+
+	resp, err = http.Get(srv.URL + "/missing")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	m := newModel()
+	m.web = w
+	m.processEvent(TestEvent{Action: "start", Package: "a"})
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestA"})
+	m.processEvent(TestEvent{Action: "fail", Package: "a", Test: "TestA"})
+	m.updateWeb(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/events", nil)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	events := bufio.NewReader(resp.Body)
+
+	// the current frame is sent first
+	assert.Contains(t, nextFrame(t, events), "TestA")
+
+	// throttled
+	m.processEvent(TestEvent{Action: "run", Package: "a", Test: "TestB"})
+	m.updateWeb(false)
+	m.updateWeb(true)
+	frame := nextFrame(t, events)
+	assert.Contains(t, frame, "TestB")
+}
+
+func TestWebPublishStripsEscapes(t *testing.T) {
+	w := newWebServer()
+	w.publish("\x1b[1;31m✖\x1b[0m \x1b]8;;file:///a_test.go\x07a_test.go\x1b]8;;\x07")
+	assert.Equal(t, "✖ a_test.go", w.frame)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// goTestRun is `go test -json` run by gotestpretty in wrapper mode, e.g.
+//
+//	gotestpretty ./... -- -run TestFoo -count=1
+//
+// The command's stdout and stderr are combined, like `2>&1`, so build errors are
+// shown.  Stdin is left to the live view, for keys.
+type goTestRun struct {
+	cmd *exec.Cmd
+	out *io.PipeReader
+	// testFlags are the go test flags, without -json
+	testFlags []string
+
+	// stopping is set to stop starting packages, see startGoTestPackages
+	stopping atomic.Bool
+	// done is closed once every go test has exited
+	done chan struct{}
+
+	mu       sync.Mutex
+	exitCode int
+	err      error
+	// skipped are the packages which weren't started because of stopping
+	skipped []string
+	// running are the go test commands which haven't exited, and killed is set
+	// by kill, so no more are started
+	running map[*exec.Cmd]bool
+	killed  bool
+}
+
+// splitWrapperArgs splits the arguments after the flags into the packages, and the
+// go test flags after "--".  If there are no packages, the "--" is optional.
+func splitWrapperArgs(args []string) (pkgs, testFlags []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+		if strings.HasPrefix(arg, "-") {
+			// package patterns never start with "-", so these must be flags
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
+}
+
+func newGoTestRun(out *io.PipeReader, testFlags []string) *goTestRun {
+	return &goTestRun{out: out, testFlags: testFlags, done: make(chan struct{}), running: map[*exec.Cmd]bool{}}
+}
+
+func startGoTest(pkgs, testFlags []string) (*goTestRun, error) {
+	args := append([]string{"test", "-json"}, testFlags...)
+	args = append(args, pkgs...)
+	r, w := io.Pipe()
+	run := newGoTestRun(r, testFlags)
+	run.cmd = exec.Command("go", args...)
+	run.cmd.Stdout = w
+	run.cmd.Stderr = w
+	setProcessGroup(run.cmd)
+	if err := run.cmd.Start(); err != nil {
+		return nil, err
+	}
+	run.running[run.cmd] = true
+	go func() {
+		err := run.cmd.Wait()
+		run.mu.Lock()
+		delete(run.running, run.cmd)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			run.exitCode = exitErr.ExitCode()
+		} else {
+			run.err = err
+		}
+		run.mu.Unlock()
+		_ = w.Close()
+		close(run.done)
+	}()
+	return run, nil
+}
+
+// startGoTestPackages is startGoTest, but runs each package with its own go test,
+// up to GOMAXPROCS at once like go test does, so that it can stop starting
+// packages once stopStarting is called, for -stop-over-budget.  The packages
+// are import paths, not patterns.
+func startGoTestPackages(pkgs, testFlags []string) *goTestRun {
+	r, w := io.Pipe()
+	run := newGoTestRun(r, testFlags)
+	go func() {
+		var wg sync.WaitGroup
+		// the packages' output is interleaved a line at a time
+		var lines sync.Mutex
+		slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+		for i, pkg := range pkgs {
+			slots <- struct{}{}
+			if run.stopping.Load() {
+				run.mu.Lock()
+				run.skipped = pkgs[i:]
+				run.mu.Unlock()
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				run.runPackage(pkg, w, &lines)
+			}()
+		}
+		wg.Wait()
+		_ = w.Close()
+		close(run.done)
+	}()
+	return run
+}
+
+// runPackage runs go test on one package, copying its output to w a line at a time,
+// and keeps the highest exit code.
+func (r *goTestRun) runPackage(pkg string, w io.Writer, lines *sync.Mutex) {
+	args := append([]string{"test", "-json"}, r.testFlags...)
+	cmd := exec.Command("go", append(args, pkg)...)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	setProcessGroup(cmd)
+	r.mu.Lock()
+	if r.killed {
+		r.mu.Unlock()
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		r.err = err
+		r.mu.Unlock()
+		return
+	}
+	r.running[cmd] = true
+	r.mu.Unlock()
+	go func() {
+		err := cmd.Wait()
+		r.mu.Lock()
+		delete(r.running, cmd)
+		r.mu.Unlock()
+		_ = pw.CloseWithError(err)
+	}()
+	br := bufio.NewReader(pr)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' {
+				line = append(line, '\n')
+			}
+			lines.Lock()
+			_, _ = w.Write(line)
+			lines.Unlock()
+		}
+		if err != nil {
+			var exitErr *exec.ExitError
+			r.mu.Lock()
+			if errors.As(err, &exitErr) {
+				r.exitCode = max(r.exitCode, exitErr.ExitCode())
+			} else if err != io.EOF {
+				r.err = err
+			}
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+// stopStarting stops a run started by startGoTestPackages from starting any more
+// packages.  The packages already running finish.
+func (r *goTestRun) stopStarting() {
+	r.stopping.Store(true)
+}
+
+// kill stops the run early: it kills the go test commands still running, and
+// starts no more.
+func (r *goTestRun) kill() {
+	r.stopStarting()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.killed = true
+	for cmd := range r.running {
+		_ = killGroup(cmd.Process)
+	}
+}
+
+// quit sends SIGQUIT to the go test commands still running, see sendQuit.
+func (r *goTestRun) quit() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for cmd := range r.running {
+		errs = append(errs, sendQuit(cmd.Process))
+	}
+	return errors.Join(errs...)
+}
+
+// notStarted returns the packages which weren't run because of stopStarting.  Only
+// valid once its output has been read to the end.
+func (r *goTestRun) notStarted() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skipped
+}
+
+func (r *goTestRun) Read(p []byte) (int, error) {
+	return r.out.Read(p)
+}
+
+// result waits for go test to exit, and returns its exit code, or an error if it
+// couldn't be run.
+func (r *goTestRun) result() (int, error) {
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exitCode, r.err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWrapperArgs(t *testing.T) {
+	tests := []struct {
+		args            []string
+		pkgs, testFlags []string
+	}{
+		{[]string{"./..."}, []string{"./..."}, nil},
+		{[]string{"./a", "./b", "--", "-run", "TestFoo"}, []string{"./a", "./b"}, []string{"-run", "TestFoo"}},
+		{[]string{"-run", "TestFoo"}, []string{}, []string{"-run", "TestFoo"}},
+		{[]string{"./...", "--"}, []string{"./..."}, []string{}},
+	}
+	for _, tt := range tests {
+		pkgs, testFlags := splitWrapperArgs(tt.args)
+		assert.Equal(t, tt.pkgs, pkgs, "%v", tt.args)
+		assert.Equal(t, tt.testFlags, testFlags, "%v", tt.args)
+	}
+}
+
+func TestStartGoTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	run, err := startGoTest([]string{"."}, []string{"-run", "^$"})
+	require.NoError(t, err)
+	out, err := io.ReadAll(run)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"Action":"pass"`)
+
+	code, err := run.result()
+	require.NoError(t, err)
+	assert.Equal(t, 0, code)
+
+	run, err = startGoTest([]string{"."}, []string{"-notaflag"})
+	require.NoError(t, err)
+	_, _ = io.ReadAll(run)
+	code, err = run.result()
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, code)
+}
+
+func TestGoTestKill(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	run, err := startGoTest([]string{"."}, []string{"-run", "^$", "-count=1"})
+	require.NoError(t, err)
+	run.kill()
+	_, _ = io.ReadAll(run)
+	code, _ := run.result()
+	assert.NotEqual(t, 0, code)
+}
+
+func TestStartGoTestPackages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+	run := startGoTestPackages([]string{"github.com/ansel1/gotestpretty", "github.com/ansel1/gotestpretty/pretty"}, []string{"-run", "^$"})
+	out, err := io.ReadAll(run)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"Action":"pass","Package":"github.com/ansel1/gotestpretty",`)
+	assert.Contains(t, string(out), `"Action":"pass","Package":"github.com/ansel1/gotestpretty/pretty"`)
+
+	code, err := run.result()
+	require.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Empty(t, run.notStarted())
+
+	run = startGoTestPackages([]string{"github.com/ansel1/gotestpretty"}, []string{"-notaflag"})
+	_, _ = io.ReadAll(run)
+	code, err = run.result()
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, code)
+}